@@ -0,0 +1,203 @@
+package balltree
+
+import (
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// DefaultLeafSize is used when a non-positive leaf size is given.
+const DefaultLeafSize = 16
+
+// Tree is a ball tree: every node is a ball (a centre and a radius) enclosing
+// all of the Datapoints beneath it. Unlike the axis-aligned cells of a k-d
+// tree, balls adapt to the intrinsic shape of the data, so pruning stays
+// effective in the tens to hundreds of dimensions where k-d trees degrade to a
+// linear scan. Pruning relies only on the triangle inequality, so any
+// metric.Func which is a true metric may be used.
+type Tree struct {
+	root     *node
+	m        metric.Func
+	dims     int
+	size     int
+	leafSize int
+}
+
+type node struct {
+	centre      *kdtree.Datapoint
+	radius      float64
+	points      kdtree.Datapoints // only at the leaves
+	left, right *node
+}
+
+// Build constructs a Tree over ds using the metric m (metric.Euclidean when nil),
+// holding at most leafSize Datapoints in each leaf.
+// All Datapoints must share the same dimensionality.
+func Build(ds kdtree.Datapoints, m metric.Func, leafSize int) (*Tree, error) {
+	if m == nil {
+		m = metric.Euclidean
+	}
+	if leafSize <= 0 {
+		leafSize = DefaultLeafSize
+	}
+	t := &Tree{m: m, size: len(ds), leafSize: leafSize}
+	if len(ds) == 0 {
+		return t, nil
+	}
+	t.dims = ds[0].Dimensionality()
+	points := make(kdtree.Datapoints, len(ds))
+	for i, d := range ds {
+		if d == nil || d.Dimensionality() != t.dims {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+		points[i] = d
+	}
+	t.root = t.build(points)
+	return t, nil
+}
+
+// build encloses ds in a ball about its centroid, then splits it between the
+// two Datapoints which are (approximately) furthest apart.
+func (t *Tree) build(ds kdtree.Datapoints) *node {
+	n := &node{centre: centroid(ds, t.dims)}
+	var far *kdtree.Datapoint
+	for _, d := range ds {
+		if r := t.m(n.centre, d); r >= n.radius {
+			n.radius, far = r, d
+		}
+	}
+	if len(ds) <= t.leafSize || n.radius == 0 {
+		n.points = ds
+		return n
+	}
+
+	a, b := far, far
+	var spread float64
+	for _, d := range ds {
+		if r := t.m(a, d); r > spread {
+			spread, b = r, d
+		}
+	}
+	// order by how much closer each Datapoint is to a than to b, then halve
+	closer := make([]float64, len(ds))
+	for i, d := range ds {
+		closer[i] = t.m(a, d) - t.m(b, d)
+	}
+	sort.Sort(byCloseness{ds, closer})
+	mid := len(ds) / 2
+	n.left = t.build(ds[:mid])
+	n.right = t.build(ds[mid:])
+	return n
+}
+
+func centroid(ds kdtree.Datapoints, dims int) *kdtree.Datapoint {
+	c := make([]float64, dims)
+	for _, d := range ds {
+		for axis := range c {
+			c[axis] += d.At(axis)
+		}
+	}
+	for axis := range c {
+		c[axis] /= float64(len(ds))
+	}
+	return kdtree.NewDatapoint(nil, c)
+}
+
+// Len returns the number of Datapoints held.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Dims returns the dimensionality of the Datapoints held.
+func (t *Tree) Dims() int {
+	return t.dims
+}
+
+// NN returns the nearest neighbour of the target under the Tree's metric,
+// or nil if the Tree is empty.
+func (t *Tree) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := t.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k nearest neighbours of the target under the Tree's metric, nearest first.
+func (t *Tree) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if t.root == nil || k <= 0 || target == nil || target.Dimensionality() != t.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	t.knn(t.root, target, t.m(target, t.root.centre), set)
+	sorted := set.Sorted()
+	ds := make(kdtree.Datapoints, len(sorted))
+	for i := range sorted {
+		ds[i] = sorted[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
+
+// knn searches n, given the distance from the target to its centre.
+func (t *Tree) knn(n *node, target *kdtree.Datapoint, toCentre float64, set *nearest.Set) {
+	if toCentre-n.radius >= set.Worst() {
+		return
+	}
+	if n.points != nil {
+		for _, d := range n.points {
+			set.Push(d, t.m(target, d))
+		}
+		return
+	}
+	toLeft, toRight := t.m(target, n.left.centre), t.m(target, n.right.centre)
+	if toLeft <= toRight {
+		t.knn(n.left, target, toLeft, set)
+		t.knn(n.right, target, toRight, set)
+	} else {
+		t.knn(n.right, target, toRight, set)
+		t.knn(n.left, target, toLeft, set)
+	}
+}
+
+// Radius returns every Datapoint within distance r of the target under the
+// Tree's metric (inclusive).
+func (t *Tree) Radius(target *kdtree.Datapoint, r float64) kdtree.Datapoints {
+	if t.root == nil || target == nil || target.Dimensionality() != t.dims {
+		return nil
+	}
+	var found kdtree.Datapoints
+	t.radiusSearch(t.root, target, r, &found)
+	return found
+}
+
+func (t *Tree) radiusSearch(n *node, target *kdtree.Datapoint, r float64, found *kdtree.Datapoints) {
+	toCentre := t.m(target, n.centre)
+	if toCentre-n.radius > r {
+		return
+	}
+	if n.points != nil {
+		whole := toCentre+n.radius <= r // the ball lies entirely within the query
+		for _, d := range n.points {
+			if whole || t.m(target, d) <= r {
+				*found = append(*found, d)
+			}
+		}
+		return
+	}
+	t.radiusSearch(n.left, target, r, found)
+	t.radiusSearch(n.right, target, r, found)
+}
+
+type byCloseness struct {
+	ds     kdtree.Datapoints
+	closer []float64
+}
+
+func (b byCloseness) Len() int           { return len(b.ds) }
+func (b byCloseness) Less(i, j int) bool { return b.closer[i] < b.closer[j] }
+func (b byCloseness) Swap(i, j int) {
+	b.ds[i], b.ds[j] = b.ds[j], b.ds[i]
+	b.closer[i], b.closer[j] = b.closer[j], b.closer[i]
+}
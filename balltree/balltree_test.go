@@ -0,0 +1,112 @@
+package balltree
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/testutil"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// clustered produces Datapoints in dims dimensions lying near a few centres,
+// which is typical of real high-dimensional data (e.g. feature vectors).
+func clustered(r *rand.Rand, n, dims int) kdtree.Datapoints {
+	return testutil.Clustered(r, n, testutil.Centres(r, 8, dims, testutil.Uniform(0, 10)), 1)
+}
+
+func Test_BallTree_Matches_Brute_Force(t *testing.T) {
+	r := rand.New(rand.NewSource(60))
+	for _, dims := range []int{1, 3, 20, 64} {
+		ds := clustered(r, 800, dims)
+		metrics := []metric.Func{metric.Euclidean, metric.Manhattan, metric.Chebyshev}
+		if dims > 1 { // in 1-D every angle is 0 or π, and rounding decides ties
+			metrics = append(metrics, metric.Angular)
+		}
+		for _, m := range metrics {
+			tree, err := Build(ds, m, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for q := 0; q < 20; q++ {
+				target := clustered(r, 1, dims)[0]
+				dists := make([]float64, len(ds))
+				for i := range ds {
+					dists[i] = m(target, ds[i])
+				}
+				sort.Float64s(dists)
+
+				got := tree.KNN(target, 10)
+				for i := range got {
+					if m(target, got[i]) != dists[i] {
+						t.Fatal(`dims=`, dims, ` KNN disagreed at rank `, i)
+					}
+				}
+				radius := dists[25]
+				want := sort.Search(len(dists), func(i int) bool { return dists[i] > radius })
+				if got := len(tree.Radius(target, radius)); got != want {
+					t.Error(`dims=`, dims, ` Radius want: `, want, `
+					got: `, got)
+				}
+			}
+		}
+	}
+}
+
+func Test_BallTree_Duplicates_And_Errors(t *testing.T) {
+	var ds kdtree.Datapoints
+	for i := 0; i < 100; i++ {
+		ds = append(ds, kdtree.NewDatapoint(i, []float64{1, 1}))
+	}
+	tree, err := Build(ds, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(tree.KNN(kdtree.NewDatapoint(nil, []float64{0, 0}), 7)); got != 7 {
+		t.Error(`want: 7
+		got: `, got)
+	}
+	if tree.NN(nil) != nil || tree.KNN(nil, 1) != nil || tree.Radius(nil, 1) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	ds = append(ds, kdtree.NewDatapoint(nil, []float64{1}))
+	if _, err := Build(ds, nil, 4); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
+
+// The benchmarks compare exact KNN on a ball tree against the k-d tree as the
+// dimensionality grows: the two are level in a couple of dimensions, but on
+// clustered data the ball tree is several times faster from around 8 to 32
+// dimensions, by which point the k-d tree visits nearly every leaf.
+
+var benchmarkDims = []int{2, 8, 32, 64, 128}
+
+func Benchmark_BallTree_KNN(b *testing.B) {
+	for _, dims := range benchmarkDims {
+		r := rand.New(rand.NewSource(1))
+		tree, _ := Build(clustered(r, 10000, dims), metric.Euclidean, 0)
+		targets := clustered(r, 100, dims)
+		b.Run(fmt.Sprint("dims=", dims), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.KNN(targets[i%len(targets)], 10)
+			}
+		})
+	}
+}
+
+func Benchmark_KdTree_KNN(b *testing.B) {
+	for _, dims := range benchmarkDims {
+		r := rand.New(rand.NewSource(1))
+		tree, _ := kdtree.NewTree(clustered(r, 10000, dims), kdtree.Median)
+		targets := clustered(r, 100, dims)
+		b.Run(fmt.Sprint("dims=", dims), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tree.KNN(targets[i%len(targets)], 10)
+			}
+		})
+	}
+}
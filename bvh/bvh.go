@@ -0,0 +1,346 @@
+package bvh
+
+import (
+	"math"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/geom"
+)
+
+// DefaultLeafSize is used when a non-positive leaf size is given.
+const DefaultLeafSize = 4
+
+// DefaultBins is the number of buckets used by the SAH SplitFunc.
+const DefaultBins = 12
+
+// SplitFunc chooses where to divide a set of Primitives when building a BVH:
+// the axis and the value along it, where Primitives whose Bounds' Centroid
+// lies below the value go left and the rest go right. Reporting ok as false
+// asks for the Primitives to be kept together as a leaf instead.
+type SplitFunc func(ps []Primitive) (axis int, pivot float64, ok bool)
+
+// Set of pre-defined functions which match the prototype of `SplitFunc`
+var (
+	// SAH implements a binned surface area heuristic split, which minimises
+	// the expected cost of a ray query and gives the fastest trees,
+	// at the price of a slower build. Traversing a node is costed at an
+	// eighth of intersecting a Primitive.
+	SAH = NewSAH(DefaultBins, 0.125)
+
+	// Midpoint implements a fast spatial median split, halving the extent of
+	// the centroids along their longest axis.
+	Midpoint = func(ps []Primitive) (int, float64, bool) {
+		cb := centroidBounds(ps)
+		axis := cb.LongestAxis()
+		return axis, (cb.Min[axis] + cb.Max[axis]) / 2, true
+	}
+
+	// Median implements an object median split along the longest axis,
+	// giving a balanced tree regardless of how the Primitives are distributed.
+	Median = func(ps []Primitive) (int, float64, bool) {
+		axis := centroidBounds(ps).LongestAxis()
+		values := make([]float64, len(ps))
+		for i, p := range ps {
+			values[i] = p.Bounds().Centroid()[axis]
+		}
+		sort.Float64s(values)
+		return axis, values[len(values)/2], true
+	}
+)
+
+// NewSAH returns a SplitFunc using the surface area heuristic over the given
+// number of buckets per axis. The cost of a split is traversal plus, for each
+// side, the number of Primitives weighted by the fraction of the parent's
+// surface area it covers; a leaf costs the number of its Primitives.
+// A higher traversal cost therefore produces shallower trees with larger leaves.
+func NewSAH(bins int, traversal float64) SplitFunc {
+	if bins < 2 {
+		bins = DefaultBins
+	}
+	return func(ps []Primitive) (int, float64, bool) {
+		cb := centroidBounds(ps)
+		parent := geom.EmptyBox()
+		for _, p := range ps {
+			parent = parent.Union(p.Bounds())
+		}
+		area := parent.SurfaceArea()
+		bestCost, bestAxis, bestPivot := float64(len(ps)), 0, 0.0
+		found := false
+
+		for axis := 0; axis < 3; axis++ {
+			extent := cb.Max[axis] - cb.Min[axis]
+			if extent <= 0 {
+				continue
+			}
+			counts := make([]int, bins)
+			boxes := make([]geom.Box, bins)
+			for i := range boxes {
+				boxes[i] = geom.EmptyBox()
+			}
+			for _, p := range ps {
+				b := p.Bounds()
+				i := bucket(b.Centroid()[axis], cb.Min[axis], extent, bins)
+				counts[i]++
+				boxes[i] = boxes[i].Union(b)
+			}
+			// sweep from the right to find the cost of every right-hand side
+			rightArea := make([]float64, bins)
+			rightCount := make([]int, bins)
+			acc, n := geom.EmptyBox(), 0
+			for i := bins - 1; i > 0; i-- {
+				acc, n = acc.Union(boxes[i]), n+counts[i]
+				rightArea[i], rightCount[i] = acc.SurfaceArea(), n
+			}
+			acc, n = geom.EmptyBox(), 0
+			for i := 0; i < bins-1; i++ {
+				acc, n = acc.Union(boxes[i]), n+counts[i]
+				if n == 0 || rightCount[i+1] == 0 {
+					continue
+				}
+				cost := traversal
+				if area > 0 {
+					cost += (acc.SurfaceArea()*float64(n) + rightArea[i+1]*float64(rightCount[i+1])) / area
+				}
+				if cost < bestCost {
+					bestCost, bestAxis, found = cost, axis, true
+					bestPivot = cb.Min[axis] + extent*float64(i+1)/float64(bins)
+				}
+			}
+		}
+		return bestAxis, bestPivot, found
+	}
+}
+
+// bucket returns the index of the bucket which value falls into.
+func bucket(value, min, extent float64, bins int) int {
+	i := int(float64(bins) * (value - min) / extent)
+	if i >= bins {
+		i = bins - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// centroidBounds returns the Box enclosing the centroids of the Primitives' Bounds.
+func centroidBounds(ps []Primitive) geom.Box {
+	cb := geom.EmptyBox()
+	for _, p := range ps {
+		cb = cb.Extend(p.Bounds().Centroid())
+	}
+	return cb
+}
+
+// BVH is a bounding volume hierarchy: a binary tree in which every node holds
+// the Box enclosing all of the Primitives beneath it, and the leaves hold the
+// Primitives themselves. How the Primitives are divided at each node is
+// decided by a SplitFunc, in the same manner as the PivotFunc of a k-d tree.
+type BVH struct {
+	root *node
+	size int
+}
+
+type node struct {
+	box         geom.Box
+	prims       []Primitive // only at the leaves
+	left, right *node
+}
+
+// Hit is a Primitive met by a Ray at parameter T.
+type Hit struct {
+	Primitive Primitive
+	T         float64
+}
+
+// Build constructs a BVH over a copy of ps, dividing the Primitives with the
+// given SplitFunc (SAH when nil) until at most leafSize remain in each leaf.
+func Build(ps []Primitive, split SplitFunc, leafSize int) *BVH {
+	if split == nil {
+		split = SAH
+	}
+	if leafSize <= 0 {
+		leafSize = DefaultLeafSize
+	}
+	bvh := &BVH{size: len(ps)}
+	if len(ps) > 0 {
+		prims := make([]Primitive, len(ps))
+		copy(prims, ps)
+		bvh.root = build(prims, split, leafSize)
+	}
+	return bvh
+}
+
+func build(ps []Primitive, split SplitFunc, leafSize int) *node {
+	n := &node{box: geom.EmptyBox()}
+	for _, p := range ps {
+		n.box = n.box.Union(p.Bounds())
+	}
+	if len(ps) <= leafSize {
+		n.prims = ps
+		return n
+	}
+	axis, pivot, ok := split(ps)
+	if !ok {
+		n.prims = ps
+		return n
+	}
+	mid := 0
+	for i, p := range ps {
+		if p.Bounds().Centroid()[axis] < pivot {
+			ps[i], ps[mid] = ps[mid], ps[i]
+			mid++
+		}
+	}
+	if mid == 0 || mid == len(ps) {
+		// the split left one side empty, so fall back to halving by count
+		sort.Slice(ps, func(i, j int) bool {
+			return ps[i].Bounds().Centroid()[axis] < ps[j].Bounds().Centroid()[axis]
+		})
+		mid = len(ps) / 2
+	}
+	n.left = build(ps[:mid], split, leafSize)
+	n.right = build(ps[mid:], split, leafSize)
+	return n
+}
+
+// Len returns the number of Primitives held.
+func (bvh *BVH) Len() int {
+	return bvh.size
+}
+
+// Bounds returns the Box enclosing every Primitive held.
+func (bvh *BVH) Bounds() geom.Box {
+	if bvh.root == nil {
+		return geom.EmptyBox()
+	}
+	return bvh.root.box
+}
+
+// FirstHit returns the Primitive which the Ray meets first, and whether it meets any.
+func (bvh *BVH) FirstHit(r geom.Ray) (Hit, bool) {
+	best := Hit{T: math.Inf(1)}
+	if bvh.root != nil {
+		bvh.root.firstHit(r, &best)
+	}
+	return best, best.Primitive != nil
+}
+
+func (n *node) firstHit(r geom.Ray, best *Hit) {
+	if n.prims != nil {
+		for _, p := range n.prims {
+			if t, ok := p.Intersect(r); ok && t < best.T {
+				*best = Hit{p, t}
+			}
+		}
+		return
+	}
+	near, far := n.left, n.right
+	tNear, okNear := enters(r, near.box, best.T)
+	tFar, okFar := enters(r, far.box, best.T)
+	if okFar && (!okNear || tFar < tNear) {
+		near, far = far, near
+		tNear, tFar = tFar, tNear
+		okNear, okFar = okFar, okNear
+	}
+	if okNear {
+		near.firstHit(r, best)
+	}
+	if okFar && tFar <= best.T {
+		far.firstHit(r, best)
+	}
+}
+
+// AllHits returns every Primitive which the Ray meets, nearest first.
+func (bvh *BVH) AllHits(r geom.Ray) []Hit {
+	var hits []Hit
+	if bvh.root != nil {
+		bvh.root.allHits(r, &hits)
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].T < hits[j].T })
+	return hits
+}
+
+func (n *node) allHits(r geom.Ray, hits *[]Hit) {
+	if _, ok := r.IntersectBox(n.box); !ok {
+		return
+	}
+	if n.prims != nil {
+		for _, p := range n.prims {
+			if t, ok := p.Intersect(r); ok {
+				*hits = append(*hits, Hit{p, t})
+			}
+		}
+		return
+	}
+	n.left.allHits(r, hits)
+	n.right.allHits(r, hits)
+}
+
+// ClosestPoint returns the point on any held Primitive nearest to p, and the
+// Primitive it lies on, which is nil if the BVH is empty.
+func (bvh *BVH) ClosestPoint(p geom.Vec3) (geom.Vec3, Primitive) {
+	var closest geom.Vec3
+	var prim Primitive
+	best := math.Inf(1)
+	if bvh.root != nil {
+		bvh.root.closestPoint(p, &closest, &prim, &best)
+	}
+	return closest, prim
+}
+
+func (n *node) closestPoint(p geom.Vec3, closest *geom.Vec3, prim *Primitive, best *float64) {
+	if n.prims != nil {
+		for _, candidate := range n.prims {
+			q := candidate.ClosestPoint(p)
+			if d := p.DistSq(q); d < *best {
+				*closest, *prim, *best = q, candidate, d
+			}
+		}
+		return
+	}
+	near, far := n.left, n.right
+	dNear, dFar := near.box.DistSq(p), far.box.DistSq(p)
+	if dFar < dNear {
+		near, far = far, near
+		dNear, dFar = dFar, dNear
+	}
+	if dNear < *best {
+		near.closestPoint(p, closest, prim, best)
+	}
+	if dFar < *best {
+		far.closestPoint(p, closest, prim, best)
+	}
+}
+
+// Overlapping returns every Primitive whose Bounds overlap the Box.
+func (bvh *BVH) Overlapping(b geom.Box) []Primitive {
+	var found []Primitive
+	if bvh.root != nil {
+		bvh.root.overlapping(b, &found)
+	}
+	return found
+}
+
+func (n *node) overlapping(b geom.Box, found *[]Primitive) {
+	if !n.box.Overlaps(b) {
+		return
+	}
+	if n.prims != nil {
+		for _, p := range n.prims {
+			if p.Bounds().Overlaps(b) {
+				*found = append(*found, p)
+			}
+		}
+		return
+	}
+	n.left.overlapping(b, found)
+	n.right.overlapping(b, found)
+}
+
+// enters returns the parameter at which the Ray enters the Box,
+// and whether it does so no later than limit.
+func enters(r geom.Ray, b geom.Box, limit float64) (float64, bool) {
+	t, ok := r.IntersectBox(b)
+	return t, ok && t <= limit
+}
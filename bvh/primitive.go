@@ -0,0 +1,47 @@
+package bvh
+
+import "github.com/benjamin-rood/goeometric/geom"
+
+// Primitive is the interface implemented by anything a BVH can hold.
+type Primitive interface {
+	// Bounds returns a Box enclosing the Primitive.
+	Bounds() geom.Box
+	// Intersect returns the smallest parameter t >= 0 at which the Ray meets
+	// the Primitive, and whether it does so at all.
+	Intersect(r geom.Ray) (t float64, ok bool)
+	// ClosestPoint returns the point of the Primitive nearest to p.
+	ClosestPoint(p geom.Vec3) geom.Vec3
+}
+
+// Triangle is a Primitive with three vertices and a pointer to any other
+// structure or type which you may wish to associate with it, such as a face
+// index or material.
+type Triangle struct {
+	geom.Triangle
+	data interface{} // ideally a pointer to some other associated thing
+}
+
+// NewTriangle is an explicit constructor for a Triangle.
+func NewTriangle(data interface{}, a, b, c geom.Vec3) *Triangle {
+	return &Triangle{Triangle: geom.Triangle{A: a, B: b, C: c}, data: data}
+}
+
+// Mesh returns a Triangle for each face, given as indices into vertices.
+// Each Triangle's data is the index of its face.
+func Mesh(vertices []geom.Vec3, faces [][3]int) []Primitive {
+	ps := make([]Primitive, len(faces))
+	for i, f := range faces {
+		ps[i] = NewTriangle(i, vertices[f[0]], vertices[f[1]], vertices[f[2]])
+	}
+	return ps
+}
+
+// Data returns the interface value of the object that the Triangle is linked with.
+func (tr *Triangle) Data() interface{} {
+	return tr.data
+}
+
+// Intersect returns the parameter at which the Ray meets the Triangle.
+func (tr *Triangle) Intersect(r geom.Ray) (float64, bool) {
+	return r.IntersectTriangle(tr.Triangle)
+}
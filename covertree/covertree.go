@@ -0,0 +1,237 @@
+package covertree
+
+import (
+	"math"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// Tree is a cover tree (Beygelzimer, Kakade & Langford, 2006) in its
+// explicit representation: each node appears implicitly at every level from
+// its own level down, and its children are attached at lower levels.
+// The invariants maintained are
+//
+//	nesting:    a node present at level i is present at every level below i;
+//	covering:   a child attached at level i-1 lies within 2^i of its parent;
+//	separation: the nodes present at level i are more than 2^i apart.
+//
+// Together they bound every descendant of a node at level i to within 2^(i+1)
+// of it, which is what prunes searches, and give NN queries in
+// O(c^12 log n) for data with expansion constant c. Only the triangle
+// inequality is relied upon, so any true metric.Func may be used.
+type Tree struct {
+	root *node
+	m    metric.Func
+	dims int
+	size int
+}
+
+type node struct {
+	point      *kdtree.Datapoint
+	duplicates kdtree.Datapoints // Datapoints at distance zero from point
+	level      int
+	children   map[int][]*node // keyed by the level each child was attached at
+}
+
+// New returns an empty Tree using the metric m (metric.Euclidean when nil).
+func New(m metric.Func) *Tree {
+	if m == nil {
+		m = metric.Euclidean
+	}
+	return &Tree{m: m}
+}
+
+// Build constructs a Tree by inserting every Datapoint in ds.
+func Build(ds kdtree.Datapoints, m metric.Func) (*Tree, error) {
+	t := New(m)
+	for _, d := range ds {
+		if err := t.Insert(d); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Len returns the number of Datapoints held.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Dims returns the dimensionality of the Datapoints held,
+// or 0 if nothing has been inserted yet.
+func (t *Tree) Dims() int {
+	return t.dims
+}
+
+// Insert adds a Datapoint, attaching it beneath the deepest node which covers it.
+func (t *Tree) Insert(d *kdtree.Datapoint) error {
+	if d == nil {
+		return kdtree.ErrDimensionMismatch
+	}
+	if t.root == nil {
+		t.root = &node{point: d}
+		t.dims = d.Dimensionality()
+		t.size = 1
+		return nil
+	}
+	if d.Dimensionality() != t.dims {
+		return kdtree.ErrDimensionMismatch
+	}
+	t.size++
+
+	toRoot := t.m(t.root.point, d)
+	if toRoot == 0 {
+		t.root.duplicates = append(t.root.duplicates, d)
+		return nil
+	}
+	if toRoot > covering(t.root.level) {
+		// the root is present at every level, so it may simply be raised
+		t.root.level = int(math.Ceil(math.Log2(toRoot)))
+	}
+
+	type candidate struct {
+		n    *node
+		dist float64
+	}
+	var parent *node
+	var parentLevel int
+	cover := []candidate{{t.root, toRoot}}
+	for i := t.root.level; ; i-- {
+		// the cover set at level i, together with the children joining at i-1
+		expanded := cover
+		for _, c := range cover {
+			for _, child := range c.n.children[i-1] {
+				dist := t.m(child.point, d)
+				if dist == 0 {
+					child.duplicates = append(child.duplicates, d)
+					return nil
+				}
+				expanded = append(expanded, candidate{child, dist})
+			}
+		}
+		var next []candidate
+		for _, c := range expanded {
+			if c.dist <= covering(i) {
+				next = append(next, c)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		for _, c := range cover {
+			if c.dist <= covering(i) {
+				parent, parentLevel = c.n, i
+				break
+			}
+		}
+		cover = next
+	}
+
+	if parent.children == nil {
+		parent.children = make(map[int][]*node)
+	}
+	parent.children[parentLevel-1] = append(parent.children[parentLevel-1], &node{point: d, level: parentLevel - 1})
+	return nil
+}
+
+// covering returns 2^level, the covering radius at a level.
+func covering(level int) float64 {
+	return math.Ldexp(1, level)
+}
+
+// reach bounds the distance from a node to any of its descendants.
+func (n *node) reach() float64 {
+	return covering(n.level + 1)
+}
+
+// NN returns the nearest neighbour of the target under the Tree's metric,
+// or nil if the Tree is empty.
+func (t *Tree) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := t.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k nearest neighbours of the target under the Tree's metric, nearest first.
+func (t *Tree) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if t.root == nil || k <= 0 || target == nil || target.Dimensionality() != t.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	t.knn(t.root, t.m(target, t.root.point), target, set)
+	sorted := set.Sorted()
+	ds := make(kdtree.Datapoints, len(sorted))
+	for i := range sorted {
+		ds[i] = sorted[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
+
+func (t *Tree) knn(n *node, dist float64, target *kdtree.Datapoint, set *nearest.Set) {
+	set.Push(n.point, dist)
+	for _, d := range n.duplicates {
+		set.Push(d, dist)
+	}
+	children, dists := t.closest(n, target)
+	for i, child := range children {
+		if dists[i]-child.reach() <= set.Worst() {
+			t.knn(child, dists[i], target, set)
+		}
+	}
+}
+
+// closest returns the children of n with their distances from the target, nearest first.
+func (t *Tree) closest(n *node, target *kdtree.Datapoint) ([]*node, []float64) {
+	var children []*node
+	for _, c := range n.children {
+		children = append(children, c...)
+	}
+	dists := make([]float64, len(children))
+	for i, c := range children {
+		dists[i] = t.m(target, c.point)
+	}
+	sort.Sort(byDistance{children, dists})
+	return children, dists
+}
+
+// Radius returns every Datapoint within distance r of the target under the
+// Tree's metric (inclusive).
+func (t *Tree) Radius(target *kdtree.Datapoint, r float64) kdtree.Datapoints {
+	if t.root == nil || target == nil || target.Dimensionality() != t.dims {
+		return nil
+	}
+	var found kdtree.Datapoints
+	t.radiusSearch(t.root, t.m(target, t.root.point), target, r, &found)
+	return found
+}
+
+func (t *Tree) radiusSearch(n *node, dist float64, target *kdtree.Datapoint, r float64, found *kdtree.Datapoints) {
+	if dist <= r {
+		*found = append(*found, n.point)
+		*found = append(*found, n.duplicates...)
+	}
+	for _, children := range n.children {
+		for _, child := range children {
+			if d := t.m(target, child.point); d-child.reach() <= r {
+				t.radiusSearch(child, d, target, r, found)
+			}
+		}
+	}
+}
+
+type byDistance struct {
+	nodes []*node
+	dists []float64
+}
+
+func (b byDistance) Len() int           { return len(b.nodes) }
+func (b byDistance) Less(i, j int) bool { return b.dists[i] < b.dists[j] }
+func (b byDistance) Swap(i, j int) {
+	b.nodes[i], b.nodes[j] = b.nodes[j], b.nodes[i]
+	b.dists[i], b.dists[j] = b.dists[j], b.dists[i]
+}
@@ -0,0 +1,143 @@
+package covertree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/testutil"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// coarse values, so that repeats are common
+var coarse = testutil.Coarse(100, 0.25)
+
+func Test_CoverTree_Matches_Brute_Force(t *testing.T) {
+	r := rand.New(rand.NewSource(61))
+	for _, dims := range []int{1, 2, 5} {
+		ds := testutil.RandomDatapoints(r, 600, dims, coarse)
+		for _, m := range []metric.Func{metric.Euclidean, metric.Manhattan, metric.Chebyshev} {
+			tree, err := Build(ds, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tree.Len() != len(ds) || tree.Dims() != dims {
+				t.Fatal(`want: `, len(ds), dims, `
+				got: `, tree.Len(), tree.Dims())
+			}
+			for q := 0; q < 30; q++ {
+				target := testutil.RandomDatapoints(r, 1, dims, coarse)[0]
+				dists := make([]float64, len(ds))
+				for i := range ds {
+					dists[i] = m(target, ds[i])
+				}
+				sort.Float64s(dists)
+
+				got := tree.KNN(target, 10)
+				if len(got) != 10 {
+					t.Fatal(`want: 10
+					got: `, len(got))
+				}
+				for i := range got {
+					if m(target, got[i]) != dists[i] {
+						t.Fatal(`dims=`, dims, ` KNN disagreed at rank `, i)
+					}
+				}
+				if m(target, tree.NN(target)) != dists[0] {
+					t.Error(`dims=`, dims, ` NN disagreed for `, target)
+				}
+				radius := dists[40]
+				want := sort.Search(len(dists), func(i int) bool { return dists[i] > radius })
+				if got := len(tree.Radius(target, radius)); got != want {
+					t.Error(`dims=`, dims, ` Radius want: `, want, `
+					got: `, got)
+				}
+			}
+		}
+	}
+}
+
+func Test_CoverTree_Invariants(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ds := make(kdtree.Datapoints, 400)
+	for i := range ds {
+		ds[i] = kdtree.NewDatapoint(i, []float64{r.NormFloat64() * 100, r.NormFloat64()})
+	}
+	tree, _ := Build(ds, nil)
+
+	var nodes []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		nodes = append(nodes, n)
+		for level, children := range n.children {
+			for _, child := range children {
+				if child.level != level || level >= n.level {
+					t.Fatal(`child at level `, child.level, ` filed under `, level, ` beneath level `, n.level)
+				}
+				if d := tree.m(n.point, child.point); d > covering(level+1) {
+					t.Error(`covering broken: `, d, ` > `, covering(level+1))
+				}
+			}
+			for _, child := range children {
+				walk(child)
+			}
+		}
+	}
+	walk(tree.root)
+	if len(nodes) != len(ds) {
+		t.Fatal(`want: `, len(ds), `
+		got: `, len(nodes))
+	}
+	for i, p := range nodes {
+		for _, q := range nodes[i+1:] {
+			level := p.level
+			if q.level < level {
+				level = q.level
+			}
+			if d := tree.m(p.point, q.point); d <= covering(level) {
+				t.Error(`separation broken at level `, level, `: `, d)
+			}
+		}
+	}
+}
+
+func Test_CoverTree_Incremental_Duplicates_And_Errors(t *testing.T) {
+	tree := New(nil)
+	target := kdtree.NewDatapoint(nil, []float64{0, 0})
+	if tree.NN(target) != nil || tree.Len() != 0 || tree.Dims() != 0 {
+		t.Error(`empty Tree should hold nothing`)
+	}
+	for i := 0; i < 50; i++ {
+		if err := tree.Insert(kdtree.NewDatapoint(i, []float64{1, 1})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	far := kdtree.NewDatapoint(`far`, []float64{1e6, -1e6})
+	near := kdtree.NewDatapoint(`near`, []float64{1e-6, 0})
+	tree.Insert(far)
+	tree.Insert(near)
+	if tree.NN(target) != near || tree.NN(kdtree.NewDatapoint(nil, []float64{9e5, -9e5})) != far {
+		t.Error(`NN should find the isolated Datapoints`)
+	}
+	if tree.NN(nil) != nil || tree.KNN(nil, 1) != nil || tree.Radius(nil, 1) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	if got := len(tree.KNN(target, 20)); got != 20 {
+		t.Error(`want: 20
+		got: `, got)
+	}
+	if got := len(tree.Radius(target, math.Sqrt2)); got != 51 {
+		t.Error(`want: 51
+		got: `, got)
+	}
+	if err := tree.Insert(kdtree.NewDatapoint(nil, []float64{1})); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	if tree.Len() != 52 {
+		t.Error(`want: 52
+		got: `, tree.Len())
+	}
+}
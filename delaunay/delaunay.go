@@ -0,0 +1,310 @@
+package delaunay
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// ErrDegenerate is returned by Triangulate when there are fewer than three
+// distinct Datapoints, or when they are all collinear, so that no triangle
+// can be formed.
+var ErrDegenerate = errors.New("delaunay: datapoints are collinear")
+
+// ghost stands for the vertex at infinity. Every edge of the convex hull is
+// joined to it by a ghost triangle, so that a Datapoint outside the hull can
+// be inserted in just the same way as one inside (Shewchuk's approach,
+// rather than enclosing everything in a large super-triangle).
+const ghost = -1
+
+// Triangulation is the Delaunay triangulation of a set of distinct 2-D
+// Datapoints: no Datapoint lies strictly inside the circumcircle of any of
+// its triangles. Triangles are given as indices into Points, in
+// anticlockwise order.
+type Triangulation struct {
+	points     kdtree.Datapoints
+	vecs       []geom.Vec2
+	triangles  [][3]int
+	neighbours [][3]int // across the edge from v[i] to v[i+1], -1 on the hull
+	incident   []int    // a triangle touching each point
+	index      *kdtree.NodeTree
+	vertex     map[*kdtree.Datapoint]int
+}
+
+// Triangulate builds the Delaunay triangulation of two-dimensional
+// Datapoints by the Bowyer–Watson algorithm: each Datapoint in turn removes
+// the triangles whose circumcircles contain it, and the cavity left behind is
+// filled by joining it to the cavity's boundary. The Datapoints are inserted
+// in random order, and each insertion starts from the triangle beside the
+// nearest Datapoint already inserted, found with a k-d tree, so that only a
+// short walk is needed to reach the cavity. Repeats of a position are
+// dropped, keeping the first.
+func Triangulate(ds kdtree.Datapoints) (*Triangulation, error) {
+	b, err := newBuilder(ds)
+	if err != nil {
+		return nil, err
+	}
+	if !b.seed() {
+		return nil, ErrDegenerate
+	}
+	for _, p := range b.order[3:] {
+		b.insert(p)
+	}
+	return b.finish(), nil
+}
+
+type triangle struct {
+	v    [3]int // anticlockwise; a ghost triangle has the ghost at v[2]
+	dead bool
+}
+
+type builder struct {
+	points   kdtree.Datapoints
+	vecs     []geom.Vec2
+	order    []int // of insertion
+	tris     []triangle
+	edges    map[[2]int]int // directed edge to the triangle on its left
+	incident []int
+	index    *kdtree.NodeTree
+	vertex   map[*kdtree.Datapoint]int
+}
+
+func newBuilder(ds kdtree.Datapoints) (*builder, error) {
+	b := &builder{edges: make(map[[2]int]int), vertex: make(map[*kdtree.Datapoint]int)}
+	seen := make(map[geom.Vec2]bool, len(ds))
+	for _, d := range ds {
+		v, err := geom.Vec2From(d)
+		if err != nil {
+			return nil, err
+		}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		b.vertex[d] = len(b.points)
+		b.points = append(b.points, d)
+		b.vecs = append(b.vecs, v)
+	}
+	b.order = rand.New(rand.NewSource(int64(len(b.points)))).Perm(len(b.points))
+	b.incident = make([]int, len(b.points))
+	b.index, _ = kdtree.NewNodeTree(nil)
+	return b, nil
+}
+
+// seed moves the first three points which are not collinear to the front of
+// the insertion order and makes the first triangle from them, along with its
+// three ghost triangles.
+func (b *builder) seed() bool {
+	if len(b.order) < 3 {
+		return false
+	}
+	o := b.order
+	third := 2
+	for third < len(o) && geom.Orient2D(b.vecs[o[0]], b.vecs[o[1]], b.vecs[o[third]]) == 0 {
+		third++
+	}
+	if third == len(o) {
+		return false
+	}
+	o[2], o[third] = o[third], o[2]
+	if geom.Orient2D(b.vecs[o[0]], b.vecs[o[1]], b.vecs[o[2]]) < 0 {
+		o[1], o[2] = o[2], o[1]
+	}
+	b.add(o[0], o[1], o[2])
+	b.add(o[1], o[0], ghost)
+	b.add(o[2], o[1], ghost)
+	b.add(o[0], o[2], ghost)
+	for _, p := range o[:3] {
+		b.index.Insert(b.points[p])
+	}
+	return true
+}
+
+func (b *builder) add(u, v, w int) {
+	t := len(b.tris)
+	b.tris = append(b.tris, triangle{v: [3]int{u, v, w}})
+	for i, p := range [3]int{u, v, w} {
+		b.edges[[2]int{p, [3]int{u, v, w}[(i+1)%3]}] = t
+		if p != ghost {
+			b.incident[p] = t
+		}
+	}
+}
+
+// insert adds point p to a Delaunay triangulation of the points before it.
+func (b *builder) insert(p int) {
+	at := b.vecs[p]
+	start := b.locate(at, b.incident[b.vertex[b.index.NN(b.points[p])]])
+
+	cavity := []int{start}
+	b.tris[start].dead = true
+	for i := 0; i < len(cavity); i++ {
+		v := b.tris[cavity[i]].v
+		for j := 0; j < 3; j++ {
+			n := b.edges[[2]int{v[(j+1)%3], v[j]}]
+			if !b.tris[n].dead && b.conflicts(n, at) {
+				b.tris[n].dead = true
+				cavity = append(cavity, n)
+			}
+		}
+	}
+
+	var boundary [][2]int
+	for _, t := range cavity {
+		v := b.tris[t].v
+		for j := 0; j < 3; j++ {
+			u, w := v[j], v[(j+1)%3]
+			if !b.tris[b.edges[[2]int{w, u}]].dead {
+				boundary = append(boundary, [2]int{u, w})
+			}
+		}
+	}
+	for _, t := range cavity {
+		v := b.tris[t].v
+		for j := 0; j < 3; j++ {
+			delete(b.edges, [2]int{v[j], v[(j+1)%3]})
+		}
+	}
+	for _, e := range boundary {
+		switch {
+		case e[0] == ghost:
+			b.add(e[1], p, ghost)
+		case e[1] == ghost:
+			b.add(p, e[0], ghost)
+		default:
+			b.add(e[0], e[1], p)
+		}
+	}
+	b.index.Insert(b.points[p])
+}
+
+// locate walks from triangle t towards the position until it reaches either
+// the real triangle containing it, or the ghost triangle beyond the hull
+// edge it lies outside of. Either is in conflict with the position.
+func (b *builder) locate(at geom.Vec2, t int) int {
+	if v := b.tris[t].v; v[2] == ghost {
+		t = b.edges[[2]int{v[1], v[0]}]
+	}
+	for step := 0; ; step++ {
+		v := b.tris[t].v
+		if v[2] == ghost {
+			return t
+		}
+		moved := false
+		for i := 0; i < 3 && !moved; i++ {
+			j := (i + step) % 3 // vary the first edge tried, so the walk cannot cycle
+			u, w := v[j], v[(j+1)%3]
+			if geom.Orient2D(b.vecs[u], b.vecs[w], at) < 0 {
+				t, moved = b.edges[[2]int{w, u}], true
+			}
+		}
+		if !moved {
+			return t
+		}
+	}
+}
+
+// conflicts reports whether the position lies strictly inside the
+// circumcircle of triangle t. The circumcircle of a ghost triangle is the open
+// half-plane beyond its hull edge, together with the inside of the edge itself.
+func (b *builder) conflicts(t int, at geom.Vec2) bool {
+	v := b.tris[t].v
+	if v[2] != ghost {
+		return geom.InCircle(b.vecs[v[0]], b.vecs[v[1]], b.vecs[v[2]], at) > 0
+	}
+	u, w := b.vecs[v[0]], b.vecs[v[1]]
+	if o := geom.Orient2D(u, w, at); o != 0 {
+		return o > 0
+	}
+	return at.Sub(u).Dot(at.Sub(w)) < 0
+}
+
+// finish keeps the live real triangles, renumbering them and their neighbours.
+func (b *builder) finish() *Triangulation {
+	number := make([]int, len(b.tris))
+	tri := &Triangulation{points: b.points, vecs: b.vecs, incident: make([]int, len(b.points)), index: b.index, vertex: b.vertex}
+	for t, s := range b.tris {
+		number[t] = -1
+		if s.dead || s.v[2] == ghost {
+			continue
+		}
+		number[t] = len(tri.triangles)
+		tri.triangles = append(tri.triangles, s.v)
+		for _, p := range s.v {
+			tri.incident[p] = number[t]
+		}
+	}
+	tri.neighbours = make([][3]int, len(tri.triangles))
+	for t, v := range tri.triangles {
+		for i := 0; i < 3; i++ {
+			tri.neighbours[t][i] = number[b.edges[[2]int{v[(i+1)%3], v[i]}]]
+		}
+	}
+	return tri
+}
+
+// Points returns the distinct Datapoints of the Triangulation, in the order
+// that the indices of Triangles refer to.
+func (t *Triangulation) Points() kdtree.Datapoints {
+	return t.points
+}
+
+// Triangles returns every triangle as the indices of its corners in Points,
+// in anticlockwise order.
+func (t *Triangulation) Triangles() [][3]int {
+	return t.triangles
+}
+
+// Neighbours returns the triangles sharing each edge of triangle i, where
+// edge j runs from corner j to corner j+1. It is -1 for an edge of the hull.
+func (t *Triangulation) Neighbours(i int) [3]int {
+	return t.neighbours[i]
+}
+
+// Edges returns every edge of the Triangulation once, as a pair of indices
+// into Points with the smaller first; ready for graph.FromEdges.
+func (t *Triangulation) Edges() [][2]int {
+	var edges [][2]int
+	for i, v := range t.triangles {
+		for j := 0; j < 3; j++ {
+			u, w := v[j], v[(j+1)%3]
+			if u < w || t.neighbours[i][j] == -1 {
+				if u > w {
+					u, w = w, u
+				}
+				edges = append(edges, [2]int{u, w})
+			}
+		}
+	}
+	return edges
+}
+
+// Locate returns the triangle containing the Datapoint, reporting false if
+// it lies outside the Triangulation. The search walks from a triangle
+// beside the nearest of the Points, found with the k-d tree built during
+// triangulation. A Datapoint on an edge may be placed in either triangle.
+func (t *Triangulation) Locate(d *kdtree.Datapoint) (int, bool) {
+	at, err := geom.Vec2From(d)
+	if err != nil {
+		return -1, false
+	}
+	tri := t.incident[t.vertex[t.index.NN(d)]]
+	for step := 0; ; step++ {
+		v := t.triangles[tri]
+		moved := false
+		for i := 0; i < 3 && !moved; i++ {
+			j := (i + step) % 3
+			if geom.Orient2D(t.vecs[v[j]], t.vecs[v[(j+1)%3]], at) < 0 {
+				if t.neighbours[tri][j] == -1 {
+					return -1, false
+				}
+				tri, moved = t.neighbours[tri][j], true
+			}
+		}
+		if !moved {
+			return tri, true
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package delaunay
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/hull"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func gridDatapoints(r *rand.Rand, n, side int) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, n)
+	for i := range ds {
+		// coarse values, so repeated, collinear and cocircular points are common
+		ds[i] = kdtree.NewDatapoint(i, []float64{float64(r.Intn(side)), float64(r.Intn(side))})
+	}
+	return ds
+}
+
+func uniformDatapoints(r *rand.Rand, n int) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, n)
+	for i := range ds {
+		ds[i] = kdtree.NewDatapoint(i, []float64{r.Float64(), r.Float64()})
+	}
+	return ds
+}
+
+func polygonArea(vs []geom.Vec2) float64 {
+	var area float64
+	for i := range vs {
+		area += vs[i].Cross(vs[(i+1)%len(vs)])
+	}
+	return area / 2
+}
+
+// checkDelaunay tests every triangle against every point, and that the
+// triangles exactly cover the convex hull.
+func checkDelaunay(t *testing.T, ds kdtree.Datapoints, tri *Triangulation) {
+	var area float64
+	boundary := 0
+	for i, v := range tri.Triangles() {
+		a, b, c := tri.vecs[v[0]], tri.vecs[v[1]], tri.vecs[v[2]]
+		if geom.Orient2D(a, b, c) <= 0 {
+			t.Fatal(`triangle `, i, ` is not anticlockwise`)
+		}
+		for _, p := range tri.vecs {
+			if geom.InCircle(a, b, c, p) > 0 {
+				t.Fatal(`triangle `, i, ` has `, p, ` inside its circumcircle`)
+			}
+		}
+		for j, n := range tri.Neighbours(i) {
+			if n == -1 {
+				boundary++
+				continue
+			}
+			w := tri.Triangles()[n]
+			k := 0
+			for w[k] != v[(j+1)%3] {
+				k++
+			}
+			if w[(k+1)%3] != v[j] || tri.Neighbours(n)[k] != i {
+				t.Fatal(`triangles `, i, ` and `, n, ` disagree about their shared edge`)
+			}
+		}
+		area += polygonArea([]geom.Vec2{a, b, c})
+	}
+
+	hullPoints, _ := hull.MonotoneChain(ds)
+	vs := make([]geom.Vec2, len(hullPoints))
+	for i, d := range hullPoints {
+		vs[i], _ = geom.Vec2From(d)
+	}
+	if want := polygonArea(vs); math.Abs(area-want) > 1e-9*want {
+		t.Error(`triangles cover an area of `, area, ` not the hull's `, want)
+	}
+	if want := 2*len(tri.Points()) - 2 - boundary; len(tri.Triangles()) != want {
+		t.Error(`want: `, want, ` triangles`, `
+		got: `, len(tri.Triangles()))
+	}
+}
+
+func Test_Delaunay_Triangulate(t *testing.T) {
+	r := rand.New(rand.NewSource(68))
+	for trial := 0; trial < 60; trial++ {
+		var ds kdtree.Datapoints
+		if trial%2 == 0 {
+			ds = gridDatapoints(r, r.Intn(400)+3, 3+r.Intn(30))
+		} else {
+			ds = uniformDatapoints(r, r.Intn(400)+3)
+		}
+		tri, err := Triangulate(ds)
+		if err == ErrDegenerate {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkDelaunay(t, ds, tri)
+	}
+}
+
+func Test_Delaunay_Locate(t *testing.T) {
+	r := rand.New(rand.NewSource(68))
+	tri, err := Triangulate(uniformDatapoints(r, 500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hullPoints, _ := hull.MonotoneChain(tri.Points())
+	for q := 0; q < 500; q++ {
+		d := kdtree.NewDatapoint(nil, []float64{r.Float64()*1.2 - 0.1, r.Float64()*1.2 - 0.1})
+		p, _ := geom.Vec2From(d)
+		inside := true
+		for i := range hullPoints {
+			a, _ := geom.Vec2From(hullPoints[i])
+			b, _ := geom.Vec2From(hullPoints[(i+1)%len(hullPoints)])
+			if geom.Orient2D(a, b, p) < 0 {
+				inside = false
+			}
+		}
+		i, ok := tri.Locate(d)
+		if ok != inside {
+			t.Fatal(`want: `, inside, `
+			got: `, ok, ` for `, p)
+		}
+		if !ok {
+			continue
+		}
+		for j, v := 0, tri.Triangles()[i]; j < 3; j++ {
+			if geom.Orient2D(tri.vecs[v[j]], tri.vecs[v[(j+1)%3]], p) < 0 {
+				t.Fatal(p, ` is not in the located triangle `, i)
+			}
+		}
+	}
+}
+
+func Test_Delaunay_Voronoi(t *testing.T) {
+	r := rand.New(rand.NewSource(68))
+	ds := uniformDatapoints(r, 300)
+	tri, err := Triangulate(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := []kdtree.Range{kdtree.NewRange(-0.5, 1.5), kdtree.NewRange(0.25, 0.75)}
+	v, err := tri.Voronoi(bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var area float64
+	for i := 0; i < v.Len(); i++ {
+		area += polygonArea(v.Cell(i))
+	}
+	if math.Abs(area-1) > 1e-9 {
+		t.Error(`want: cells covering the bounds' area of 1
+		got: `, area)
+	}
+
+	linear, _ := kdtree.NewLinear(tri.Points())
+	for q := 0; q < 1000; q++ {
+		d := kdtree.NewDatapoint(nil, []float64{r.Float64()*2 - 0.5, r.Float64()*0.5 + 0.25})
+		p, _ := geom.Vec2From(d)
+		nearest := tri.vertex[linear.NN(d)]
+		cell := v.Cell(nearest)
+		for j := range cell {
+			if cell[j].Sub(p).Cross(cell[(j+1)%len(cell)].Sub(p)) < -1e-12 {
+				t.Fatal(p, ` is not in the cell of its nearest site `, v.Site(nearest))
+			}
+		}
+	}
+
+	if _, err := tri.Voronoi(bounds[:1]); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
+
+func Test_Delaunay_GeoJSON(t *testing.T) {
+	square := kdtree.Datapoints{
+		kdtree.NewDatapoint("a", []float64{0, 0}),
+		kdtree.NewDatapoint("b", []float64{2, 0}),
+		kdtree.NewDatapoint("c", []float64{2, 2}),
+		kdtree.NewDatapoint("d", []float64{0, 2}),
+		kdtree.NewDatapoint("e", []float64{1, 1}),
+	}
+	tri, err := Triangulate(square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tri.Triangles()) != 4 || len(tri.Edges()) != 8 {
+		t.Error(`want: 4 triangles and 8 edges
+		got: `, len(tri.Triangles()), len(tri.Edges()))
+	}
+	v, _ := tri.Voronoi([]kdtree.Range{kdtree.NewRange(0, 2), kdtree.NewRange(0, 2)})
+
+	for _, marshal := range []func() ([]byte, error){tri.GeoJSON, v.GeoJSON} {
+		b, err := marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fc struct {
+			Type     string
+			Features []struct {
+				Geometry struct {
+					Type        string
+					Coordinates [][][2]float64
+				}
+				Properties map[string]interface{}
+			}
+		}
+		if err := json.Unmarshal(b, &fc); err != nil {
+			t.Fatal(err)
+		}
+		if fc.Type != "FeatureCollection" || len(fc.Features) != 4 && len(fc.Features) != 5 {
+			t.Fatal(`unexpected GeoJSON: `, string(b))
+		}
+		for _, f := range fc.Features {
+			ring := f.Geometry.Coordinates[0]
+			if f.Geometry.Type != "Polygon" || ring[0] != ring[len(ring)-1] || f.Properties["data"] == nil {
+				t.Error(`unexpected Feature: `, f)
+			}
+		}
+	}
+	if _, err := json.Marshal(tri); err != nil {
+		t.Error(err)
+	}
+	if _, err := json.Marshal(v); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Delaunay_Errors(t *testing.T) {
+	line := kdtree.Datapoints{
+		kdtree.NewDatapoint(nil, []float64{0, 0}),
+		kdtree.NewDatapoint(nil, []float64{1, 1}),
+		kdtree.NewDatapoint(nil, []float64{1, 1}),
+		kdtree.NewDatapoint(nil, []float64{3, 3}),
+	}
+	if _, err := Triangulate(line); err != ErrDegenerate {
+		t.Error(`want: `, ErrDegenerate, `
+		got: `, err)
+	}
+	if _, err := Triangulate(kdtree.Datapoints{kdtree.NewDatapoint(nil, []float64{1, 2, 3})}); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
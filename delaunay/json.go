@@ -0,0 +1,73 @@
+package delaunay
+
+import (
+	"encoding/json"
+
+	"github.com/benjamin-rood/goeometric/geojson"
+	"github.com/benjamin-rood/goeometric/geom"
+)
+
+// MarshalJSON implements json.Marshaler interface
+func (t *Triangulation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"Datapoints": t.points,
+		"Triangles":  t.triangles,
+	})
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (v *Voronoi) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"Sites":  v.sites,
+		"Cells":  v.cells,
+		"Bounds": [2][2]float64{{v.bounds[0].Min(), v.bounds[0].Max()}, {v.bounds[1].Min(), v.bounds[1].Max()}},
+	})
+}
+
+// GeoJSON returns the Triangulation as a GeoJSON FeatureCollection of
+// Polygons, one per triangle, each with the index of the triangle and the
+// data of its three corners as properties.
+func (t *Triangulation) GeoJSON() ([]byte, error) {
+	fc := &geojson.FeatureCollection{Type: "FeatureCollection", Features: []*geojson.Feature{}}
+	for i, v := range t.triangles {
+		fc.Features = append(fc.Features, polygonFeature(
+			[]geom.Vec2{t.vecs[v[0]], t.vecs[v[1]], t.vecs[v[2]]},
+			map[string]interface{}{
+				"index": i,
+				"data":  []interface{}{t.points[v[0]].Data(), t.points[v[1]].Data(), t.points[v[2]].Data()},
+			}))
+	}
+	return json.Marshal(fc)
+}
+
+// GeoJSON returns the Voronoi diagram as a GeoJSON FeatureCollection of
+// Polygons, one per non-empty cell, each with the index and data of its
+// site as properties.
+func (v *Voronoi) GeoJSON() ([]byte, error) {
+	fc := &geojson.FeatureCollection{Type: "FeatureCollection", Features: []*geojson.Feature{}}
+	for i, cell := range v.cells {
+		if len(cell) == 0 {
+			continue
+		}
+		fc.Features = append(fc.Features, polygonFeature(cell, map[string]interface{}{
+			"index": i,
+			"data":  v.sites[i].Data(),
+		}))
+	}
+	return json.Marshal(fc)
+}
+
+// polygonFeature closes the ring, which is already anticlockwise as RFC 7946
+// recommends for an exterior ring.
+func polygonFeature(ring []geom.Vec2, properties map[string]interface{}) *geojson.Feature {
+	coordinates := make([][2]float64, 0, len(ring)+1)
+	for _, p := range ring {
+		coordinates = append(coordinates, [2]float64(p))
+	}
+	coordinates = append(coordinates, [2]float64(ring[0]))
+	return &geojson.Feature{
+		Type:       "Feature",
+		Geometry:   geojson.NewGeometry("Polygon", [][][2]float64{coordinates}),
+		Properties: properties,
+	}
+}
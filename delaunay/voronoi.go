@@ -0,0 +1,86 @@
+package delaunay
+
+import (
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Voronoi is the Voronoi diagram dual to a Triangulation, with every cell
+// clipped to a bounding box. Cell i is the region of the box nearer to
+// Site i than to any other site, given as a convex polygon in anticlockwise
+// order; it is empty when the site's region misses the box entirely.
+type Voronoi struct {
+	sites  kdtree.Datapoints
+	cells  [][]geom.Vec2
+	bounds [2]kdtree.Range
+}
+
+// Voronoi returns the Voronoi diagram of the Points, clipped to the
+// two-dimensional bounds. Each cell is found by cutting the box down by the
+// perpendicular bisector between its site and each of the site's Delaunay
+// neighbours, which are exactly the sites whose cells it borders, so the
+// cells of sites on the hull need no special treatment despite being
+// unbounded.
+func (t *Triangulation) Voronoi(bounds []kdtree.Range) (*Voronoi, error) {
+	if len(bounds) != 2 {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	box := []geom.Vec2{
+		{bounds[0].Min(), bounds[1].Min()},
+		{bounds[0].Max(), bounds[1].Min()},
+		{bounds[0].Max(), bounds[1].Max()},
+		{bounds[0].Min(), bounds[1].Max()},
+	}
+	neighbours := make([][]int, len(t.points))
+	for _, e := range t.Edges() {
+		neighbours[e[0]] = append(neighbours[e[0]], e[1])
+		neighbours[e[1]] = append(neighbours[e[1]], e[0])
+	}
+	v := &Voronoi{sites: t.points, cells: make([][]geom.Vec2, len(t.points)), bounds: [2]kdtree.Range{bounds[0], bounds[1]}}
+	for i, site := range t.vecs {
+		cell := box
+		for _, j := range neighbours[i] {
+			normal := t.vecs[j].Sub(site)
+			cell = clip(cell, normal, normal.Dot(site.Add(t.vecs[j]).Scale(0.5)))
+		}
+		v.cells[i] = cell
+	}
+	return v, nil
+}
+
+// clip returns the part of a convex polygon where normal·x <= offset, by
+// the Sutherland–Hodgman algorithm.
+func clip(polygon []geom.Vec2, normal geom.Vec2, offset float64) []geom.Vec2 {
+	var kept []geom.Vec2
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		dp, dq := normal.Dot(p)-offset, normal.Dot(q)-offset
+		if dp <= 0 {
+			kept = append(kept, p)
+		}
+		if (dp < 0 && dq > 0) || (dp > 0 && dq < 0) {
+			kept = append(kept, p.Lerp(q, dp/(dp-dq)))
+		}
+	}
+	return kept
+}
+
+// Len returns the number of sites, and so of cells.
+func (v *Voronoi) Len() int {
+	return len(v.sites)
+}
+
+// Site returns the Datapoint of cell i.
+func (v *Voronoi) Site(i int) *kdtree.Datapoint {
+	return v.sites[i]
+}
+
+// Cell returns the vertices of cell i in anticlockwise order.
+func (v *Voronoi) Cell(i int) []geom.Vec2 {
+	return v.cells[i]
+}
+
+// Bounds returns the box to which the cells are clipped.
+func (v *Voronoi) Bounds() []kdtree.Range {
+	return []kdtree.Range{v.bounds[0], v.bounds[1]}
+}
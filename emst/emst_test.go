@@ -0,0 +1,165 @@
+package emst
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/testutil"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// prim returns the lengths of the edges of a minimum spanning tree over the
+// complete graph, shortest first, in O(n²).
+func prim(ds kdtree.Datapoints) []float64 {
+	if len(ds) == 0 {
+		return nil
+	}
+	dist := make([]float64, len(ds))
+	done := make([]bool, len(ds))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	var lengths []float64
+	next := 0
+	for {
+		done[next] = true
+		for i, d := range ds {
+			if !done[i] {
+				dist[i] = math.Min(dist[i], kdtree.Distance(ds[next], d))
+			}
+		}
+		next = -1
+		for i := range ds {
+			if !done[i] && (next == -1 || dist[i] < dist[next]) {
+				next = i
+			}
+		}
+		if next == -1 {
+			break
+		}
+		lengths = append(lengths, dist[next])
+	}
+	sort.Float64s(lengths)
+	return lengths
+}
+
+func Test_EMST_Boruvka_Matches_Prim(t *testing.T) {
+	r := rand.New(rand.NewSource(69))
+	for trial := 0; trial < 60; trial++ {
+		value := testutil.Uniform(0, 1)
+		if trial%2 == 0 {
+			value = testutil.Coarse(20, 1) // so equal lengths and repeats are common
+		}
+		ds := testutil.RandomDatapoints(r, r.Intn(400)+1, trial%3+1, value)
+		edges, err := Boruvka(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := prim(ds)
+		if len(edges) != len(want) {
+			t.Fatal(`want: `, len(want), ` edges`, `
+			got: `, len(edges))
+		}
+		sets := newDisjointSets(len(ds))
+		for i, e := range edges {
+			if math.Abs(e.Length-want[i]) > 1e-12 || e.Length != kdtree.Distance(e.From, e.To) {
+				t.Fatal(`edge `, i, ` want: `, want[i], `
+				got: `, e.Length)
+			}
+			if !sets.union(e.From.Data().(int), e.To.Data().(int)) {
+				t.Fatal(`edges form a cycle`)
+			}
+		}
+	}
+}
+
+// components joins Datapoints no further apart than h, by brute force.
+func components(ds kdtree.Datapoints, h float64) int {
+	sets := newDisjointSets(len(ds))
+	count := len(ds)
+	for i := range ds {
+		for j := range ds[:i] {
+			if kdtree.Distance(ds[i], ds[j]) <= h && sets.union(i, j) {
+				count--
+			}
+		}
+	}
+	return count
+}
+
+func Test_EMST_SingleLinkage(t *testing.T) {
+	r := rand.New(rand.NewSource(69))
+	ds := testutil.RandomDatapoints(r, 300, 2, testutil.Uniform(0, 1))
+	d, err := SingleLinkage(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merges := d.Merges()
+	if len(merges) != len(ds)-1 || merges[len(merges)-1].Size != len(ds) {
+		t.Fatal(`the merges should end with every Datapoint in one cluster`)
+	}
+	for i, m := range merges {
+		if m.A >= m.B || m.B >= len(ds)+i || (i > 0 && m.Height < merges[i-1].Height) {
+			t.Fatal(`merge `, i, ` is out of order: `, m)
+		}
+	}
+
+	for _, h := range []float64{0, 0.01, 0.03, 0.05, 0.1, 1} {
+		clusters := d.CutHeight(h)
+		if want := components(ds, h); len(clusters) != want {
+			t.Error(`want: `, want, ` clusters at `, h, `
+			got: `, len(clusters))
+		}
+		total := 0
+		for _, c := range clusters {
+			total += len(c)
+		}
+		if total != len(ds) {
+			t.Error(`clusters should partition the Datapoints`)
+		}
+	}
+	for _, k := range []int{1, 2, 7, 300, 500} {
+		want := k
+		if want > len(ds) {
+			want = len(ds)
+		}
+		if got := len(d.CutCount(k)); got != want {
+			t.Error(`want: `, want, `
+			got: `, got)
+		}
+	}
+	if len(d.CutCount(2)[0]) == 0 || d.CutCount(1)[0][0] != d.Points()[0] {
+		t.Error(`clusters should be ordered by their first Datapoint`)
+	}
+}
+
+func Test_EMST_Errors(t *testing.T) {
+	if edges, err := Boruvka(nil); edges != nil || err != nil {
+		t.Error(`nothing should span nothing`)
+	}
+	p := kdtree.NewDatapoint(nil, []float64{1, 2})
+	if edges, err := Boruvka(kdtree.Datapoints{p, p}); edges != nil || err != nil {
+		t.Error(`a repeated Datapoint should be spanned once`)
+	}
+	if _, err := Boruvka(kdtree.Datapoints{p, kdtree.NewDatapoint(nil, []float64{1})}); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
+
+// Boruvka spans 20,000 uniform 2-D points in about a fifth of a second, and
+// 100,000 in about one and a half, where Prim's algorithm over the complete
+// graph would have 5×10⁹ distances to compute for the latter.
+func Benchmark_EMST_Boruvka(b *testing.B) {
+	for _, n := range []int{1000, 20000, 100000} {
+		ds := testutil.RandomDatapoints(rand.New(rand.NewSource(1)), n, 2, testutil.Uniform(0, 1))
+		b.Run(fmt.Sprint("n=", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Boruvka(ds)
+			}
+		})
+	}
+}
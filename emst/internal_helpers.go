@@ -0,0 +1,41 @@
+package emst
+
+// disjointSets is a union–find structure over the integers [0, n).
+type disjointSets struct {
+	parent, rank []int
+}
+
+func newDisjointSets(n int) *disjointSets {
+	s := &disjointSets{parent: make([]int, n), rank: make([]int, n)}
+	for i := range s.parent {
+		s.parent[i] = i
+	}
+	return s
+}
+
+// find returns the representative of the set holding i, halving the path
+// to it as it goes.
+func (s *disjointSets) find(i int) int {
+	for s.parent[i] != i {
+		s.parent[i] = s.parent[s.parent[i]]
+		i = s.parent[i]
+	}
+	return i
+}
+
+// union joins the sets holding i and j, reporting false if they were
+// already the same set.
+func (s *disjointSets) union(i, j int) bool {
+	i, j = s.find(i), s.find(j)
+	if i == j {
+		return false
+	}
+	if s.rank[i] < s.rank[j] {
+		i, j = j, i
+	}
+	s.parent[j] = i
+	if s.rank[i] == s.rank[j] {
+		s.rank[i]++
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package emst
+
+import (
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Merge is a step of a Dendrogram, joining clusters A and B at the given
+// height. Clusters are numbered as in SciPy's linkage matrices: 0 to n-1
+// are the single Datapoints, and n+i is the cluster formed by Merge i.
+type Merge struct {
+	A, B   int
+	Height float64
+	Size   int
+}
+
+// Dendrogram is a hierarchical clustering of Datapoints, recorded as the
+// sequence of merges from single Datapoints up to one cluster of them all.
+type Dendrogram struct {
+	points kdtree.Datapoints
+	merges []Merge
+}
+
+// SingleLinkage returns the single-linkage hierarchical clustering of ds,
+// where the distance between two clusters is that between their nearest
+// members. The merges are exactly the edges of the Euclidean minimum
+// spanning tree taken in order of length, so it is found with Boruvka in
+// roughly O(n log n) rather than the O(n²) of the naive algorithm.
+func SingleLinkage(ds kdtree.Datapoints) (*Dendrogram, error) {
+	edges, err := Boruvka(ds)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dendrogram{}
+	index := make(map[*kdtree.Datapoint]int, len(ds))
+	for _, p := range ds {
+		if _, ok := index[p]; !ok {
+			index[p] = len(d.points)
+			d.points = append(d.points, p)
+		}
+	}
+
+	n := len(d.points)
+	sets := newDisjointSets(n)
+	cluster := make([]int, n) // the Dendrogram number of each set's cluster
+	size := make([]int, n)
+	for i := range cluster {
+		cluster[i], size[i] = i, 1
+	}
+	for i, e := range edges {
+		a, b := sets.find(index[e.From]), sets.find(index[e.To])
+		m := Merge{A: cluster[a], B: cluster[b], Height: e.Length, Size: size[a] + size[b]}
+		if m.A > m.B {
+			m.A, m.B = m.B, m.A
+		}
+		d.merges = append(d.merges, m)
+		sets.union(a, b)
+		root := sets.find(a)
+		cluster[root], size[root] = n+i, m.Size
+	}
+	return d, nil
+}
+
+// Points returns the Datapoints clustered, numbered as the single
+// Datapoint clusters of the Merges.
+func (d *Dendrogram) Points() kdtree.Datapoints {
+	return d.points
+}
+
+// Merges returns every Merge, lowest first.
+func (d *Dendrogram) Merges() []Merge {
+	return d.merges
+}
+
+// CutHeight returns the clusters formed by every Merge no higher than h,
+// so that Datapoints lie in the same cluster exactly when they are linked by
+// a chain of steps no longer than h.
+func (d *Dendrogram) CutHeight(h float64) []kdtree.Datapoints {
+	merged := sort.Search(len(d.merges), func(i int) bool {
+		return d.merges[i].Height > h
+	})
+	return d.cut(merged)
+}
+
+// CutCount returns k clusters, or one per Datapoint if there are fewer
+// than k, by undoing the k-1 highest Merges.
+func (d *Dendrogram) CutCount(k int) []kdtree.Datapoints {
+	merged := len(d.points) - k
+	if merged < 0 {
+		merged = 0
+	}
+	if merged > len(d.merges) {
+		merged = len(d.merges)
+	}
+	return d.cut(merged)
+}
+
+// cut returns the clusters formed by the first merged Merges, ordered by
+// their first Datapoint.
+func (d *Dendrogram) cut(merged int) []kdtree.Datapoints {
+	n := len(d.points)
+	sets := newDisjointSets(n)
+	first := make([]int, n+merged) // some Datapoint of each cluster
+	for i := 0; i < n; i++ {
+		first[i] = i
+	}
+	for i, m := range d.merges[:merged] {
+		sets.union(first[m.A], first[m.B])
+		first[n+i] = first[m.A]
+	}
+	var clusters []kdtree.Datapoints
+	number := make(map[int]int)
+	for i, p := range d.points {
+		root := sets.find(i)
+		c, ok := number[root]
+		if !ok {
+			c = len(clusters)
+			number[root] = c
+			clusters = append(clusters, nil)
+		}
+		clusters[c] = append(clusters[c], p)
+	}
+	return clusters
+}
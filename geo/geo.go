@@ -0,0 +1,123 @@
+package geo
+
+import (
+	"math"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// Every geographic Datapoint holds two values, its latitude and then its
+// longitude, in degrees. Distances are in kilometres.
+
+// EarthRadius is the mean radius of the Earth in kilometres (IUGG), the
+// radius of the sphere on which Haversine measures distances.
+const EarthRadius = 6371.0088
+
+// The WGS-84 ellipsoid, on which Vincenty measures distances.
+const (
+	wgs84A = 6378.137 // equatorial radius in kilometres
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+)
+
+// NewDatapoint constructs a geographic Datapoint at a latitude and longitude
+// in degrees. The longitude is wrapped into [-180, 180).
+func NewDatapoint(data interface{}, lat, lon float64) *kdtree.Datapoint {
+	if lon < -180 || lon >= 180 { // longitudes already in range are kept exactly
+		lon = math.Mod(lon+180, 360)
+		if lon < 0 {
+			lon += 360
+		}
+		lon -= 180
+	}
+	return kdtree.NewDatapoint(data, []float64{lat, lon})
+}
+
+// LatLon returns the latitude and longitude of a geographic Datapoint.
+func LatLon(d *kdtree.Datapoint) (lat, lon float64) {
+	return d.At(0), d.At(1)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Set of pre-defined metrics between geographic Datapoints which match the
+// prototype of `metric.Func`
+var (
+	// Haversine is the great-circle distance on a sphere of EarthRadius, which
+	// is accurate to within about 0.5% anywhere on the Earth.
+	Haversine metric.Func = func(p, q *kdtree.Datapoint) float64 {
+		lat1, lat2 := radians(p.At(0)), radians(q.At(0))
+		sinLat := math.Sin((lat2 - lat1) / 2)
+		sinLon := math.Sin(radians(q.At(1)-p.At(1)) / 2)
+		h := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLon*sinLon
+		return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+	}
+
+	// Vincenty is the geodesic distance on the WGS-84 ellipsoid by Vincenty's
+	// inverse formula, accurate to within a millimetre or so. The formula
+	// fails to converge for points very nearly antipodal, where Haversine is
+	// used instead.
+	Vincenty metric.Func = vincenty
+)
+
+func vincenty(p, q *kdtree.Datapoint) float64 {
+	u1 := math.Atan((1 - wgs84F) * math.Tan(radians(p.At(0))))
+	u2 := math.Atan((1 - wgs84F) * math.Tan(radians(q.At(0))))
+	sinU1, cosU1 := math.Sincos(u1)
+	sinU2, cosU2 := math.Sincos(u2)
+	l := radians(q.At(1) - p.At(1))
+
+	lambda := l
+	for i := 0; i < 200; i++ {
+		sinLambda, cosLambda := math.Sincos(lambda)
+		sinSigma := math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0 // coincident points
+		}
+		cosSigma := sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma := math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha := 1 - sinAlpha*sinAlpha
+		cos2SigmaM := 0.0 // on the equator
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		c := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		previous := lambda
+		lambda = l + (1-c)*wgs84F*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) > 1e-12 {
+			continue
+		}
+
+		uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+		a := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+		b := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+		deltaSigma := b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		return wgs84B * a * (sigma - deltaSigma)
+	}
+	return Haversine(p, q)
+}
+
+// UnitVector returns the point on the unit sphere, in Earth-centred
+// Cartesian coordinates, at the latitude and longitude of a geographic
+// Datapoint. Straight-line distance between unit vectors increases with
+// great-circle distance, so ordinary Euclidean structures can answer
+// geographic nearest neighbour queries over them.
+func UnitVector(d *kdtree.Datapoint) []float64 {
+	sinLat, cosLat := math.Sincos(radians(d.At(0)))
+	sinLon, cosLon := math.Sincos(radians(d.At(1)))
+	return []float64{cosLat * cosLon, cosLat * sinLon, sinLat}
+}
+
+// chord returns the straight-line distance between two points on the unit
+// sphere which are km apart along a great circle of EarthRadius.
+func chord(km float64) float64 {
+	if km >= math.Pi*EarthRadius {
+		return 2
+	}
+	return 2 * math.Sin(km/(2*EarthRadius))
+}
@@ -0,0 +1,151 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func Test_Geo_NewDatapoint(t *testing.T) {
+	for _, lon := range []float64{190, -170, 550, -530} {
+		if _, got := LatLon(NewDatapoint(nil, 10, lon)); got != -170 {
+			t.Error(`want: -170
+			got: `, got)
+		}
+	}
+	if lat, lon := LatLon(NewDatapoint(nil, -33.9, 180)); lat != -33.9 || lon != -180 {
+		t.Error(`want: -33.9 -180
+		got: `, lat, lon)
+	}
+	for _, lon := range []float64{144 + 25/60.0 + 29.52440/3600, -179.99999999, 0.1, -180} {
+		if _, got := LatLon(NewDatapoint(nil, 0, lon)); got != lon {
+			t.Error(`a longitude in range should be kept exactly, want: `, lon, `
+			got: `, got)
+		}
+	}
+}
+
+func Test_Geo_Distances(t *testing.T) {
+	// Vincenty's own test line, from Flinders Peak to Buninyong
+	flinders := NewDatapoint(nil, -(37 + 57/60.0 + 3.72030/3600), 144+25/60.0+29.52440/3600)
+	buninyong := NewDatapoint(nil, -(37 + 39/60.0 + 10.15610/3600), 143+55/60.0+35.38390/3600)
+	if got := Vincenty(flinders, buninyong); math.Abs(got-54.972271) > 1e-6 {
+		t.Error(`want: 54.972271
+		got: `, got)
+	}
+	if got := Haversine(flinders, buninyong); math.Abs(got-54.972271) > 0.005*54.972271 {
+		t.Error(`want: within 0.5% of 54.972271
+		got: `, got)
+	}
+
+	pole, east, west := NewDatapoint(nil, 90, 0), NewDatapoint(nil, 0, 179.9), NewDatapoint(nil, 0, -179.9)
+	quarter := math.Pi * EarthRadius / 2
+	distanceTests := []struct {
+		name string
+		p, q *kdtree.Datapoint
+		want float64
+	}{
+		{"across the antimeridian", east, west, 0.2 * math.Pi * EarthRadius / 180},
+		{"pole to equator", pole, east, quarter},
+		{"around the pole", NewDatapoint(nil, 89, 0), NewDatapoint(nil, 89, 180), 2 * math.Pi * EarthRadius / 180},
+		{"same point", east, east, 0},
+	}
+	for _, dt := range distanceTests {
+		if got := Haversine(dt.p, dt.q); math.Abs(got-dt.want) > 1e-6 {
+			t.Error(dt.name, ` want: `, dt.want, `
+			got: `, got)
+		}
+		if got := Vincenty(dt.p, dt.q); math.Abs(got-dt.want) > 0.005*dt.want+1e-9 {
+			t.Error(dt.name, ` (Vincenty) want: within 0.5% of `, dt.want, `
+			got: `, got)
+		}
+	}
+	if got := Vincenty(NewDatapoint(nil, 0, 0), NewDatapoint(nil, 0.5, 179.7)); math.IsNaN(got) || got < 19000 {
+		t.Error(`nearly antipodal points should fall back to a sensible distance, got: `, got)
+	}
+}
+
+// randomGlobal returns Datapoints spread uniformly over the sphere, with
+// some crowded around a pole and along the antimeridian.
+func randomGlobal(r *rand.Rand, n int) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, n)
+	for i := range ds {
+		lat := math.Asin(2*r.Float64()-1) * 180 / math.Pi
+		lon := r.Float64()*360 - 180
+		switch i % 3 {
+		case 1:
+			lat = 85 + r.Float64()*5
+		case 2:
+			lon = 179 + r.Float64()*2
+		}
+		ds[i] = NewDatapoint(i, lat, lon)
+	}
+	return ds
+}
+
+func Test_Geo_Index_Matches_Brute_Force(t *testing.T) {
+	r := rand.New(rand.NewSource(71))
+	ds := randomGlobal(r, 1000)
+	idx, err := NewIndex(ds[:800])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range ds[800:] {
+		idx.Insert(d)
+	}
+	for _, d := range ds[:100] {
+		if !idx.Delete(d) {
+			t.Fatal(`Delete should remove `, d)
+		}
+	}
+	held := ds[100:]
+	if idx.Len() != len(held) || idx.Delete(ds[0]) {
+		t.Fatal(`want: `, len(held), `
+		got: `, idx.Len())
+	}
+
+	for _, target := range randomGlobal(r, 60) {
+		sorted := append(kdtree.Datapoints{}, held...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return Haversine(target, sorted[i]) < Haversine(target, sorted[j])
+		})
+		got := idx.KNN(target, 10)
+		for i := range got {
+			if math.Abs(Haversine(target, got[i])-Haversine(target, sorted[i])) > 1e-6 {
+				t.Fatal(`KNN `, i, ` want: `, Haversine(target, sorted[i]), ` km
+				got: `, Haversine(target, got[i]), ` km`)
+			}
+		}
+		if idx.NN(target) != got[0] {
+			t.Error(`NN should be the first of KNN`)
+		}
+
+		km := 500 + r.Float64()*2000
+		want := 0
+		for want < len(sorted) && Haversine(target, sorted[want]) <= km {
+			want++
+		}
+		found := idx.Radius(target, km)
+		if len(found) != want {
+			t.Error(`Radius `, km, ` km want: `, want, `
+			got: `, len(found))
+		}
+	}
+	if len(idx.Radius(held[0], 30000)) != len(held) {
+		t.Error(`a radius of half the Earth's circumference should hold everything`)
+	}
+}
+
+func Test_Geo_Errors(t *testing.T) {
+	if _, err := NewIndex(kdtree.Datapoints{kdtree.NewDatapoint(nil, []float64{1, 2, 3})}); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	idx, _ := NewIndex(nil)
+	if idx.NN(NewDatapoint(nil, 0, 0)) != nil || idx.Insert(kdtree.NewDatapoint(nil, []float64{1})) != kdtree.ErrDimensionMismatch {
+		t.Error(`an empty Index should hold nothing and refuse non-geographic Datapoints`)
+	}
+}
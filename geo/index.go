@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Index answers nearest neighbour and radius queries over geographic
+// Datapoints by great-circle distance. It keeps a k-d tree over the
+// UnitVector of each Datapoint, where Euclidean distance is the chord
+// through the sphere: since that orders Datapoints exactly as great-circle
+// distance does, the answers are correct near the poles and across the
+// antimeridian, where treating latitude and longitude as planar is not.
+type Index struct {
+	tree  *kdtree.NodeTree
+	units map[*kdtree.Datapoint]*kdtree.Datapoint // from each Datapoint to its unit vector
+}
+
+// NewIndex constructs an Index over geographic Datapoints.
+func NewIndex(ds kdtree.Datapoints) (*Index, error) {
+	idx := &Index{units: make(map[*kdtree.Datapoint]*kdtree.Datapoint, len(ds))}
+	units := make(kdtree.Datapoints, 0, len(ds))
+	for _, d := range ds {
+		if d == nil || d.Dimensionality() != 2 {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+		if _, ok := idx.units[d]; ok {
+			continue
+		}
+		idx.units[d] = kdtree.NewDatapoint(d, UnitVector(d))
+		units = append(units, idx.units[d])
+	}
+	tree, err := kdtree.NewNodeTree(units)
+	if err != nil {
+		return nil, err
+	}
+	idx.tree = tree
+	return idx, nil
+}
+
+// Len returns the number of Datapoints held.
+func (idx *Index) Len() int {
+	return idx.tree.Len()
+}
+
+// Insert adds a geographic Datapoint; inserting one already held does nothing.
+func (idx *Index) Insert(d *kdtree.Datapoint) error {
+	if d == nil || d.Dimensionality() != 2 {
+		return kdtree.ErrDimensionMismatch
+	}
+	if _, ok := idx.units[d]; ok {
+		return nil
+	}
+	unit := kdtree.NewDatapoint(d, UnitVector(d))
+	if err := idx.tree.Insert(unit); err != nil {
+		return err
+	}
+	idx.units[d] = unit
+	return nil
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+func (idx *Index) Delete(d *kdtree.Datapoint) bool {
+	unit, ok := idx.units[d]
+	if !ok {
+		return false
+	}
+	delete(idx.units, d)
+	return idx.tree.Delete(unit)
+}
+
+// NN returns the Datapoint nearest the target by great-circle distance, or
+// nil if the Index is empty.
+func (idx *Index) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := idx.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k Datapoints nearest the target by great-circle distance,
+// nearest first.
+func (idx *Index) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if target == nil || target.Dimensionality() != 2 {
+		return nil
+	}
+	return originals(idx.tree.KNN(kdtree.NewDatapoint(nil, UnitVector(target)), k))
+}
+
+// Radius returns every Datapoint within km kilometres of the target along a
+// great circle of EarthRadius (inclusive), in no particular order.
+func (idx *Index) Radius(target *kdtree.Datapoint, km float64) kdtree.Datapoints {
+	if target == nil || target.Dimensionality() != 2 || km < 0 {
+		return nil
+	}
+	centre, c := UnitVector(target), chord(km)+1e-9 // widened against rounding; Haversine decides
+	bounds := make([]kdtree.Range, len(centre))
+	for axis, v := range centre {
+		bounds[axis] = kdtree.NewRange(v-c, v+c)
+	}
+	var found kdtree.Datapoints
+	for _, d := range originals(idx.tree.Range(bounds)) {
+		if Haversine(target, d) <= km {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+func originals(units kdtree.Datapoints) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, len(units))
+	for i, unit := range units {
+		ds[i] = unit.Data().(*kdtree.Datapoint)
+	}
+	return ds
+}
@@ -0,0 +1,157 @@
+package geojson
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/benjamin-rood/goeometric/geo"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Datapoints read and written here are geographic, as in package geo: their
+// values are latitude and then longitude, in degrees. GeoJSON itself gives
+// positions as longitude and then latitude (RFC 7946), and the two orders
+// are swapped on the way in and out.
+
+// ErrNotFeatureCollection is returned by Read when the GeoJSON is not a
+// FeatureCollection.
+var ErrNotFeatureCollection = errors.New("geojson: not a FeatureCollection")
+
+// ErrPosition is returned by Read when a position has fewer than two values.
+var ErrPosition = errors.New("geojson: position needs a longitude and a latitude")
+
+// FeatureCollection is a GeoJSON FeatureCollection, ready to be marshalled.
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON Geometry, whose coordinates are left encoded until
+// its type is known.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Read decodes a GeoJSON FeatureCollection into Datapoints, one for each
+// Point and one for each position of each MultiPoint, with the properties
+// of its Feature as the data of each. Features of other geometry types, or
+// with no geometry, are skipped. Any altitude is dropped.
+func Read(r io.Reader) (kdtree.Datapoints, error) {
+	var fc FeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return nil, err
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, ErrNotFeatureCollection
+	}
+	var ds kdtree.Datapoints
+	for _, f := range fc.Features {
+		if f == nil || f.Geometry == nil {
+			continue
+		}
+		var positions [][]float64
+		switch f.Geometry.Type {
+		case "Point":
+			var position []float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &position); err != nil {
+				return nil, err
+			}
+			positions = [][]float64{position}
+		case "MultiPoint":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &positions); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		for _, p := range positions {
+			if len(p) < 2 {
+				return nil, ErrPosition
+			}
+			ds = append(ds, geo.NewDatapoint(f.Properties, p[1], p[0]))
+		}
+	}
+	return ds, nil
+}
+
+// Points returns a FeatureCollection with a Point Feature for each
+// Datapoint, such as the results of a query. Data which is a
+// map[string]interface{} (as from Read) becomes the properties; any other
+// data is kept under the property "data".
+func Points(ds kdtree.Datapoints) *FeatureCollection {
+	fc := &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+	for _, d := range ds {
+		lat, lon := geo.LatLon(d)
+		properties, ok := d.Data().(map[string]interface{})
+		if !ok {
+			properties = map[string]interface{}{"data": d.Data()}
+		}
+		fc.Features = append(fc.Features, &Feature{
+			Type:       "Feature",
+			Geometry:   NewGeometry("Point", [2]float64{lon, lat}),
+			Properties: properties,
+		})
+	}
+	return fc
+}
+
+// Partitions returns a FeatureCollection with a Polygon Feature for the
+// region of each leaf of a k-d tree over geographic Datapoints: the
+// bounding box of the whole tree, cut down by the pivot of every Branch
+// above the leaf. Each has the depth of the leaf and the number of
+// Datapoints it holds as properties.
+func Partitions(root *kdtree.Branch) *FeatureCollection {
+	fc := &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+	if root == nil || len(root.Bounds()) != 2 {
+		return fc
+	}
+	bounds := root.Bounds()
+	partition(root, [2][2]float64{
+		{bounds[0].Min(), bounds[0].Max()},
+		{bounds[1].Min(), bounds[1].Max()},
+	}, fc)
+	return fc
+}
+
+// partition adds the leaves below branch, whose region is the box of
+// [min, max] latitudes and longitudes.
+func partition(branch *kdtree.Branch, box [2][2]float64, fc *FeatureCollection) {
+	if branch == nil {
+		return
+	}
+	if !branch.IsLeaf() {
+		axis := branch.Depth() % 2
+		left, right := box, box
+		left[axis][1], right[axis][0] = branch.Pivot(), branch.Pivot()
+		partition(branch.Left(), left, fc)
+		partition(branch.Right(), right, fc)
+		return
+	}
+	lat, lon := box[0], box[1]
+	fc.Features = append(fc.Features, &Feature{
+		Type: "Feature",
+		Geometry: NewGeometry("Polygon", [][][2]float64{{
+			{lon[0], lat[0]}, {lon[1], lat[0]}, {lon[1], lat[1]}, {lon[0], lat[1]}, {lon[0], lat[0]},
+		}}),
+		Properties: map[string]interface{}{
+			"depth": branch.Depth(),
+			"count": len(branch.Datapoints),
+		},
+	})
+}
+
+// NewGeometry returns a Geometry of the given type, such as "Polygon", with
+// the coordinates encoded as they are given: no axes are swapped.
+func NewGeometry(kind string, coordinates interface{}) *Geometry {
+	encoded, _ := json.Marshal(coordinates)
+	return &Geometry{Type: kind, Coordinates: encoded}
+}
@@ -0,0 +1,126 @@
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/geo"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+const sample = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [174.76, -36.85, 12]}, "properties": {"name": "Auckland"}},
+		{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[-0.13, 51.51], [2.35, 48.86]]}, "properties": {"name": "capitals"}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": null},
+		{"type": "Feature", "geometry": null, "properties": {"name": "nowhere"}}
+	]
+}`
+
+func Test_GeoJSON_Read(t *testing.T) {
+	ds, err := Read(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readTests := []struct {
+		lat, lon float64
+		name     string
+	}{
+		{-36.85, 174.76, "Auckland"},
+		{51.51, -0.13, "capitals"},
+		{48.86, 2.35, "capitals"},
+	}
+	if len(ds) != len(readTests) {
+		t.Fatal(`want: `, len(readTests), ` Datapoints
+		got: `, len(ds))
+	}
+	for i, rt := range readTests {
+		lat, lon := geo.LatLon(ds[i])
+		name := ds[i].Data().(map[string]interface{})["name"]
+		if lat != rt.lat || lon != rt.lon || name != rt.name {
+			t.Error(`want: `, rt.lat, rt.lon, rt.name, `
+			got: `, lat, lon, name)
+		}
+	}
+
+	errorTests := []struct {
+		input string
+		want  error
+	}{
+		{`{"type": "Feature", "features": []}`, ErrNotFeatureCollection},
+		{`{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1]}}]}`, ErrPosition},
+	}
+	for _, et := range errorTests {
+		if _, err := Read(strings.NewReader(et.input)); err != et.want {
+			t.Error(`want: `, et.want, `
+			got: `, err)
+		}
+	}
+	if _, err := Read(strings.NewReader(`{"type": `)); err == nil {
+		t.Error(`malformed JSON should be an error`)
+	}
+}
+
+func Test_GeoJSON_Points_Round_Trip(t *testing.T) {
+	ds, _ := Read(strings.NewReader(sample))
+	ds = append(ds, geo.NewDatapoint(7, -45, -170))
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(Points(ds)); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.EqualTo(ds) {
+		t.Error(`want: `, ds.PointsSetString(), `
+		got: `, again.PointsSetString())
+	}
+	if again[0].Data().(map[string]interface{})["name"] != "Auckland" || again[3].Data().(map[string]interface{})["data"] != 7.0 {
+		t.Error(`properties should survive the round trip`)
+	}
+}
+
+func Test_GeoJSON_Partitions(t *testing.T) {
+	r := rand.New(rand.NewSource(72))
+	ds := make(kdtree.Datapoints, 300)
+	for i := range ds {
+		ds[i] = geo.NewDatapoint(i, r.Float64()*20-40, r.Float64()*30+160)
+	}
+	tree, _ := kdtree.NewTree(ds, kdtree.Median)
+	fc := Partitions(tree.Root())
+
+	bounds := tree.Root().Bounds()
+	var area float64
+	count := 0
+	for _, f := range fc.Features {
+		var rings [][][2]float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &rings); err != nil || f.Geometry.Type != "Polygon" {
+			t.Fatal(`want: a Polygon
+			got: `, f.Geometry.Type, err)
+		}
+		ring := rings[0]
+		if len(ring) != 5 || ring[0] != ring[4] {
+			t.Fatal(`the ring should be a closed box: `, ring)
+		}
+		area += (ring[2][0] - ring[0][0]) * (ring[2][1] - ring[0][1])
+		count += f.Properties["count"].(int)
+	}
+	want := (bounds[0].Max() - bounds[0].Min()) * (bounds[1].Max() - bounds[1].Min())
+	if math.Abs(area-want) > 1e-9*want {
+		t.Error(`the partitions should tile the bounds, want: `, want, `
+		got: `, area)
+	}
+	if count != len(ds) {
+		t.Error(`want: `, len(ds), `
+		got: `, count)
+	}
+	if len(Partitions(nil).Features) != 0 {
+		t.Error(`nothing should have no partitions`)
+	}
+}
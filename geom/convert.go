@@ -0,0 +1,42 @@
+package geom
+
+import "github.com/benjamin-rood/goeometric/kdtree"
+
+// Vec2From returns the values of a two-dimensional Datapoint as a Vec2.
+func Vec2From(d *kdtree.Datapoint) (Vec2, error) {
+	if d == nil || d.Dimensionality() != 2 {
+		return Vec2{}, kdtree.ErrDimensionMismatch
+	}
+	return Vec2{d.At(0), d.At(1)}, nil
+}
+
+// Vec3From returns the values of a three-dimensional Datapoint as a Vec3.
+func Vec3From(d *kdtree.Datapoint) (Vec3, error) {
+	if d == nil || d.Dimensionality() != 3 {
+		return Vec3{}, kdtree.ErrDimensionMismatch
+	}
+	return Vec3{d.At(0), d.At(1), d.At(2)}, nil
+}
+
+// VecNFrom returns a copy of the values of a Datapoint as a VecN.
+func VecNFrom(d *kdtree.Datapoint) (VecN, error) {
+	if d == nil {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	return VecN(d.Set()), nil
+}
+
+// Datapoint returns a new Datapoint at v, associated with data.
+func (v Vec2) Datapoint(data interface{}) *kdtree.Datapoint {
+	return kdtree.NewDatapoint(data, v[:])
+}
+
+// Datapoint returns a new Datapoint at v, associated with data.
+func (v Vec3) Datapoint(data interface{}) *kdtree.Datapoint {
+	return kdtree.NewDatapoint(data, v[:])
+}
+
+// Datapoint returns a new Datapoint at v, associated with data.
+func (v VecN) Datapoint(data interface{}) *kdtree.Datapoint {
+	return kdtree.NewDatapoint(data, v)
+}
@@ -0,0 +1,158 @@
+package geom
+
+import (
+	"math"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func Test_Geom_Vec_Operations(t *testing.T) {
+	v, w := Vec3{1, 2, 3}, Vec3{4, -5, 6}
+	if got := v.Add(w).Sub(w); got != v {
+		t.Error(`want: `, v, `
+		got: `, got)
+	}
+	if got := v.Cross(w); got != (Vec3{27, 6, -13}) || got.Dot(v) != 0 || got.Dot(w) != 0 {
+		t.Error(`want: [27 6 -13]
+		got: `, got)
+	}
+	if got := v.Lerp(w, 0.5); got != (Vec3{2.5, -1.5, 4.5}) {
+		t.Error(`want: [2.5 -1.5 4.5]
+		got: `, got)
+	}
+	if got := (Vec3{3, 4, 12}).Norm(); got != 13 {
+		t.Error(`want: 13
+		got: `, got)
+	}
+	if got := (Vec2{1, 0}).Cross(Vec2{0, 1}); got != 1 {
+		t.Error(`want: 1
+		got: `, got)
+	}
+	if got := (Vec2{3, 4}).Unit(); math.Abs(got.Norm()-1) > 1e-15 || (Vec2{}).Unit() != (Vec2{}) {
+		t.Error(`Unit should scale to length 1 and leave the zero vector alone`)
+	}
+	n, m := VecN{1, 2, 3, 4}, VecN{2, 2, 2, 2}
+	if got := n.Dot(m); got != 20 {
+		t.Error(`want: 20
+		got: `, got)
+	}
+	if got := n.Scale(2).Sub(m).Add(m).Dist(n.Lerp(m, 0).Scale(2)); got != 0 {
+		t.Error(`want: 0
+		got: `, got)
+	}
+	if got := n.DistSq(m); got != 1+0+1+4 {
+		t.Error(`want: 6
+		got: `, got)
+	}
+}
+
+func Test_Geom_Datapoint_Conversions(t *testing.T) {
+	d := kdtree.NewDatapoint(`x`, []float64{1, 2, 3})
+	v, err := Vec3From(d)
+	if err != nil || v != (Vec3{1, 2, 3}) {
+		t.Error(`want: [1 2 3]
+		got: `, v, err)
+	}
+	if _, err := Vec2From(d); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	if back := v.Datapoint(`x`); !back.EqualTo(d) || back.Data() != `x` {
+		t.Error(`want: `, d, `
+		got: `, back)
+	}
+	n, err := VecNFrom(d)
+	n[0] = 100
+	if err != nil || d.At(0) != 1 || !n.Datapoint(nil).EqualTo(kdtree.NewDatapoint(nil, []float64{100, 2, 3})) {
+		t.Error(`VecNFrom should copy the Datapoint's values`)
+	}
+	if _, err := VecNFrom(nil); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
+
+func Test_Geom_Intersections(t *testing.T) {
+	ray := Ray{Origin: Vec3{0, 0, -5}, Dir: Vec3{0, 0, 1}}
+	if got, ok := ray.IntersectSphere(Sphere{Vec3{0, 0, 0}, 2}); !ok || got != 3 {
+		t.Error(`sphere want: 3
+		got: `, got, ok)
+	}
+	if _, ok := ray.IntersectSphere(Sphere{Vec3{3, 0, 0}, 2}); ok {
+		t.Error(`the Ray should pass beside the Sphere`)
+	}
+	if got, ok := (Ray{Origin: Vec3{}, Dir: Vec3{1, 0, 0}}).IntersectSphere(Sphere{Vec3{}, 1}); !ok || got != 0 {
+		t.Error(`a Ray starting inside should report 0, got: `, got, ok)
+	}
+	if got, ok := ray.IntersectPlane(NewPlane(Vec3{0, 0, 1}, Vec3{0, 0, -7})); !ok || got != 6 {
+		t.Error(`plane want: 6
+		got: `, got, ok)
+	}
+	if got, ok := ray.IntersectBox(NewBox(Vec3{-1, -1, -1}, Vec3{1, 1, 1})); !ok || got != 4 {
+		t.Error(`box want: 4
+		got: `, got, ok)
+	}
+	if _, ok := ray.IntersectBox(NewBox(Vec3{2, 2, 2}, Vec3{3, 3, 3})); ok {
+		t.Error(`the Ray should miss the Box`)
+	}
+	tr := Triangle{Vec3{-1, -1, 2}, Vec3{1, -1, 2}, Vec3{0, 1, 2}}
+	if got, ok := ray.IntersectTriangle(tr); !ok || got != 7 {
+		t.Error(`triangle want: 7
+		got: `, got, ok)
+	}
+	if tr.Area() != 2 || tr.Normal() != (Vec3{0, 0, 1}) {
+		t.Error(`want: 2 [0 0 1]
+		got: `, tr.Area(), tr.Normal())
+	}
+
+	plane := PlaneThrough(Vec3{0, 0, 1}, Vec3{1, 0, 1}, Vec3{0, 1, 1})
+	if got := plane.SignedDistance(Vec3{5, 5, 4}); got != 3 {
+		t.Error(`want: 3
+		got: `, got)
+	}
+	if got := plane.Project(Vec3{5, 5, 4}); got != (Vec3{5, 5, 1}) {
+		t.Error(`want: [5 5 1]
+		got: `, got)
+	}
+	if got, ok := plane.IntersectSegment(Segment{Vec3{0, 0, 0}, Vec3{4, 0, 4}}); !ok || got != (Vec3{1, 0, 1}) {
+		t.Error(`want: [1 0 1]
+		got: `, got, ok)
+	}
+	if _, ok := plane.IntersectSegment(Segment{Vec3{0, 0, 2}, Vec3{4, 0, 4}}); ok {
+		t.Error(`the Segment lies wholly above the Plane`)
+	}
+
+	seg := Segment{Vec3{0, 0, 0}, Vec3{10, 0, 0}}
+	if got := seg.ClosestPoint(Vec3{3, 4, 0}); got != (Vec3{3, 0, 0}) {
+		t.Error(`want: [3 0 0]
+		got: `, got)
+	}
+	if got := seg.ClosestPoint(Vec3{-3, 4, 0}); got != seg.A {
+		t.Error(`want: `, seg.A, `
+		got: `, got)
+	}
+	if got, ok := (Segment2{Vec2{0, 0}, Vec2{2, 2}}).Intersect(Segment2{Vec2{0, 2}, Vec2{2, 0}}); !ok || got != (Vec2{1, 1}) {
+		t.Error(`want: [1 1]
+		got: `, got, ok)
+	}
+	if _, ok := (Segment2{Vec2{0, 0}, Vec2{1, 1}}).Intersect(Segment2{Vec2{0, 2}, Vec2{0.9, 1.1}}); ok {
+		t.Error(`the Segment2s stop short of each other`)
+	}
+
+	box := NewBox(Vec3{1, 1, 1}, Vec3{-1, -1, -1})
+	if box.Min != (Vec3{-1, -1, -1}) || box.Volume() != 8 || box.SurfaceArea() != 24 || !EmptyBox().IsEmpty() {
+		t.Error(`NewBox should order its corners`)
+	}
+	if got := box.DistSq(Vec3{3, 0, 2}); got != 5 {
+		t.Error(`want: 5
+		got: `, got)
+	}
+	sphere := Sphere{Vec3{3, 0, 0}, 2}
+	if !sphere.IntersectsBox(box) || !sphere.IntersectsSphere(Sphere{Vec3{-1, 0, 0}, 2}) || sphere.Contains(Vec3{0, 0, 0}) {
+		t.Error(`Sphere tests disagreed`)
+	}
+	if !box.Overlaps(sphere.Bounds()) || box.Overlaps(NewBox(Vec3{2, 2, 2}, Vec3{3, 3, 3})) {
+		t.Error(`Box overlap tests disagreed`)
+	}
+}
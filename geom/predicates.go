@@ -0,0 +1,236 @@
+package geom
+
+import (
+	"math"
+	"math/big"
+)
+
+// The predicates below follow Shewchuk, "Adaptive Precision Floating-Point
+// Arithmetic and Fast Robust Geometric Predicates" (1997). Each is first
+// evaluated in ordinary floating point alongside a bound on its rounding
+// error; only when the result is too close to zero for its sign to be
+// trusted is it evaluated again exactly, using big.Rat. Since nearly every
+// call in practice is decided by the first stage, they cost little more than
+// the naive formulae, yet the sign they return is always correct.
+
+// Error bound coefficients, from Shewchuk's predicates.c.
+var (
+	unitRoundoff = math.Ldexp(1, -53)
+	ccwErrBoundA = (3 + 16*unitRoundoff) * unitRoundoff
+	o3dErrBoundA = (7 + 56*unitRoundoff) * unitRoundoff
+	iccErrBoundA = (10 + 96*unitRoundoff) * unitRoundoff
+	ispErrBoundA = (16 + 224*unitRoundoff) * unitRoundoff
+)
+
+// Orient2D returns a positive value if a, b and c occur in anticlockwise
+// order, a negative value if they occur in clockwise order, and zero if they
+// are collinear. The result approximates twice the signed area of the
+// triangle abc, and its sign is always exact.
+func Orient2D(a, b, c Vec2) float64 {
+	detLeft := (a[0] - c[0]) * (b[1] - c[1])
+	detRight := (a[1] - c[1]) * (b[0] - c[0])
+	det := detLeft - detRight
+
+	var detSum float64
+	switch {
+	case detLeft > 0:
+		if detRight <= 0 {
+			return det
+		}
+		detSum = detLeft + detRight
+	case detLeft < 0:
+		if detRight >= 0 {
+			return det
+		}
+		detSum = -detLeft - detRight
+	default:
+		return det
+	}
+	if errBound := ccwErrBoundA * detSum; det >= errBound || -det >= errBound {
+		return det
+	}
+	return exact(det, orientExact, a[:], b[:], c[:])
+}
+
+// Orient3D returns a positive value if d lies below the plane through a, b
+// and c, a negative value if it lies above, and zero if the four are
+// coplanar; "below" is the side from which a, b and c appear clockwise.
+// The result approximates six times the signed volume of the tetrahedron
+// abcd, and its sign is always exact.
+func Orient3D(a, b, c, d Vec3) float64 {
+	adx, bdx, cdx := a[0]-d[0], b[0]-d[0], c[0]-d[0]
+	ady, bdy, cdy := a[1]-d[1], b[1]-d[1], c[1]-d[1]
+	adz, bdz, cdz := a[2]-d[2], b[2]-d[2], c[2]-d[2]
+
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+
+	det := adz*(bdxcdy-cdxbdy) + bdz*(cdxady-adxcdy) + cdz*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*math.Abs(adz) +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*math.Abs(bdz) +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*math.Abs(cdz)
+	if errBound := o3dErrBoundA * permanent; det > errBound || -det > errBound {
+		return det
+	}
+	return exact(det, orientExact, a[:], b[:], c[:], d[:])
+}
+
+// InCircle returns a positive value if d lies inside the circle through a, b
+// and c, a negative value if it lies outside, and zero if the four are
+// cocircular. The points a, b and c must be in anticlockwise order
+// (Orient2D(a, b, c) > 0), or the sign of the result is reversed.
+func InCircle(a, b, c, d Vec2) float64 {
+	adx, bdx, cdx := a[0]-d[0], b[0]-d[0], c[0]-d[0]
+	ady, bdy, cdy := a[1]-d[1], b[1]-d[1], c[1]-d[1]
+
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	aLift := adx*adx + ady*ady
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	bLift := bdx*bdx + bdy*bdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+	cLift := cdx*cdx + cdy*cdy
+
+	det := aLift*(bdxcdy-cdxbdy) + bLift*(cdxady-adxcdy) + cLift*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*aLift +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*bLift +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*cLift
+	if errBound := iccErrBoundA * permanent; det > errBound || -det > errBound {
+		return det
+	}
+	return exact(det, inSphereExact, a[:], b[:], c[:], d[:])
+}
+
+// InSphere returns a positive value if e lies inside the sphere through a,
+// b, c and d, a negative value if it lies outside, and zero if the five are
+// cospherical. The points a, b, c and d must be positively oriented
+// (Orient3D(a, b, c, d) > 0), or the sign of the result is reversed.
+func InSphere(a, b, c, d, e Vec3) float64 {
+	aex, bex, cex, dex := a[0]-e[0], b[0]-e[0], c[0]-e[0], d[0]-e[0]
+	aey, bey, cey, dey := a[1]-e[1], b[1]-e[1], c[1]-e[1], d[1]-e[1]
+	aez, bez, cez, dez := a[2]-e[2], b[2]-e[2], c[2]-e[2], d[2]-e[2]
+
+	aexbey, bexaey := aex*bey, bex*aey
+	bexcey, cexbey := bex*cey, cex*bey
+	cexdey, dexcey := cex*dey, dex*cey
+	dexaey, aexdey := dex*aey, aex*dey
+	aexcey, cexaey := aex*cey, cex*aey
+	bexdey, dexbey := bex*dey, dex*bey
+	ab, bc, cd, da := aexbey-bexaey, bexcey-cexbey, cexdey-dexcey, dexaey-aexdey
+	ac, bd := aexcey-cexaey, bexdey-dexbey
+
+	abc := aez*bc - bez*ac + cez*ab
+	bcd := bez*cd - cez*bd + dez*bc
+	cda := cez*da + dez*ac + aez*cd
+	dab := dez*ab + aez*bd + bez*da
+
+	aLift := aex*aex + aey*aey + aez*aez
+	bLift := bex*bex + bey*bey + bez*bez
+	cLift := cex*cex + cey*cey + cez*cez
+	dLift := dex*dex + dey*dey + dez*dez
+
+	det := (dLift*abc - cLift*dab) + (bLift*cda - aLift*bcd)
+
+	abs := math.Abs
+	aez, bez, cez, dez = abs(aez), abs(bez), abs(cez), abs(dez)
+	aexbey, bexaey, bexcey, cexbey = abs(aexbey), abs(bexaey), abs(bexcey), abs(cexbey)
+	cexdey, dexcey, dexaey, aexdey = abs(cexdey), abs(dexcey), abs(dexaey), abs(aexdey)
+	aexcey, cexaey, bexdey, dexbey = abs(aexcey), abs(cexaey), abs(bexdey), abs(dexbey)
+	permanent := ((cexdey+dexcey)*bez+(dexbey+bexdey)*cez+(bexcey+cexbey)*dez)*aLift +
+		((dexaey+aexdey)*cez+(aexcey+cexaey)*dez+(cexdey+dexcey)*aez)*bLift +
+		((aexbey+bexaey)*dez+(bexdey+dexbey)*aez+(dexaey+aexdey)*bez)*cLift +
+		((bexcey+cexbey)*aez+(cexaey+aexcey)*bez+(aexbey+bexaey)*cez)*dLift
+	if errBound := ispErrBoundA * permanent; det > errBound || -det > errBound {
+		return det
+	}
+	return exact(det, inSphereExact, a[:], b[:], c[:], d[:], e[:])
+}
+
+// exact evaluates a predicate exactly over the points given, returning a
+// value with the exact sign. The estimate is returned unchanged if any
+// coordinate is not finite, since there is then no exact answer to give.
+func exact(estimate float64, fn func(ps [][]*big.Rat) *big.Rat, points ...[]float64) float64 {
+	ps := make([][]*big.Rat, len(points))
+	for i, p := range points {
+		ps[i] = make([]*big.Rat, len(p))
+		for axis, f := range p {
+			if ps[i][axis] = new(big.Rat).SetFloat64(f); ps[i][axis] == nil {
+				return estimate
+			}
+		}
+	}
+	det := fn(ps)
+	f, _ := det.Float64()
+	if f == 0 && det.Sign() != 0 {
+		// the determinant is too small to represent, but its sign is known
+		f = float64(det.Sign()) * math.SmallestNonzeroFloat64
+	}
+	return f
+}
+
+func sub(a, b *big.Rat) *big.Rat { return new(big.Rat).Sub(a, b) }
+func mul(a, b *big.Rat) *big.Rat { return new(big.Rat).Mul(a, b) }
+func add(a, b *big.Rat) *big.Rat { return new(big.Rat).Add(a, b) }
+
+// translated returns every point but the last, less the last.
+func translated(ps [][]*big.Rat) [][]*big.Rat {
+	origin := ps[len(ps)-1]
+	rows := make([][]*big.Rat, len(ps)-1)
+	for i := range rows {
+		rows[i] = make([]*big.Rat, len(origin))
+		for axis := range origin {
+			rows[i][axis] = sub(ps[i][axis], origin[axis])
+		}
+	}
+	return rows
+}
+
+// lifted appends the squared length of each row to it.
+func lifted(rows [][]*big.Rat) [][]*big.Rat {
+	for i, row := range rows {
+		lift := new(big.Rat)
+		for _, v := range row {
+			lift = add(lift, mul(v, v))
+		}
+		rows[i] = append(row, lift)
+	}
+	return rows
+}
+
+// det returns the determinant of a square matrix by cofactor expansion
+// along the first row.
+func det(m [][]*big.Rat) *big.Rat {
+	if len(m) == 1 {
+		return m[0][0]
+	}
+	if len(m) == 2 {
+		return sub(mul(m[0][0], m[1][1]), mul(m[0][1], m[1][0]))
+	}
+	result := new(big.Rat)
+	for col := range m {
+		minor := make([][]*big.Rat, len(m)-1)
+		for i := range minor {
+			for j, v := range m[i+1] {
+				if j != col {
+					minor[i] = append(minor[i], v)
+				}
+			}
+		}
+		term := mul(m[0][col], det(minor))
+		if col%2 == 1 {
+			term.Neg(term)
+		}
+		result = add(result, term)
+	}
+	return result
+}
+
+// orientExact serves for both Orient2D and Orient3D.
+func orientExact(ps [][]*big.Rat) *big.Rat {
+	return det(translated(ps))
+}
+
+// inSphereExact serves for both InCircle and InSphere.
+func inSphereExact(ps [][]*big.Rat) *big.Rat {
+	return det(lifted(translated(ps)))
+}
@@ -0,0 +1,105 @@
+package geom
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func sign(f float64) int {
+	switch {
+	case f > 0:
+		return 1
+	case f < 0:
+		return -1
+	}
+	return 0
+}
+
+func rats(points ...[]float64) [][]*big.Rat {
+	ps := make([][]*big.Rat, len(points))
+	for i, p := range points {
+		for _, f := range p {
+			ps[i] = append(ps[i], new(big.Rat).SetFloat64(f))
+		}
+	}
+	return ps
+}
+
+// Test_Geom_Orient2D_Near_Collinear reproduces the grid of Kettner et al.,
+// "Classroom Examples of Robustness Problems in Geometric Computations":
+// points within a few ulps of the line through b and c, where the naive
+// determinant gets the sign wrong for a large fraction of the grid.
+func Test_Geom_Orient2D_Near_Collinear(t *testing.T) {
+	b, c := Vec2{12, 12}, Vec2{24, 24}
+	var naiveWrong int
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 64; j++ {
+			a := Vec2{0.5 + float64(i)*math.Ldexp(1, -53), 0.5 + float64(j)*math.Ldexp(1, -53)}
+			want := orientExact(rats(a[:], b[:], c[:])).Sign()
+			if got := sign(Orient2D(a, b, c)); got != want {
+				t.Fatal(`Orient2D`, a, ` want: `, want, `
+				got: `, got)
+			}
+			if sign((a[0]-c[0])*(b[1]-c[1])-(a[1]-c[1])*(b[0]-c[0])) != want {
+				naiveWrong++
+			}
+		}
+	}
+	if naiveWrong == 0 {
+		t.Error(`the grid should defeat the naive determinant somewhere`)
+	}
+}
+
+func Test_Geom_Predicates_Match_Exact(t *testing.T) {
+	r := rand.New(rand.NewSource(66))
+	// coarse coordinates make degenerate configurations common,
+	// and the small perturbations make nearly degenerate ones common
+	coord := func() float64 {
+		f := float64(r.Intn(8))
+		if r.Intn(2) == 0 {
+			f += float64(r.Intn(5)-2) * math.Ldexp(1, -50)
+		}
+		return f
+	}
+	v2 := func() Vec2 { return Vec2{coord(), coord()} }
+	v3 := func() Vec3 { return Vec3{coord(), coord(), coord()} }
+	for i := 0; i < 20000; i++ {
+		a, b, c, d := v2(), v2(), v2(), v2()
+		if want := orientExact(rats(a[:], b[:], c[:])).Sign(); sign(Orient2D(a, b, c)) != want {
+			t.Fatal(`Orient2D`, a, b, c, ` want: `, want)
+		}
+		if want := inSphereExact(rats(a[:], b[:], c[:], d[:])).Sign(); sign(InCircle(a, b, c, d)) != want {
+			t.Fatal(`InCircle`, a, b, c, d, ` want: `, want)
+		}
+		p, q, s, u, w := v3(), v3(), v3(), v3(), v3()
+		if want := orientExact(rats(p[:], q[:], s[:], u[:])).Sign(); sign(Orient3D(p, q, s, u)) != want {
+			t.Fatal(`Orient3D`, p, q, s, u, ` want: `, want)
+		}
+		if want := inSphereExact(rats(p[:], q[:], s[:], u[:], w[:])).Sign(); sign(InSphere(p, q, s, u, w)) != want {
+			t.Fatal(`InSphere`, p, q, s, u, w, ` want: `, want)
+		}
+	}
+}
+
+func Test_Geom_Predicate_Conventions(t *testing.T) {
+	a, b, c := Vec2{0, 0}, Vec2{1, 0}, Vec2{0, 1}
+	if Orient2D(a, b, c) != 1 || Orient2D(a, c, b) != -1 || Orient2D(a, b, Vec2{2, 0}) != 0 {
+		t.Error(`Orient2D should be positive for anticlockwise points`)
+	}
+	if InCircle(a, b, c, Vec2{0.5, 0.5}) <= 0 || InCircle(a, b, c, Vec2{2, 2}) >= 0 || InCircle(a, b, c, Vec2{1, 1}) != 0 {
+		t.Error(`InCircle should be positive inside`)
+	}
+	p, q, s := Vec3{0, 0, 0}, Vec3{1, 0, 0}, Vec3{0, 1, 0}
+	below := Vec3{0, 0, -1}
+	if Orient3D(p, q, s, below) <= 0 || Orient3D(p, q, s, Vec3{0, 0, 1}) >= 0 || Orient3D(p, q, s, Vec3{5, 5, 0}) != 0 {
+		t.Error(`Orient3D should be positive below an anticlockwise triangle`)
+	}
+	if InSphere(p, q, s, below, Vec3{0.2, 0.2, -0.2}) <= 0 || InSphere(p, q, s, below, Vec3{3, 3, 3}) >= 0 || InSphere(p, q, s, below, Vec3{1, 1, 0}) != 0 {
+		t.Error(`InSphere should be positive inside`)
+	}
+	if got := Orient2D(Vec2{math.Inf(1), 0}, b, c); !math.IsNaN(got) && !math.IsInf(got, 0) {
+		t.Error(`non-finite input should give the floating-point estimate, got: `, got)
+	}
+}
@@ -0,0 +1,377 @@
+package geom
+
+import "math"
+
+// epsilon is the tolerance below which a direction is taken to be parallel
+// to a plane or triangle.
+const epsilon = 1e-12
+
+// Segment is the line segment between two points in three dimensions.
+type Segment struct {
+	A, B Vec3
+}
+
+// At returns the point a fraction t of the way from A to B.
+func (s Segment) At(t float64) Vec3 {
+	return s.A.Lerp(s.B, t)
+}
+
+// Length returns the distance from A to B.
+func (s Segment) Length() float64 {
+	return s.A.Dist(s.B)
+}
+
+// ClosestPoint returns the point of the Segment nearest to p.
+func (s Segment) ClosestPoint(p Vec3) Vec3 {
+	ab := s.B.Sub(s.A)
+	lengthSq := ab.NormSq()
+	if lengthSq == 0 {
+		return s.A
+	}
+	t := p.Sub(s.A).Dot(ab) / lengthSq
+	return s.At(math.Max(0, math.Min(1, t)))
+}
+
+// Segment2 is the line segment between two points in two dimensions.
+type Segment2 struct {
+	A, B Vec2
+}
+
+// Intersect returns the point at which two Segment2s cross, and whether they
+// do so at exactly one point. Collinear overlapping Segment2s report false.
+func (s Segment2) Intersect(o Segment2) (Vec2, bool) {
+	r, q := s.B.Sub(s.A), o.B.Sub(o.A)
+	denom := r.Cross(q)
+	if denom == 0 {
+		return Vec2{}, false
+	}
+	ao := o.A.Sub(s.A)
+	t, u := ao.Cross(q)/denom, ao.Cross(r)/denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return Vec2{}, false
+	}
+	return s.A.Lerp(s.B, t), true
+}
+
+// Ray is a half-line from an Origin along a direction Dir.
+// Intersections are reported as the parameter t >= 0 of the point Origin + t*Dir.
+type Ray struct {
+	Origin, Dir Vec3
+}
+
+// At returns the point at parameter t along the Ray.
+func (r Ray) At(t float64) Vec3 {
+	return r.Origin.Add(r.Dir.Scale(t))
+}
+
+// IntersectPlane returns the parameter at which the Ray crosses the Plane,
+// and whether it does so at all.
+func (r Ray) IntersectPlane(p Plane) (float64, bool) {
+	denom := p.Normal.Dot(r.Dir)
+	if math.Abs(denom) < epsilon {
+		return 0, false
+	}
+	t := (p.D - p.Normal.Dot(r.Origin)) / denom
+	return t, t >= 0
+}
+
+// IntersectSphere returns the smallest parameter at which the Ray meets the
+// Sphere, which is 0 when it starts inside, and whether it meets it at all.
+func (r Ray) IntersectSphere(s Sphere) (float64, bool) {
+	oc := r.Origin.Sub(s.Centre)
+	a := r.Dir.NormSq()
+	b := oc.Dot(r.Dir)
+	c := oc.NormSq() - s.Radius*s.Radius
+	if c <= 0 {
+		return 0, true
+	}
+	disc := b*b - a*c
+	if disc < 0 || b > 0 {
+		return 0, false
+	}
+	return (-b - math.Sqrt(disc)) / a, true
+}
+
+// IntersectBox returns the parameter at which the Ray enters the Box, which
+// is 0 when it starts inside, and whether it meets the Box at all.
+// It is the slab test of Kay and Kajiya.
+func (r Ray) IntersectBox(b Box) (float64, bool) {
+	tmin, tmax := 0.0, math.Inf(1)
+	for axis := range r.Origin {
+		inv := 1 / r.Dir[axis]
+		t0 := (b.Min[axis] - r.Origin[axis]) * inv
+		t1 := (b.Max[axis] - r.Origin[axis]) * inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tmin {
+			tmin = t0
+		}
+		if t1 < tmax {
+			tmax = t1
+		}
+		if tmin > tmax {
+			return 0, false
+		}
+	}
+	return tmin, true
+}
+
+// IntersectTriangle returns the parameter at which the Ray meets the
+// Triangle, and whether it does so at all, by the Möller–Trumbore test.
+func (r Ray) IntersectTriangle(tr Triangle) (float64, bool) {
+	e1, e2 := tr.B.Sub(tr.A), tr.C.Sub(tr.A)
+	p := r.Dir.Cross(e2)
+	det := e1.Dot(p)
+	if math.Abs(det) < epsilon {
+		return 0, false
+	}
+	inv := 1 / det
+	s := r.Origin.Sub(tr.A)
+	u := s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(e1)
+	v := r.Dir.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	t := e2.Dot(q) * inv
+	return t, t >= 0
+}
+
+// Plane is the set of points x with Normal·x = D, where Normal has unit length.
+type Plane struct {
+	Normal Vec3
+	D      float64
+}
+
+// NewPlane returns the Plane through a point perpendicular to a normal of any length.
+func NewPlane(point, normal Vec3) Plane {
+	n := normal.Unit()
+	return Plane{n, n.Dot(point)}
+}
+
+// PlaneThrough returns the Plane through three points, with its Normal
+// following the right-hand rule from a to b to c.
+func PlaneThrough(a, b, c Vec3) Plane {
+	return NewPlane(a, b.Sub(a).Cross(c.Sub(a)))
+}
+
+// SignedDistance returns the distance from the Plane to p, positive on the side the Normal faces.
+func (p Plane) SignedDistance(q Vec3) float64 {
+	return p.Normal.Dot(q) - p.D
+}
+
+// Project returns the point of the Plane nearest to q.
+func (p Plane) Project(q Vec3) Vec3 {
+	return q.Sub(p.Normal.Scale(p.SignedDistance(q)))
+}
+
+// IntersectSegment returns the point at which the Segment crosses the Plane,
+// and whether it does so at all.
+func (p Plane) IntersectSegment(s Segment) (Vec3, bool) {
+	da, db := p.SignedDistance(s.A), p.SignedDistance(s.B)
+	if (da > 0 && db > 0) || (da < 0 && db < 0) || da == db {
+		return Vec3{}, false
+	}
+	return s.At(da / (da - db)), true
+}
+
+// Sphere is the solid ball of a Radius about a Centre.
+type Sphere struct {
+	Centre Vec3
+	Radius float64
+}
+
+// Contains reports whether p lies within the Sphere (inclusive).
+func (s Sphere) Contains(p Vec3) bool {
+	return s.Centre.DistSq(p) <= s.Radius*s.Radius
+}
+
+// IntersectsSphere reports whether two Spheres share at least one point.
+func (s Sphere) IntersectsSphere(o Sphere) bool {
+	r := s.Radius + o.Radius
+	return s.Centre.DistSq(o.Centre) <= r*r
+}
+
+// IntersectsBox reports whether the Sphere and the Box share at least one point.
+func (s Sphere) IntersectsBox(b Box) bool {
+	return b.DistSq(s.Centre) <= s.Radius*s.Radius
+}
+
+// Bounds returns the smallest Box enclosing the Sphere.
+func (s Sphere) Bounds() Box {
+	r := Vec3{s.Radius, s.Radius, s.Radius}
+	return Box{s.Centre.Sub(r), s.Centre.Add(r)}
+}
+
+// Triangle is the triangle with vertices A, B and C.
+type Triangle struct {
+	A, B, C Vec3
+}
+
+// Normal returns the unit normal of the Triangle, following the right-hand rule.
+func (tr Triangle) Normal() Vec3 {
+	return tr.B.Sub(tr.A).Cross(tr.C.Sub(tr.A)).Unit()
+}
+
+// Area returns the area of the Triangle.
+func (tr Triangle) Area() float64 {
+	return tr.B.Sub(tr.A).Cross(tr.C.Sub(tr.A)).Norm() / 2
+}
+
+// Bounds returns the smallest Box enclosing the Triangle.
+func (tr Triangle) Bounds() Box {
+	return NewBox(tr.A, tr.B).Extend(tr.C)
+}
+
+// ClosestPoint returns the point of the Triangle nearest to p, by finding
+// which of its vertex, edge or face regions p projects into
+// (Ericson, Real-Time Collision Detection, §5.1.5).
+func (tr Triangle) ClosestPoint(p Vec3) Vec3 {
+	a, b, c := tr.A, tr.B, tr.C
+	ab, ac, ap := b.Sub(a), c.Sub(a), p.Sub(a)
+	d1, d2 := ab.Dot(ap), ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bp := p.Sub(b)
+	d3, d4 := ab.Dot(bp), ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return a.Add(ab.Scale(d1 / (d1 - d3)))
+	}
+	cp := p.Sub(c)
+	d5, d6 := ab.Dot(cp), ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return a.Add(ac.Scale(d2 / (d2 - d6)))
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return b.Add(c.Sub(b).Scale((d4 - d3) / ((d4 - d3) + (d5 - d6))))
+	}
+	denom := 1 / (va + vb + vc)
+	return a.Add(ab.Scale(vb * denom)).Add(ac.Scale(vc * denom))
+}
+
+// Box is an axis-aligned box, closed on every side.
+type Box struct {
+	Min, Max Vec3
+}
+
+// NewBox returns the Box spanning two corners given in any order.
+func NewBox(a, b Vec3) Box {
+	return EmptyBox().Extend(a).Extend(b)
+}
+
+// EmptyBox returns a Box containing nothing, which is the identity for Union and Extend.
+func EmptyBox() Box {
+	inf := math.Inf(1)
+	return Box{Vec3{inf, inf, inf}, Vec3{-inf, -inf, -inf}}
+}
+
+// IsEmpty reports whether the Box contains nothing.
+func (b Box) IsEmpty() bool {
+	return b.Min[0] > b.Max[0] || b.Min[1] > b.Max[1] || b.Min[2] > b.Max[2]
+}
+
+// Extend returns the smallest Box enclosing both b and p.
+func (b Box) Extend(p Vec3) Box {
+	for axis := range p {
+		b.Min[axis] = math.Min(b.Min[axis], p[axis])
+		b.Max[axis] = math.Max(b.Max[axis], p[axis])
+	}
+	return b
+}
+
+// Union returns the smallest Box enclosing both Boxes.
+func (b Box) Union(o Box) Box {
+	for axis := range b.Min {
+		b.Min[axis] = math.Min(b.Min[axis], o.Min[axis])
+		b.Max[axis] = math.Max(b.Max[axis], o.Max[axis])
+	}
+	return b
+}
+
+// Centroid returns the centre of the Box.
+func (b Box) Centroid() Vec3 {
+	return b.Min.Lerp(b.Max, 0.5)
+}
+
+// Size returns the extent of the Box along each axis.
+func (b Box) Size() Vec3 {
+	return b.Max.Sub(b.Min)
+}
+
+// SurfaceArea returns the surface area of the Box, or 0 when it is empty.
+func (b Box) SurfaceArea() float64 {
+	if b.IsEmpty() {
+		return 0
+	}
+	d := b.Size()
+	return 2 * (d[0]*d[1] + d[1]*d[2] + d[2]*d[0])
+}
+
+// Volume returns the volume of the Box, or 0 when it is empty.
+func (b Box) Volume() float64 {
+	if b.IsEmpty() {
+		return 0
+	}
+	d := b.Size()
+	return d[0] * d[1] * d[2]
+}
+
+// LongestAxis returns the axis along which the Box is widest.
+func (b Box) LongestAxis() int {
+	d := b.Size()
+	axis := 0
+	if d[1] > d[axis] {
+		axis = 1
+	}
+	if d[2] > d[axis] {
+		axis = 2
+	}
+	return axis
+}
+
+// Contains reports whether p lies within the Box (inclusive).
+func (b Box) Contains(p Vec3) bool {
+	for axis := range p {
+		if p[axis] < b.Min[axis] || p[axis] > b.Max[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// Overlaps reports whether the Boxes share at least one point.
+func (b Box) Overlaps(o Box) bool {
+	for axis := range b.Min {
+		if b.Min[axis] > o.Max[axis] || o.Min[axis] > b.Max[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// ClosestPoint returns the point of the Box nearest to p.
+func (b Box) ClosestPoint(p Vec3) Vec3 {
+	for axis := range p {
+		p[axis] = math.Max(b.Min[axis], math.Min(b.Max[axis], p[axis]))
+	}
+	return p
+}
+
+// DistSq returns the squared distance from p to the nearest point of the Box.
+func (b Box) DistSq(p Vec3) float64 {
+	return p.DistSq(b.ClosestPoint(p))
+}
@@ -0,0 +1,205 @@
+package geom
+
+import "math"
+
+// Vec2 is a point or a vector in two dimensions.
+type Vec2 [2]float64
+
+// Vec3 is a point or a vector in three dimensions.
+type Vec3 [3]float64
+
+// VecN is a point or a vector in any number of dimensions.
+// Operations on two VecN assume both share the same dimensionality.
+type VecN []float64
+
+// Add returns v + w.
+func (v Vec2) Add(w Vec2) Vec2 {
+	return Vec2{v[0] + w[0], v[1] + w[1]}
+}
+
+// Sub returns v - w.
+func (v Vec2) Sub(w Vec2) Vec2 {
+	return Vec2{v[0] - w[0], v[1] - w[1]}
+}
+
+// Scale returns v multiplied by s.
+func (v Vec2) Scale(s float64) Vec2 {
+	return Vec2{v[0] * s, v[1] * s}
+}
+
+// Dot returns the dot product of v and w.
+func (v Vec2) Dot(w Vec2) float64 {
+	return v[0]*w[0] + v[1]*w[1]
+}
+
+// Cross returns the z component of the cross product of v and w, which is
+// positive when w lies anticlockwise of v.
+func (v Vec2) Cross(w Vec2) float64 {
+	return v[0]*w[1] - v[1]*w[0]
+}
+
+// NormSq returns the squared Euclidean length of v.
+func (v Vec2) NormSq() float64 {
+	return v.Dot(v)
+}
+
+// Norm returns the Euclidean length of v.
+func (v Vec2) Norm() float64 {
+	return math.Hypot(v[0], v[1])
+}
+
+// Unit returns v scaled to unit length, or v itself when it has none.
+func (v Vec2) Unit() Vec2 {
+	if n := v.Norm(); n > 0 {
+		return v.Scale(1 / n)
+	}
+	return v
+}
+
+// Dist returns the Euclidean distance between v and w.
+func (v Vec2) Dist(w Vec2) float64 {
+	return v.Sub(w).Norm()
+}
+
+// DistSq returns the squared Euclidean distance between v and w.
+func (v Vec2) DistSq(w Vec2) float64 {
+	return v.Sub(w).NormSq()
+}
+
+// Lerp returns the point a fraction t of the way from v to w.
+func (v Vec2) Lerp(w Vec2, t float64) Vec2 {
+	return Vec2{v[0] + (w[0]-v[0])*t, v[1] + (w[1]-v[1])*t}
+}
+
+// Add returns v + w.
+func (v Vec3) Add(w Vec3) Vec3 {
+	return Vec3{v[0] + w[0], v[1] + w[1], v[2] + w[2]}
+}
+
+// Sub returns v - w.
+func (v Vec3) Sub(w Vec3) Vec3 {
+	return Vec3{v[0] - w[0], v[1] - w[1], v[2] - w[2]}
+}
+
+// Scale returns v multiplied by s.
+func (v Vec3) Scale(s float64) Vec3 {
+	return Vec3{v[0] * s, v[1] * s, v[2] * s}
+}
+
+// Dot returns the dot product of v and w.
+func (v Vec3) Dot(w Vec3) float64 {
+	return v[0]*w[0] + v[1]*w[1] + v[2]*w[2]
+}
+
+// Cross returns the cross product of v and w.
+func (v Vec3) Cross(w Vec3) Vec3 {
+	return Vec3{
+		v[1]*w[2] - v[2]*w[1],
+		v[2]*w[0] - v[0]*w[2],
+		v[0]*w[1] - v[1]*w[0],
+	}
+}
+
+// NormSq returns the squared Euclidean length of v.
+func (v Vec3) NormSq() float64 {
+	return v.Dot(v)
+}
+
+// Norm returns the Euclidean length of v.
+func (v Vec3) Norm() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Unit returns v scaled to unit length, or v itself when it has none.
+func (v Vec3) Unit() Vec3 {
+	if n := v.Norm(); n > 0 {
+		return v.Scale(1 / n)
+	}
+	return v
+}
+
+// Dist returns the Euclidean distance between v and w.
+func (v Vec3) Dist(w Vec3) float64 {
+	return v.Sub(w).Norm()
+}
+
+// DistSq returns the squared Euclidean distance between v and w.
+func (v Vec3) DistSq(w Vec3) float64 {
+	return v.Sub(w).NormSq()
+}
+
+// Lerp returns the point a fraction t of the way from v to w.
+func (v Vec3) Lerp(w Vec3, t float64) Vec3 {
+	return Vec3{v[0] + (w[0]-v[0])*t, v[1] + (w[1]-v[1])*t, v[2] + (w[2]-v[2])*t}
+}
+
+// Add returns v + w in a new VecN.
+func (v VecN) Add(w VecN) VecN {
+	u := make(VecN, len(v))
+	for i := range v {
+		u[i] = v[i] + w[i]
+	}
+	return u
+}
+
+// Sub returns v - w in a new VecN.
+func (v VecN) Sub(w VecN) VecN {
+	u := make(VecN, len(v))
+	for i := range v {
+		u[i] = v[i] - w[i]
+	}
+	return u
+}
+
+// Scale returns v multiplied by s in a new VecN.
+func (v VecN) Scale(s float64) VecN {
+	u := make(VecN, len(v))
+	for i := range v {
+		u[i] = v[i] * s
+	}
+	return u
+}
+
+// Dot returns the dot product of v and w.
+func (v VecN) Dot(w VecN) float64 {
+	var result float64
+	for i := range v {
+		result += v[i] * w[i]
+	}
+	return result
+}
+
+// NormSq returns the squared Euclidean length of v.
+func (v VecN) NormSq() float64 {
+	return v.Dot(v)
+}
+
+// Norm returns the Euclidean length of v.
+func (v VecN) Norm() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// DistSq returns the squared Euclidean distance between v and w,
+// without allocating.
+func (v VecN) DistSq(w VecN) float64 {
+	var result float64
+	for i := range v {
+		d := v[i] - w[i]
+		result += d * d
+	}
+	return result
+}
+
+// Dist returns the Euclidean distance between v and w.
+func (v VecN) Dist(w VecN) float64 {
+	return math.Sqrt(v.DistSq(w))
+}
+
+// Lerp returns the point a fraction t of the way from v to w in a new VecN.
+func (v VecN) Lerp(w VecN, t float64) VecN {
+	u := make(VecN, len(v))
+	for i := range v {
+		u[i] = v[i] + (w[i]-v[i])*t
+	}
+	return u
+}
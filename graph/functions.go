@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"github.com/benjamin-rood/goeometric/delaunay"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// FromEdges builds a Graph over ds from a list of edges given as index pairs
+// into ds, such as the edges of a triangulation. Edges joining coincident
+// Datapoints are skipped.
+func FromEdges(ds kdtree.Datapoints, edges [][2]int) (*Graph, error) {
+	g := New()
+	for _, d := range ds {
+		if _, err := g.AddVertex(d); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range edges {
+		if e[0] < 0 || e[0] >= len(ds) || e[1] < 0 || e[1] >= len(ds) {
+			return nil, ErrUnknownVertex
+		}
+		if _, err := g.AddEdge(ds[e[0]], ds[e[1]]); err != nil && err != ErrCoincident {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// FromDelaunay builds the Delaunay triangulation Graph over ds, so that
+// every bounded face is a Delaunay triangle. Repeats of a position are
+// dropped, keeping the first, and ds must not be entirely collinear.
+func FromDelaunay(ds kdtree.Datapoints) (*Graph, error) {
+	tri, err := delaunay.Triangulate(ds)
+	if err != nil {
+		return nil, err
+	}
+	return FromEdges(tri.Points(), tri.Edges())
+}
+
+// FromKNN builds the (symmetric) k-nearest-neighbour Graph over ds, joining
+// every Datapoint to its k nearest neighbours as found by a k-d tree.
+// Neighbours at the same position are skipped and do not count towards k.
+func FromKNN(ds kdtree.Datapoints, k int) (*Graph, error) {
+	g, err := FromEdges(ds, nil)
+	if err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return g, nil
+	}
+	tree, err := kdtree.NewTree(ds, kdtree.Median)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		// widen the search until k neighbours lie past any coincident ones
+		var near kdtree.Datapoints
+		for m := k + 1; ; m *= 2 {
+			near = near[:0]
+			found := tree.KNN(d, m)
+			for _, n := range found {
+				if kdtree.DistanceSq(d, n) > 0 && len(near) < k {
+					near = append(near, n)
+				}
+			}
+			if len(near) == k || len(found) < m {
+				break
+			}
+		}
+		for _, n := range near {
+			if _, err := g.AddEdge(d, n); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,304 @@
+package graph
+
+import (
+	"container/ring"
+	"errors"
+	"math"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+var (
+	// ErrUnknownVertex is returned when an edge refers to a Datapoint which is not a Vertex of the Graph.
+	ErrUnknownVertex = errors.New("graph: datapoint is not a vertex of the graph")
+	// ErrCoincident is returned when an edge would join two Vertices at the same position,
+	// as it has no direction by which to order it around either Vertex.
+	ErrCoincident = errors.New("graph: edge joins coincident vertices")
+)
+
+// Graph is an undirected planar-map style graph over 2-D Datapoints.
+// Every edge is stored as a pair of twin HalfEdges, and the HalfEdges leaving
+// each Vertex are kept in an axial circular list (a container/ring) sorted
+// counter-clockwise by angle, so that adjacency walks and face traversal
+// are simple rotations around the rings.
+type Graph struct {
+	vertices []*Vertex
+	index    map[*kdtree.Datapoint]*Vertex
+	edges    int
+}
+
+// Vertex is a Datapoint of the Graph together with its axial list.
+type Vertex struct {
+	*kdtree.Datapoint
+	darts  *ring.Ring // the outgoing HalfEdge of smallest angle; nil when isolated
+	degree int
+}
+
+// HalfEdge is one direction of an edge, leaving its origin Vertex.
+type HalfEdge struct {
+	origin *Vertex
+	twin   *HalfEdge
+	elem   *ring.Ring // this HalfEdge's place in the origin's axial list
+	angle  float64
+}
+
+// New returns an empty Graph.
+func New() *Graph {
+	return &Graph{index: make(map[*kdtree.Datapoint]*Vertex)}
+}
+
+// Len returns the number of Vertices.
+func (g *Graph) Len() int {
+	return len(g.vertices)
+}
+
+// Edges returns the number of (undirected) edges.
+func (g *Graph) Edges() int {
+	return g.edges
+}
+
+// Vertices returns the Vertices in the order they were added.
+func (g *Graph) Vertices() []*Vertex {
+	vs := make([]*Vertex, len(g.vertices))
+	copy(vs, g.vertices)
+	return vs
+}
+
+// Vertex returns the Vertex for a Datapoint, or nil if it has not been added.
+func (g *Graph) Vertex(d *kdtree.Datapoint) *Vertex {
+	return g.index[d]
+}
+
+// AddVertex adds a 2-D Datapoint as an isolated Vertex, returning the existing
+// Vertex if it has already been added.
+func (g *Graph) AddVertex(d *kdtree.Datapoint) (*Vertex, error) {
+	if d == nil || d.Dimensionality() != 2 {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	if v, exists := g.index[d]; exists {
+		return v, nil
+	}
+	v := &Vertex{Datapoint: d}
+	g.vertices = append(g.vertices, v)
+	g.index[d] = v
+	return v, nil
+}
+
+// AddEdge joins two Vertices, returning the HalfEdge leaving u.
+// If the edge already exists the existing HalfEdge is returned.
+func (g *Graph) AddEdge(u, v *kdtree.Datapoint) (*HalfEdge, error) {
+	uv, vv := g.index[u], g.index[v]
+	if uv == nil || vv == nil {
+		return nil, ErrUnknownVertex
+	}
+	if u.At(0) == v.At(0) && u.At(1) == v.At(1) {
+		return nil, ErrCoincident
+	}
+	if e := uv.edgeTo(vv); e != nil {
+		return e, nil
+	}
+	e := &HalfEdge{origin: uv, angle: math.Atan2(v.At(1)-u.At(1), v.At(0)-u.At(0))}
+	t := &HalfEdge{origin: vv, angle: math.Atan2(u.At(1)-v.At(1), u.At(0)-v.At(0))}
+	e.twin, t.twin = t, e
+	uv.attach(e)
+	vv.attach(t)
+	g.edges++
+	return e, nil
+}
+
+// RemoveEdge removes the edge joining two Vertices, reporting whether it existed.
+func (g *Graph) RemoveEdge(u, v *kdtree.Datapoint) bool {
+	uv, vv := g.index[u], g.index[v]
+	if uv == nil || vv == nil {
+		return false
+	}
+	e := uv.edgeTo(vv)
+	if e == nil {
+		return false
+	}
+	uv.detach(e)
+	vv.detach(e.twin)
+	g.edges--
+	return true
+}
+
+// attach links e into the axial list, keeping it sorted counter-clockwise.
+func (v *Vertex) attach(e *HalfEdge) {
+	e.elem = ring.New(1)
+	e.elem.Value = e
+	v.degree++
+	if v.darts == nil {
+		v.darts = e.elem
+		return
+	}
+	if e.angle < dart(v.darts).angle {
+		v.darts.Prev().Link(e.elem)
+		v.darts = e.elem
+		return
+	}
+	at := v.darts
+	for at.Next() != v.darts && dart(at.Next()).angle <= e.angle {
+		at = at.Next()
+	}
+	at.Link(e.elem)
+}
+
+func (v *Vertex) detach(e *HalfEdge) {
+	v.degree--
+	if v.degree == 0 {
+		v.darts = nil
+		return
+	}
+	if v.darts == e.elem {
+		v.darts = e.elem.Next()
+	}
+	e.elem.Prev().Unlink(1)
+}
+
+func (v *Vertex) edgeTo(w *Vertex) *HalfEdge {
+	var found *HalfEdge
+	v.Walk(func(e *HalfEdge) bool {
+		if e.twin.origin == w {
+			found = e
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func dart(r *ring.Ring) *HalfEdge {
+	return r.Value.(*HalfEdge)
+}
+
+// Degree returns the number of edges incident to the Vertex.
+func (v *Vertex) Degree() int {
+	return v.degree
+}
+
+// Walk calls fn for each HalfEdge leaving the Vertex, counter-clockwise starting
+// from the negative x-axis (angle -π). Returning false from fn stops the walk.
+func (v *Vertex) Walk(fn func(*HalfEdge) bool) {
+	if v.darts == nil {
+		return
+	}
+	r := v.darts
+	for i := 0; i < v.degree; i++ {
+		if !fn(dart(r)) {
+			return
+		}
+		r = r.Next()
+	}
+}
+
+// Neighbours returns the adjacent Datapoints in counter-clockwise order.
+func (v *Vertex) Neighbours() kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, 0, v.degree)
+	v.Walk(func(e *HalfEdge) bool {
+		ds = append(ds, e.Target().Datapoint)
+		return true
+	})
+	return ds
+}
+
+// BreadthFirst calls fn for each Vertex reachable from start in breadth-first
+// order, visiting neighbours counter-clockwise. Returning false from fn stops the walk.
+func (g *Graph) BreadthFirst(start *kdtree.Datapoint, fn func(*Vertex) bool) {
+	first := g.index[start]
+	if first == nil {
+		return
+	}
+	visited := map[*Vertex]bool{first: true}
+	queue := []*Vertex{first}
+	var v *Vertex
+	for len(queue) != 0 {
+		v, queue = queue[0], queue[1:]
+		if !fn(v) {
+			return
+		}
+		v.Walk(func(e *HalfEdge) bool {
+			if w := e.Target(); !visited[w] {
+				visited[w] = true
+				queue = append(queue, w)
+			}
+			return true
+		})
+	}
+}
+
+// Origin returns the Vertex the HalfEdge leaves.
+func (e *HalfEdge) Origin() *Vertex {
+	return e.origin
+}
+
+// Target returns the Vertex the HalfEdge points to.
+func (e *HalfEdge) Target() *Vertex {
+	return e.twin.origin
+}
+
+// Twin returns the HalfEdge running in the opposite direction.
+func (e *HalfEdge) Twin() *HalfEdge {
+	return e.twin
+}
+
+// Angle returns the direction of the HalfEdge in radians, in [-π, π].
+func (e *HalfEdge) Angle() float64 {
+	return e.angle
+}
+
+// RotateCCW returns the next HalfEdge counter-clockwise around the origin.
+func (e *HalfEdge) RotateCCW() *HalfEdge {
+	return dart(e.elem.Next())
+}
+
+// RotateCW returns the next HalfEdge clockwise around the origin.
+func (e *HalfEdge) RotateCW() *HalfEdge {
+	return dart(e.elem.Prev())
+}
+
+// Next returns the HalfEdge following this one around the face to its left:
+// the first HalfEdge clockwise from the twin around the target.
+// Bounded faces are therefore traversed counter-clockwise, and the outer face clockwise.
+func (e *HalfEdge) Next() *HalfEdge {
+	return e.twin.RotateCW()
+}
+
+// Face returns the cycle of HalfEdges bounding the face to the left of e, starting with e.
+func (e *HalfEdge) Face() []*HalfEdge {
+	face := []*HalfEdge{e}
+	for f := e.Next(); f != e; f = f.Next() {
+		face = append(face, f)
+	}
+	return face
+}
+
+// Faces returns every face of the Graph as its cycle of HalfEdges.
+// Each HalfEdge belongs to exactly one face.
+func (g *Graph) Faces() [][]*HalfEdge {
+	visited := make(map[*HalfEdge]bool)
+	var faces [][]*HalfEdge
+	for _, v := range g.vertices {
+		v.Walk(func(e *HalfEdge) bool {
+			if !visited[e] {
+				face := e.Face()
+				for _, f := range face {
+					visited[f] = true
+				}
+				faces = append(faces, face)
+			}
+			return true
+		})
+	}
+	return faces
+}
+
+// FaceArea returns the signed area enclosed by a face: positive for the
+// counter-clockwise bounded faces, negative for the outer face.
+func FaceArea(face []*HalfEdge) float64 {
+	var area float64
+	for _, e := range face {
+		p, q := e.Origin(), e.Target()
+		area += p.At(0)*q.At(1) - q.At(0)*p.At(1)
+	}
+	return area / 2
+}
@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+var (
+	// unit square with one diagonal, plus a pendant vertex off one corner
+	square = kdtree.Datapoints{
+		kdtree.NewDatapoint("a", []float64{0, 0}),
+		kdtree.NewDatapoint("b", []float64{1, 0}),
+		kdtree.NewDatapoint("c", []float64{1, 1}),
+		kdtree.NewDatapoint("d", []float64{0, 1}),
+		kdtree.NewDatapoint("e", []float64{2, 2}),
+	}
+	squareEdges = [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {0, 2}, {2, 4}}
+)
+
+func names(ds kdtree.Datapoints) string {
+	var s string
+	for _, d := range ds {
+		s += d.Data().(string)
+	}
+	return s
+}
+
+func Test_Graph_Neighbours_Are_Angularly_Sorted(t *testing.T) {
+	g, err := FromEdges(square, squareEdges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		vertex int
+		want   string
+	}{
+		{0, "bcd"},
+		{2, "abed"},
+		{4, "c"},
+	}
+	for _, nt := range tests {
+		got := names(g.Vertex(square[nt.vertex]).Neighbours())
+		if got != nt.want {
+			t.Error(`want: `, nt.want, `
+			got: `, got)
+		}
+	}
+	e, _ := g.AddEdge(square[2], square[1])
+	if e.RotateCCW().Target().Data() != "e" || e.RotateCW().Target().Data() != "a" {
+		t.Error(`rotation around c should give e counter-clockwise and a clockwise of b`)
+	}
+}
+
+func Test_Graph_Faces(t *testing.T) {
+	g, _ := FromEdges(square, squareEdges)
+	faces := g.Faces()
+	// Euler: V - E + F = 2 for a connected planar graph
+	if len(faces) != 2-g.Len()+g.Edges() {
+		t.Fatal(`want: `, 2-g.Len()+g.Edges(), ` faces
+		got: `, len(faces))
+	}
+	var total, outer float64
+	for _, face := range faces {
+		area := FaceArea(face)
+		if area < 0 {
+			outer = area
+			if len(face) != 6 { // the pendant edge is walked in both directions
+				t.Error(`want the outer face to have 6 HalfEdges
+				got: `, len(face))
+			}
+		} else if area != 0.5 {
+			t.Error(`want each triangle to have area 0.5
+			got: `, area)
+		}
+		total += area
+	}
+	if outer != -1 || total != 0 {
+		t.Error(`want: outer area -1 and total 0
+		got: `, outer, total)
+	}
+
+	g.RemoveEdge(square[2], square[0])
+	if len(g.Faces()) != 2 || g.Edges() != 5 || g.Vertex(square[0]).Degree() != 2 {
+		t.Error(`removing the diagonal should merge the two triangles`)
+	}
+}
+
+func Test_Graph_FromKNN(t *testing.T) {
+	r := rand.New(rand.NewSource(56))
+	var ds kdtree.Datapoints
+	for i := 0; i < 200; i++ {
+		ds = append(ds, kdtree.NewDatapoint(i, []float64{r.Float64(), r.Float64()}))
+	}
+	g, err := FromKNN(ds, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range g.Vertices() {
+		if v.Degree() < 4 {
+			t.Error(`every vertex should be joined to at least its 4 nearest neighbours`)
+		}
+		previous := math.Inf(-1)
+		v.Walk(func(e *HalfEdge) bool {
+			if e.Angle() < previous || e.Twin().Twin() != e || e.Origin() != v {
+				t.Error(`axial list out of order or twins inconsistent at `, v.Datapoint)
+			}
+			previous = e.Angle()
+			return true
+		})
+	}
+
+	var reached int
+	g.BreadthFirst(ds[0], func(*Vertex) bool {
+		reached++
+		return true
+	})
+	if reached == 0 || reached > g.Len() {
+		t.Error(`breadth first walk reached `, reached, ` of `, g.Len())
+	}
+}
+
+func Test_Graph_FromKNN_Skips_Coincident_Neighbours(t *testing.T) {
+	ds := kdtree.Datapoints{
+		kdtree.NewDatapoint(0, []float64{0, 0}),
+		kdtree.NewDatapoint(1, []float64{0, 0}),
+		kdtree.NewDatapoint(2, []float64{0, 0}),
+		kdtree.NewDatapoint(3, []float64{1, 0}),
+		kdtree.NewDatapoint(4, []float64{0, 2}),
+		kdtree.NewDatapoint(5, []float64{5, 5}),
+	}
+	g, err := FromKNN(ds, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range ds[:3] {
+		if got := g.Vertex(d).Degree(); got < 2 {
+			t.Error(`want: a coincident Datapoint joined to 2 distinct neighbours
+		got: degree `, got)
+		}
+	}
+}
+
+func Test_Graph_FromDelaunay(t *testing.T) {
+	r := rand.New(rand.NewSource(68))
+	var ds kdtree.Datapoints
+	for i := 0; i < 200; i++ {
+		ds = append(ds, kdtree.NewDatapoint(i, []float64{r.Float64(), r.Float64()}))
+	}
+	g, err := FromDelaunay(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	faces := g.Faces()
+	if g.Len()-g.Edges()+len(faces) != 2 { // Euler's formula, counting the outer face
+		t.Error(`want: V - E + F = 2
+		got: `, g.Len(), g.Edges(), len(faces))
+	}
+	triangles := 0
+	for _, face := range faces {
+		if len(face) == 3 && FaceArea(face) > 0 {
+			triangles++
+		}
+	}
+	if triangles != len(faces)-1 {
+		t.Error(`want: every bounded face to be a triangle
+		got: `, triangles, ` of `, len(faces)-1)
+	}
+}
+
+func Test_Graph_Errors(t *testing.T) {
+	g := New()
+	if _, err := g.AddVertex(kdtree.NewDatapoint(nil, []float64{1, 2, 3})); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	p, q := kdtree.NewDatapoint(nil, []float64{1, 1}), kdtree.NewDatapoint(nil, []float64{1, 1})
+	if _, err := g.AddEdge(p, q); err != ErrUnknownVertex {
+		t.Error(`want: `, ErrUnknownVertex, `
+		got: `, err)
+	}
+	g.AddVertex(p)
+	g.AddVertex(q)
+	if _, err := g.AddEdge(p, q); err != ErrCoincident {
+		t.Error(`want: `, ErrCoincident, `
+		got: `, err)
+	}
+}
@@ -0,0 +1,359 @@
+package hnsw
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// Defaults used when non-positive options are given.
+const (
+	DefaultM              = 16
+	DefaultEfConstruction = 200
+	DefaultEfSearch       = 50
+)
+
+// Options configures the construction of an Index.
+type Options struct {
+	// M is the number of neighbours linked to each new node on every layer
+	// (twice as many are kept on the bottom layer). More links raise recall
+	// and memory alike; 12 to 48 suits most embeddings.
+	M int
+	// EfConstruction is the breadth of the search for the neighbours of each
+	// new node. Higher builds a better graph, more slowly.
+	EfConstruction int
+	// EfSearch is the breadth of the search for each query, at least k.
+	// Higher raises recall and the cost of queries; see SetEfSearch.
+	EfSearch int
+	// Distance ranks neighbours, metric.Euclidean when nil. Any monotone
+	// function of a distance will do, such as metric.Angular for cosine
+	// similarity.
+	Distance metric.Func
+	// Seed seeds the random choice of each node's top layer.
+	Seed int64
+}
+
+// Index is a Hierarchical Navigable Small World graph (Malkov and Yashunin,
+// 2016) for approximate nearest neighbour search in high dimensions. Every
+// Datapoint is a node on the bottom layer, linked to its near neighbours,
+// and on each layer above with exponentially falling probability, so that a
+// query descends greedily through ever shorter links. It implements
+// kdtree.SpatialIndex, so it can stand in for a kd-tree where the
+// dimensionality is too high for one to prune.
+//
+// Delete only marks a node as deleted: it still guides searches, but is no
+// longer returned, and a query searches on past deleted nodes until it has
+// found enough which are not. An Index is not safe for concurrent use.
+type Index struct {
+	opts      Options
+	dims      int
+	nodes     []*node
+	ids       map[*kdtree.Datapoint]int
+	entry     int // the node on the top layer where every search starts, -1 when empty
+	live      int
+	levelMult float64
+	rng       *rand.Rand
+	visited   []uint32 // the epoch at which each node was last visited
+	epoch     uint32
+}
+
+type node struct {
+	point   *kdtree.Datapoint
+	links   [][]int // the neighbours on each layer from the bottom up
+	deleted bool
+}
+
+var _ kdtree.SpatialIndex = (*Index)(nil)
+
+// New returns an empty Index.
+func New(opts Options) *Index {
+	if opts.M <= 0 {
+		opts.M = DefaultM
+	}
+	if opts.EfConstruction <= 0 {
+		opts.EfConstruction = DefaultEfConstruction
+	}
+	if opts.EfSearch <= 0 {
+		opts.EfSearch = DefaultEfSearch
+	}
+	if opts.Distance == nil {
+		opts.Distance = metric.Euclidean
+	}
+	branching := opts.M
+	if branching < 2 {
+		branching = 2
+	}
+	return &Index{
+		opts:      opts,
+		ids:       make(map[*kdtree.Datapoint]int),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(branching)),
+		rng:       rand.New(rand.NewSource(opts.Seed)),
+	}
+}
+
+// Build returns an Index holding every Datapoint in ds, which must share
+// the same dimensionality.
+func Build(ds kdtree.Datapoints, opts Options) (*Index, error) {
+	idx := New(opts)
+	for _, d := range ds {
+		if err := idx.Insert(d); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// Len returns the number of Datapoints held, not counting those deleted.
+func (idx *Index) Len() int {
+	return idx.live
+}
+
+// Dims returns the dimensionality of the Datapoints held,
+// or 0 if nothing has been inserted yet.
+func (idx *Index) Dims() int {
+	return idx.dims
+}
+
+// Deleted returns the number of nodes marked as deleted, which still take
+// up memory and search time. Once it is a sizeable fraction of the Index, a
+// fresh Build over Datapoints() reclaims them.
+func (idx *Index) Deleted() int {
+	return len(idx.nodes) - idx.live
+}
+
+// Datapoints returns the Datapoints held, in the order they were inserted.
+func (idx *Index) Datapoints() kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, 0, idx.live)
+	for _, n := range idx.nodes {
+		if !n.deleted {
+			ds = append(ds, n.point)
+		}
+	}
+	return ds
+}
+
+// SetEfSearch changes the breadth of the search for each query, trading
+// speed for recall without rebuilding.
+func (idx *Index) SetEfSearch(ef int) {
+	if ef <= 0 {
+		ef = DefaultEfSearch
+	}
+	idx.opts.EfSearch = ef
+}
+
+// Insert links a Datapoint into the graph. Inserting a Datapoint which is
+// already held has no effect; inserting one which was deleted restores it.
+func (idx *Index) Insert(d *kdtree.Datapoint) error {
+	if d == nil {
+		return kdtree.ErrDimensionMismatch
+	}
+	if len(idx.nodes) == 0 && idx.dims == 0 {
+		idx.dims = d.Dimensionality()
+	}
+	if d.Dimensionality() != idx.dims {
+		return kdtree.ErrDimensionMismatch
+	}
+	if id, held := idx.ids[d]; held {
+		if idx.nodes[id].deleted {
+			idx.nodes[id].deleted = false
+			idx.live++
+		}
+		return nil
+	}
+
+	level := int(-math.Log(1-idx.rng.Float64()) * idx.levelMult)
+	id := len(idx.nodes)
+	n := &node{point: d, links: make([][]int, level+1)}
+	idx.nodes = append(idx.nodes, n)
+	idx.ids[d] = id
+	idx.visited = append(idx.visited, 0)
+	idx.live++
+	if idx.entry < 0 {
+		idx.entry = id
+		return nil
+	}
+
+	top := len(idx.nodes[idx.entry].links) - 1
+	entries := []nearest.Candidate{{Item: idx.entry, Dist: idx.opts.Distance(d, idx.nodes[idx.entry].point)}}
+	for layer := top; layer > level; layer-- {
+		entries = idx.searchLayer(d, entries, 1, layer, false)
+	}
+	bottom := level
+	if top < bottom {
+		bottom = top
+	}
+	for layer := bottom; layer >= 0; layer-- {
+		entries = idx.searchLayer(d, entries, idx.opts.EfConstruction, layer, false)
+		n.links[layer] = idx.selectNeighbours(entries, idx.opts.M)
+		for _, neighbour := range n.links[layer] {
+			idx.link(neighbour, id, layer)
+		}
+	}
+	if level > top {
+		idx.entry = id
+	}
+	return nil
+}
+
+// link adds a link from one node to another on a layer, pruning the node's
+// links back to the most diverse when it has too many.
+func (idx *Index) link(from, to, layer int) {
+	n := idx.nodes[from]
+	n.links[layer] = append(n.links[layer], to)
+	limit := idx.opts.M
+	if layer == 0 {
+		limit *= 2
+	}
+	if len(n.links[layer]) <= limit {
+		return
+	}
+	set := nearest.New(len(n.links[layer]))
+	for _, other := range n.links[layer] {
+		set.Push(other, idx.opts.Distance(n.point, idx.nodes[other].point))
+	}
+	n.links[layer] = idx.selectNeighbours(set.Sorted(), limit)
+}
+
+// selectNeighbours chooses up to m of the candidates, nearest first, by the
+// heuristic of Malkov and Yashunin: a candidate is kept only if it is nearer
+// the new node than to any already kept, which links across clusters rather
+// than deep within the nearest one.
+func (idx *Index) selectNeighbours(candidates []nearest.Candidate, m int) []int {
+	kept := make([]int, 0, m)
+	for _, c := range candidates {
+		if len(kept) == m {
+			break
+		}
+		p := idx.nodes[c.Item.(int)].point
+		diverse := true
+		for _, k := range kept {
+			if idx.opts.Distance(p, idx.nodes[k].point) < c.Dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			kept = append(kept, c.Item.(int))
+		}
+	}
+	return kept
+}
+
+// searchLayer returns up to ef of the nodes nearest the target on a layer,
+// nearest first, found by a best-first search from the entries. When live is
+// true deleted nodes are still searched through but left out of the result,
+// so the search goes on until it has found ef nodes which are not deleted.
+func (idx *Index) searchLayer(target *kdtree.Datapoint, entries []nearest.Candidate, ef, layer int, live bool) []nearest.Candidate {
+	idx.epoch++
+	if idx.epoch == 0 { // wrapped around, so forget every visit
+		for i := range idx.visited {
+			idx.visited[i] = 0
+		}
+		idx.epoch = 1
+	}
+	found := nearest.New(ef)
+	frontier := &minHeap{}
+	for _, e := range entries {
+		idx.visited[e.Item.(int)] = idx.epoch
+		if !live || !idx.nodes[e.Item.(int)].deleted {
+			found.Push(e.Item, e.Dist)
+		}
+		heap.Push(frontier, e)
+	}
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(nearest.Candidate)
+		if c.Dist > found.Worst() {
+			break
+		}
+		for _, neighbour := range idx.nodes[c.Item.(int)].links[layer] {
+			if idx.visited[neighbour] == idx.epoch {
+				continue
+			}
+			idx.visited[neighbour] = idx.epoch
+			dist := idx.opts.Distance(target, idx.nodes[neighbour].point)
+			if dist < found.Worst() {
+				if !live || !idx.nodes[neighbour].deleted {
+					found.Push(neighbour, dist)
+				}
+				heap.Push(frontier, nearest.Candidate{Item: neighbour, Dist: dist})
+			}
+		}
+	}
+	return found.Sorted()
+}
+
+// Delete marks the given Datapoint as deleted, reporting whether it was held.
+func (idx *Index) Delete(d *kdtree.Datapoint) bool {
+	id, held := idx.ids[d]
+	if !held || idx.nodes[id].deleted {
+		return false
+	}
+	idx.nodes[id].deleted = true
+	idx.live--
+	return true
+}
+
+// NN returns the approximate nearest neighbour of the target, or nil if the
+// index is empty.
+func (idx *Index) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := idx.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the approximate k nearest neighbours of the target, nearest
+// first.
+func (idx *Index) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if idx.live == 0 || k <= 0 || target == nil || target.Dimensionality() != idx.dims {
+		return nil
+	}
+	entries := []nearest.Candidate{{Item: idx.entry, Dist: idx.opts.Distance(target, idx.nodes[idx.entry].point)}}
+	for layer := len(idx.nodes[idx.entry].links) - 1; layer > 0; layer-- {
+		entries = idx.searchLayer(target, entries, 1, layer, false)
+	}
+	ef := idx.opts.EfSearch
+	if k > ef {
+		ef = k
+	}
+	var out kdtree.Datapoints
+	for _, c := range idx.searchLayer(target, entries, ef, 0, true) {
+		out = append(out, idx.nodes[c.Item.(int)].point)
+		if len(out) == k {
+			break
+		}
+	}
+	return out
+}
+
+// Range returns every Datapoint held within the bounds. The graph has no
+// notion of a box, so this scans every node.
+func (idx *Index) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	var out kdtree.Datapoints
+	for _, n := range idx.nodes {
+		if !n.deleted && kdtree.InBounds(n.point, bounds) {
+			out = append(out, n.point)
+		}
+	}
+	return out
+}
+
+type minHeap []nearest.Candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].Dist < h[j].Dist }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(nearest.Candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,247 @@
+package hnsw
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/testutil"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/lsh"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// clustered returns n Datapoints of the given dimensionality gathered
+// around a few random centres, as the embeddings of similar items are.
+func clustered(r *rand.Rand, n, dims int) kdtree.Datapoints {
+	return testutil.Clustered(r, n, testutil.Centres(r, 20, dims, testutil.Normal), 0.3)
+}
+
+func Test_HNSW_Recall(t *testing.T) {
+	r := rand.New(rand.NewSource(75))
+	ds := clustered(r, 3100, 128)
+	held, queries := ds[:3000], ds[3000:]
+	recallTests := []struct {
+		opts   Options
+		recall float64
+	}{
+		{Options{}, 0.95},
+		{Options{M: 8, EfConstruction: 100, EfSearch: 20}, 0.8},
+		{Options{Distance: metric.Angular}, 0.95},
+	}
+	for _, rt := range recallTests {
+		idx, err := Build(held, rt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exact := testutil.Scan(held, idx.opts.Distance)
+		got := lsh.Recall(queries, 10, idx.KNN, exact)
+		if got < rt.recall {
+			t.Error(rt.opts.M, rt.opts.EfSearch, ` want: a recall of at least `, rt.recall, `
+			got: `, got)
+		}
+		idx.SetEfSearch(400)
+		if wider := lsh.Recall(queries, 10, idx.KNN, exact); wider < got || wider < 0.99 {
+			t.Error(`a wider search should find more, want: at least 0.99
+			got: `, wider)
+		}
+	}
+}
+
+func Test_HNSW_SpatialIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(75))
+	ds := make(kdtree.Datapoints, 1000)
+	for i := range ds {
+		ds[i] = kdtree.NewDatapoint(i, []float64{r.Float64(), r.Float64(), r.Float64()})
+	}
+	linear, _ := kdtree.NewLinear(ds)
+	tree, _ := kdtree.NewTree(ds, kdtree.Median)
+	idx, _ := Build(ds, Options{})
+	bounds := []kdtree.Range{kdtree.NewRange(0.2, 0.5), kdtree.NewRange(0.1, 0.9), kdtree.NewRange(0.4, 0.6)}
+	for _, index := range []kdtree.SpatialIndex{tree, idx} {
+		if index.Len() != len(ds) || index.Dims() != 3 {
+			t.Error(`want: 1000 3-D Datapoints
+			got: `, index.Len(), index.Dims())
+		}
+		if got := lsh.Recall(ds[:100], 5, index.KNN, linear.KNN); got < 0.99 {
+			t.Error(`want: a recall of at least 0.99
+			got: `, got)
+		}
+		if got, want := index.Range(bounds), linear.Range(bounds); !testutil.SameMembers(got, want) {
+			t.Error(`want: `, want.PointsSetString(), `
+			got: `, got.PointsSetString())
+		}
+		for _, d := range ds[:100] {
+			if index.NN(d) != d {
+				t.Error(`a held Datapoint should be its own nearest neighbour: `, d)
+			}
+		}
+	}
+}
+
+func Test_HNSW_Delete(t *testing.T) {
+	r := rand.New(rand.NewSource(75))
+	ds := clustered(r, 1000, 32)
+	idx, _ := Build(ds, Options{})
+	deleted := make(map[*kdtree.Datapoint]bool)
+	for _, d := range ds[:300] {
+		if !idx.Delete(d) {
+			t.Fatal(`Delete should remove `, d)
+		}
+		deleted[d] = true
+	}
+	if idx.Len() != 700 || idx.Deleted() != 300 || idx.Delete(ds[0]) || len(idx.Datapoints()) != 700 {
+		t.Fatal(`want: 700 held and 300 deleted
+		got: `, idx.Len(), idx.Deleted())
+	}
+	live, _ := kdtree.NewLinear(ds[300:])
+	for _, q := range ds[:300] {
+		got := idx.KNN(q, 10)
+		if len(got) != 10 {
+			t.Fatal(`want: 10 neighbours
+			got: `, len(got))
+		}
+		for _, d := range got {
+			if deleted[d] {
+				t.Fatal(`a deleted Datapoint should not be returned: `, d)
+			}
+		}
+	}
+	if got := lsh.Recall(ds[:300], 10, idx.KNN, live.KNN); got < 0.95 {
+		t.Error(`want: a recall of at least 0.95 around deleted nodes
+		got: `, got)
+	}
+
+	idx.Insert(ds[0])
+	if idx.Len() != 701 || idx.NN(ds[0]) != ds[0] {
+		t.Error(`inserting a deleted Datapoint should restore it`)
+	}
+	for _, d := range ds {
+		idx.Delete(d)
+	}
+	if idx.Len() != 0 || idx.NN(ds[0]) != nil {
+		t.Error(`an Index with everything deleted should find nothing`)
+	}
+}
+
+func Test_HNSW_Half_Deleted(t *testing.T) {
+	r := rand.New(rand.NewSource(75))
+	ds := clustered(r, 5100, 32)
+	held, queries := ds[:5000], ds[5000:]
+	idx, _ := Build(held, Options{})
+	var live kdtree.Datapoints
+	for i, d := range held {
+		if i%2 == 0 {
+			idx.Delete(d)
+		} else {
+			live = append(live, d)
+		}
+	}
+	exact, _ := kdtree.NewLinear(live)
+	if got := lsh.Recall(queries, 10, idx.KNN, exact.KNN); got < 0.95 {
+		t.Error(`want: a recall of at least 0.95 with half the nodes deleted
+		got: `, got)
+	}
+	visited := 0
+	for _, q := range queries {
+		idx.KNN(q, 10)
+		for _, epoch := range idx.visited {
+			if epoch == idx.epoch {
+				visited++
+			}
+		}
+	}
+	if perQuery := visited / len(queries); perQuery > len(held)/10 {
+		t.Error(`want: a search of a small part of the graph
+		got: `, perQuery, ` of `, len(held), ` nodes visited a query`)
+	}
+}
+
+func Test_HNSW_JSON_Round_Trip(t *testing.T) {
+	r := rand.New(rand.NewSource(75))
+	ds := clustered(r, 600, 16)
+	idx, _ := Build(ds[:500], Options{M: 10, Seed: 3})
+	idx.Delete(ds[7])
+	b, err := json.Marshal(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := &Index{}
+	if err := json.Unmarshal(b, restored); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != idx.Len() || restored.Deleted() != 1 || restored.Dims() != 16 || restored.opts.M != 10 {
+		t.Fatal(`want: `, idx.Len(), ` held
+		got: `, restored.Len())
+	}
+	for _, q := range ds[500:] {
+		want, got := idx.KNN(q, 5), restored.KNN(q, 5)
+		for i := range want {
+			// JSON gives back the data of each Datapoint as a float64
+			if !want[i].EqualTo(got[i]) || float64(want[i].Data().(int)) != got[i].Data() {
+				t.Fatal(`want: `, want.PointsSetString(), `
+				got: `, got.PointsSetString())
+			}
+		}
+	}
+	for _, d := range ds[500:] {
+		if err := restored.Insert(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if restored.Len() != 599 || restored.NN(ds[550]) != ds[550] {
+		t.Error(`a restored Index should take further Datapoints`)
+	}
+
+	corruptTests := []struct {
+		input string
+		want  error
+	}{
+		{`{"Entry": 0, "Nodes": [{"Set": [1, 2], "Links": [[1]]}]}`, ErrCorrupt},
+		{`{"Entry": 0, "Nodes": [{"Set": [1, 2], "Links": []}]}`, ErrCorrupt},
+		{`{"Entry": 1, "Nodes": [{"Set": [1, 2], "Links": [[1]]}, {"Set": [1, 2], "Links": [[0], [0]]}]}`, ErrCorrupt},
+		{`{"Entry": 0, "Nodes": [{"Set": [1, 2], "Links": [[1]]}, {"Set": [1, 2], "Links": [[0], []]}]}`, ErrCorrupt},
+		{`{"Entry": 0, "Nodes": [{"Set": [1, 2], "Links": [[1]]}, {"Set": [1], "Links": [[0]]}]}`, kdtree.ErrDimensionMismatch},
+	}
+	for _, ct := range corruptTests {
+		if err := json.Unmarshal([]byte(ct.input), &Index{}); err != ct.want {
+			t.Error(ct.input, ` want: `, ct.want, `
+			got: `, err)
+		}
+	}
+}
+
+func Test_HNSW_Errors(t *testing.T) {
+	idx := New(Options{})
+	if idx.NN(kdtree.NewDatapoint(nil, []float64{1, 2})) != nil || idx.Delete(kdtree.NewDatapoint(nil, []float64{1, 2})) {
+		t.Error(`an empty Index should hold nothing`)
+	}
+	idx.Insert(kdtree.NewDatapoint(nil, []float64{1, 2}))
+	if idx.Insert(kdtree.NewDatapoint(nil, []float64{1})) != kdtree.ErrDimensionMismatch || idx.Insert(nil) != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch)
+	}
+	if idx.KNN(kdtree.NewDatapoint(nil, []float64{1}), 3) != nil {
+		t.Error(`a query of the wrong dimensionality should find nothing`)
+	}
+}
+
+// With 20,000 clustered 128-d Datapoints, KNN answers in about 0.4ms against
+// 3.7ms for a linear scan. Building takes about 10 seconds with the default
+// options, most of it in the searches of EfConstruction.
+func Benchmark_HNSW_KNN(b *testing.B) {
+	r := rand.New(rand.NewSource(75))
+	ds := clustered(r, 20100, 128)
+	idx, _ := Build(ds[:20000], Options{})
+	linear, _ := kdtree.NewLinear(ds[:20000])
+	queries := ds[20000:]
+	b.Run("HNSW", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			idx.KNN(queries[i%len(queries)], 10)
+		}
+	})
+	b.Run("Linear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			linear.KNN(queries[i%len(queries)], 10)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package hnsw
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// ErrCorrupt is returned by UnmarshalJSON when the links of the graph do not
+// fit its nodes, or its entry is not on the top layer.
+var ErrCorrupt = errors.New("hnsw: graph links do not fit its nodes")
+
+type jsonIndex struct {
+	M              int
+	EfConstruction int
+	EfSearch       int
+	Seed           int64
+	Entry          int
+	Nodes          []jsonNode
+}
+
+type jsonNode struct {
+	Data    interface{}
+	Set     []float64
+	Links   [][]int
+	Deleted bool `json:",omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler interface, writing the whole graph
+// so that it can be restored without being rebuilt. The Distance is not
+// written.
+func (idx *Index) MarshalJSON() ([]byte, error) {
+	out := jsonIndex{
+		M:              idx.opts.M,
+		EfConstruction: idx.opts.EfConstruction,
+		EfSearch:       idx.opts.EfSearch,
+		Seed:           idx.opts.Seed,
+		Entry:          idx.entry,
+		Nodes:          make([]jsonNode, len(idx.nodes)),
+	}
+	for i, n := range idx.nodes {
+		out.Nodes[i] = jsonNode{n.point.Data(), n.point.Set(), n.links, n.deleted}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface, restoring an Index
+// written by MarshalJSON. The Distance of the Index unmarshalled into is
+// kept, metric.Euclidean for a zero Index, and must be the one the graph was
+// built with. The data of each Datapoint is restored as encoding/json
+// decodes into an interface{}, so numbers become float64s.
+func (idx *Index) UnmarshalJSON(b []byte) error {
+	var in jsonIndex
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	if in.Entry >= len(in.Nodes) || in.Entry < 0 && len(in.Nodes) > 0 {
+		return ErrCorrupt
+	}
+	for _, n := range in.Nodes {
+		if len(n.Links) == 0 || len(n.Links) > len(in.Nodes[in.Entry].Links) {
+			return ErrCorrupt // every search starts from the Entry, on the top layer
+		}
+		if len(n.Set) != len(in.Nodes[0].Set) {
+			return kdtree.ErrDimensionMismatch
+		}
+		for layer, links := range n.Links {
+			for _, link := range links {
+				if link < 0 || link >= len(in.Nodes) || len(in.Nodes[link].Links) <= layer {
+					return ErrCorrupt
+				}
+			}
+		}
+	}
+
+	dist := idx.opts.Distance
+	if dist == nil {
+		dist = metric.Euclidean
+	}
+	*idx = *New(Options{M: in.M, EfConstruction: in.EfConstruction, EfSearch: in.EfSearch, Distance: dist, Seed: in.Seed})
+	// reseeded so the layers of later nodes do not repeat those of the first
+	idx.rng = rand.New(rand.NewSource(in.Seed + int64(len(in.Nodes))))
+	idx.entry = in.Entry
+	idx.visited = make([]uint32, len(in.Nodes))
+	for i, n := range in.Nodes {
+		d := kdtree.NewDatapoint(n.Data, n.Set)
+		idx.dims = d.Dimensionality()
+		idx.nodes = append(idx.nodes, &node{point: d, links: n.Links, deleted: n.Deleted})
+		idx.ids[d] = i
+		if !n.Deleted {
+			idx.live++
+		}
+	}
+	return nil
+}
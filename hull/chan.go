@@ -0,0 +1,161 @@
+package hull
+
+import (
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Chan returns the convex hull of two-dimensional Datapoints by Chan's
+// algorithm, which runs in O(n log h) for a hull of h vertices and so beats
+// MonotoneChain when few of many Datapoints lie on the hull. It guesses a
+// bound m on h, splits the Datapoints into groups of m, finds the hull of each
+// group with MonotoneChain, and then gift-wraps the whole set in at most m
+// steps, finding the tangent to each group's hull by binary search. Each
+// failed guess squares m.
+func Chan(ds kdtree.Datapoints) (kdtree.Datapoints, error) {
+	points, err := planar(ds)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) <= 2 {
+		return datapoints(points), nil
+	}
+	for m := 4; ; m *= m {
+		if m >= len(points) {
+			return monotoneChain(points), nil
+		}
+		if hull := wrap(points, m); hull != nil {
+			return hull, nil
+		}
+	}
+}
+
+// location is where a point lies among the group hulls.
+type location struct {
+	group, index int
+}
+
+// wrap gift-wraps the points in groups of m, returning nil if the hull turns
+// out to have more than m vertices.
+func wrap(points []point, m int) kdtree.Datapoints {
+	var groups [][]point
+	where := make(map[*kdtree.Datapoint]location)
+	for lo := 0; lo < len(points); lo += m {
+		hi := lo + m
+		if hi > len(points) {
+			hi = len(points)
+		}
+		// each group is already sorted, and its hull keeps that start
+		hull := monotoneChain(points[lo:hi])
+		group := make([]point, len(hull))
+		for i, d := range hull {
+			v, _ := geom.Vec2From(d)
+			group[i] = point{d, v}
+			where[d] = location{len(groups), i}
+		}
+		groups = append(groups, group)
+	}
+
+	start := points[0] // the lowest of the leftmost points is always on the hull
+	hull := kdtree.Datapoints{start.d}
+	p := start
+	for len(hull) <= m {
+		at := where[p.d]
+		var next point
+		found := false
+		for g, group := range groups {
+			var candidate point
+			if g == at.group {
+				if len(group) == 1 {
+					continue
+				}
+				candidate = group[(at.index+1)%len(group)]
+			} else {
+				candidate = group[tangent(group, p.v)]
+			}
+			if !found || clockwiseOf(p.v, next.v, candidate.v) {
+				next, found = candidate, true
+			}
+		}
+		if !found || next.d == start.d {
+			return hull
+		}
+		hull = append(hull, next.d)
+		p = next
+	}
+	return nil
+}
+
+// clockwiseOf reports whether, seen from p, candidate lies clockwise of best,
+// or in the same direction but further away.
+func clockwiseOf(p, best, candidate geom.Vec2) bool {
+	switch o := geom.Orient2D(p, best, candidate); {
+	case o < 0:
+		return true
+	case o == 0:
+		return p.DistSq(candidate) > p.DistSq(best)
+	}
+	return false
+}
+
+// tangent returns the index of the vertex of a strictly convex, anticlockwise
+// polygon which lies furthest clockwise as seen from a point p outside it, so
+// that the whole polygon lies to the left of the line from p through it.
+//
+// Seen from p, the vertices' directions turn anticlockwise from the tangent
+// vertex t round to the opposite tangent, then clockwise back to t.
+// Taking vertex 0 as a reference, the vertices can be split into a run for
+// which t lies further on and a run for which it does not, so t is found by a
+// binary search in O(log m).
+func tangent(poly []point, p geom.Vec2) int {
+	n := len(poly)
+	if n <= 2 {
+		best := 0
+		for i := 1; i < n; i++ {
+			if clockwiseOf(p, poly[best].v, poly[i].v) {
+				best = i
+			}
+		}
+		return best
+	}
+	turn := func(i, j int) float64 {
+		return geom.Orient2D(p, poly[i%n].v, poly[j%n].v)
+	}
+	rising := func(i int) bool { // the edge from vertex i turns anticlockwise
+		return turn(i, i+1) > 0
+	}
+
+	// find the first index in [1, n) satisfying past, if any
+	var past func(c int) bool
+	if rising(0) {
+		// vertex 0 lies on the rising run from t, so t is the first vertex
+		// beyond it which rises again from clockwise of vertex 0
+		past = func(c int) bool { return rising(c) && turn(0, c) < 0 }
+	} else {
+		// vertex 0 lies on the falling run towards t, so t is the first
+		// vertex beyond it which rises, or which has turned past vertex 0;
+		// a vertex in line with vertex 0 has done so unless it is vertex 1,
+		// since the two must share an edge
+		past = func(c int) bool {
+			o := turn(0, c)
+			return rising(c) || o > 0 || (o == 0 && c != 1)
+		}
+	}
+	lo, hi := 1, n
+	for lo < hi {
+		c := (lo + hi) / 2
+		if past(c) {
+			hi = c
+		} else {
+			lo = c + 1
+		}
+	}
+	t := lo % n
+
+	// p may be in line with the edge arriving at t, in which case the
+	// further vertex of that edge is wanted
+	if prev := (t + n - 1) % n; turn(prev, t) == 0 && p.DistSq(poly[prev].v) > p.DistSq(poly[t].v) {
+		t = prev
+	}
+	return t
+}
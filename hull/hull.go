@@ -0,0 +1,79 @@
+package hull
+
+import (
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Every 2-D hull is returned as its vertices in anticlockwise order,
+// starting from the lowest of the leftmost Datapoints. Datapoints lying on
+// an edge of the hull, and repeats of a vertex, are not vertices themselves.
+
+// MonotoneChain returns the convex hull of two-dimensional Datapoints by
+// Andrew's monotone chain algorithm, which sorts the Datapoints and then
+// builds the lower and upper hulls in a single pass each, in O(n log n).
+func MonotoneChain(ds kdtree.Datapoints) (kdtree.Datapoints, error) {
+	points, err := planar(ds)
+	if err != nil {
+		return nil, err
+	}
+	return monotoneChain(points), nil
+}
+
+// point pairs a Datapoint with its position.
+type point struct {
+	d *kdtree.Datapoint
+	v geom.Vec2
+}
+
+// planar checks that every Datapoint is two-dimensional, returning their
+// positions sorted lexicographically with repeated positions removed.
+func planar(ds kdtree.Datapoints) ([]point, error) {
+	points := make([]point, 0, len(ds))
+	for _, d := range ds {
+		v, err := geom.Vec2From(d)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point{d, v})
+	}
+	sortPoints(points)
+	distinct := points[:0]
+	for i, p := range points {
+		if i == 0 || p.v != points[i-1].v {
+			distinct = append(distinct, p)
+		}
+	}
+	return distinct, nil
+}
+
+// monotoneChain expects distinct points in lexicographic order.
+func monotoneChain(points []point) kdtree.Datapoints {
+	if len(points) <= 2 {
+		return datapoints(points)
+	}
+	hull := make([]point, 0, 2*len(points))
+	for _, p := range points { // lower hull, left to right
+		for len(hull) >= 2 && geom.Orient2D(hull[len(hull)-2].v, hull[len(hull)-1].v, p.v) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- { // upper hull, right to left
+		p := points[i]
+		for len(hull) >= lower && geom.Orient2D(hull[len(hull)-2].v, hull[len(hull)-1].v, p.v) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return datapoints(hull[:len(hull)-1]) // the last point repeats the first
+}
+
+func datapoints(points []point) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, len(points))
+	for i := range points {
+		ds[i] = points[i].d
+	}
+	return ds
+}
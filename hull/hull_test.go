@@ -0,0 +1,215 @@
+package hull
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func gridDatapoints(r *rand.Rand, n, dims, side int) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, n)
+	for i := range ds {
+		f := make([]float64, dims)
+		for axis := range f {
+			f[axis] = float64(r.Intn(side)) // coarse values, so collinear and coplanar points are common
+		}
+		ds[i] = kdtree.NewDatapoint(i, f)
+	}
+	return ds
+}
+
+// isConvexHull2D checks that hull is a strictly convex anticlockwise polygon
+// starting from the lowest leftmost Datapoint, with every Datapoint in ds
+// inside or on it.
+func isConvexHull2D(ds, hull kdtree.Datapoints) bool {
+	vs := make([]geom.Vec2, len(hull))
+	for i, d := range hull {
+		vs[i], _ = geom.Vec2From(d)
+	}
+	for _, d := range ds {
+		v, _ := geom.Vec2From(d)
+		if v[0] < vs[0][0] || (v[0] == vs[0][0] && v[1] < vs[0][1]) {
+			return false
+		}
+		for i := range vs {
+			if len(vs) > 2 && geom.Orient2D(vs[i], vs[(i+1)%len(vs)], v) < 0 {
+				return false
+			}
+		}
+	}
+	for i := range vs {
+		if len(vs) > 2 && geom.Orient2D(vs[i], vs[(i+1)%len(vs)], vs[(i+2)%len(vs)]) <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_Hull_2D_Algorithms_Agree(t *testing.T) {
+	r := rand.New(rand.NewSource(67))
+	for trial := 0; trial < 300; trial++ {
+		ds := gridDatapoints(r, r.Intn(2000)+1, 2, 5+r.Intn(60))
+		chain, err := MonotoneChain(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isConvexHull2D(ds, chain) {
+			t.Fatal(`MonotoneChain is not the hull: `, chain.PointsSetString())
+		}
+		wrapped, _ := Chan(ds)
+		if !wrapped.EqualTo(chain) {
+			t.Fatal(`want: `, chain.PointsSetString(), `
+			got: `, wrapped.PointsSetString())
+		}
+	}
+}
+
+func Test_Hull_2D_Degenerate(t *testing.T) {
+	square := kdtree.Datapoints{
+		kdtree.NewDatapoint(`a`, []float64{0, 0}),
+		kdtree.NewDatapoint(`b`, []float64{1, 0}), // on an edge
+		kdtree.NewDatapoint(`c`, []float64{2, 0}),
+		kdtree.NewDatapoint(`d`, []float64{2, 2}),
+		kdtree.NewDatapoint(`e`, []float64{0, 2}),
+		kdtree.NewDatapoint(`f`, []float64{0, 0}), // repeated
+		kdtree.NewDatapoint(`g`, []float64{1, 1}), // inside
+	}
+	for _, fn := range []func(kdtree.Datapoints) (kdtree.Datapoints, error){MonotoneChain, Chan} {
+		got := ``
+		hull, _ := fn(square)
+		for _, d := range hull {
+			got += d.Data().(string)
+		}
+		if got != `acde` && got != `fcde` {
+			t.Error(`want: acde
+			got: `, got)
+		}
+		collinear := gridDatapoints(rand.New(rand.NewSource(1)), 50, 1, 100)
+		for i, d := range collinear {
+			collinear[i] = kdtree.NewDatapoint(nil, []float64{d.At(0), 2 * d.At(0)})
+		}
+		if hull, _ := fn(collinear); len(hull) != 2 {
+			t.Error(`collinear Datapoints should give a hull of their two ends, got: `, hull.PointsSetString())
+		}
+		if hull, _ := fn(nil); len(hull) != 0 {
+			t.Error(`no Datapoints should give an empty hull`)
+		}
+		if _, err := fn(kdtree.Datapoints{kdtree.NewDatapoint(nil, []float64{1, 2, 3})}); err != kdtree.ErrDimensionMismatch {
+			t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+			got: `, err)
+		}
+	}
+}
+
+// Test_Hull_Tangent checks the binary search for tangents against a linear
+// scan, including points in line with an edge of the polygon.
+func Test_Hull_Tangent(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for trial := 0; trial < 20000; trial++ {
+		hull, _ := MonotoneChain(gridDatapoints(r, 30, 2, 8))
+		poly := make([]point, len(hull))
+		for i, d := range hull {
+			v, _ := geom.Vec2From(d)
+			poly[i] = point{d, v}
+		}
+		p := geom.Vec2{float64(r.Intn(24) - 8), float64(r.Intn(24) - 8)}
+		inside := true
+		for i := range poly {
+			if geom.Orient2D(poly[i].v, poly[(i+1)%len(poly)].v, p) < 0 {
+				inside = false
+			}
+		}
+		if inside {
+			continue
+		}
+		want := 0
+		for i := range poly {
+			if clockwiseOf(p, poly[want].v, poly[i].v) {
+				want = i
+			}
+		}
+		if got := tangent(poly, p); got != want {
+			t.Fatal(`from `, p, ` to `, hull.PointsSetString(), ` want: `, want, `
+			got: `, got)
+		}
+	}
+}
+
+func Test_Hull_Quickhull(t *testing.T) {
+	r := rand.New(rand.NewSource(67))
+	for trial := 0; trial < 100; trial++ {
+		ds := gridDatapoints(r, 4+r.Intn(1000), 3, 4+r.Intn(30))
+		hull, err := Quickhull(ds)
+		if err == ErrDegenerate {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs := make([]geom.Vec3, len(hull.Vertices()))
+		for i, d := range hull.Vertices() {
+			vs[i], _ = geom.Vec3From(d)
+		}
+		// every Datapoint lies inside or on every face
+		for _, f := range hull.Faces() {
+			for _, d := range ds {
+				v, _ := geom.Vec3From(d)
+				if geom.Orient3D(vs[f[0]], vs[f[1]], vs[f[2]], v) < 0 {
+					t.Fatal(`Datapoint `, v, ` lies outside face `, f)
+				}
+			}
+		}
+		// the faces close up, each edge being shared with one other face
+		edges := make(map[[2]int]int)
+		for _, f := range hull.Faces() {
+			for k := 0; k < 3; k++ {
+				edges[[2]int{f[k], f[(k+1)%3]}]++
+			}
+		}
+		for e, n := range edges {
+			if n != 1 || edges[[2]int{e[1], e[0]}] != 1 {
+				t.Fatal(`edge `, e, ` is not shared by exactly two faces`)
+			}
+		}
+		if v, f := len(vs), len(hull.Faces()); v-len(edges)/2+f != 2 {
+			t.Fatal(`Euler's formula fails: `, v, len(edges)/2, f)
+		}
+	}
+}
+
+func Test_Hull_Quickhull_Cube(t *testing.T) {
+	var ds kdtree.Datapoints
+	for x := 0.0; x <= 2; x++ {
+		for y := 0.0; y <= 2; y++ {
+			for z := 0.0; z <= 2; z++ {
+				ds = append(ds, kdtree.NewDatapoint(nil, []float64{x, y, z}))
+			}
+		}
+	}
+	hull, err := Quickhull(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hull.Vertices()) != 8 || len(hull.Faces()) != 12 {
+		t.Error(`want: 8 12
+		got: `, len(hull.Vertices()), len(hull.Faces()))
+	}
+	var area float64
+	for _, f := range hull.Faces() {
+		a, _ := geom.Vec3From(hull.Vertices()[f[0]])
+		b, _ := geom.Vec3From(hull.Vertices()[f[1]])
+		c, _ := geom.Vec3From(hull.Vertices()[f[2]])
+		area += geom.Triangle{A: a, B: b, C: c}.Area()
+	}
+	if math.Abs(area-24) > 1e-12 {
+		t.Error(`want: 24
+		got: `, area)
+	}
+	if _, err := Quickhull(ds[:9]); err != ErrDegenerate { // the face x = 0
+		t.Error(`want: `, ErrDegenerate, `
+		got: `, err)
+	}
+}
@@ -0,0 +1,10 @@
+package hull
+
+import "sort"
+
+func sortPoints(points []point) {
+	sort.Slice(points, func(i, j int) bool {
+		a, b := points[i].v, points[j].v
+		return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+	})
+}
@@ -0,0 +1,265 @@
+package hull
+
+import (
+	"errors"
+	"math"
+
+	"github.com/benjamin-rood/goeometric/geom"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// ErrDegenerate is returned by Quickhull when the Datapoints are coplanar,
+// so that they have no three-dimensional hull. The hull of such Datapoints
+// can be found by projecting them onto their plane and using MonotoneChain.
+var ErrDegenerate = errors.New("hull: datapoints are coplanar")
+
+// Hull3D is a convex polyhedron: its vertices, and its triangular faces as
+// indices into the vertices, each ordered anticlockwise when seen from
+// outside. Coplanar faces are left as separate triangles.
+type Hull3D struct {
+	vertices kdtree.Datapoints
+	faces    [][3]int
+}
+
+// Vertices returns the vertices of the hull.
+func (h *Hull3D) Vertices() kdtree.Datapoints {
+	return h.vertices
+}
+
+// Faces returns the triangular faces of the hull as indices into Vertices.
+func (h *Hull3D) Faces() [][3]int {
+	return h.faces
+}
+
+type face struct {
+	v       [3]int // indices into the points, anticlockwise from outside
+	outside []int  // points which lie strictly outside this face
+	dead    bool
+}
+
+type edge [2]int
+
+// hull3 holds the working state of Quickhull.
+type hull3 struct {
+	points []geom.Vec3
+	faces  []*face
+	edges  map[edge]int // each directed edge to the face it bounds
+	cursor int          // no face before this has points outside it
+}
+
+// Quickhull returns the convex hull of three-dimensional Datapoints.
+// Starting from a tetrahedron of extreme Datapoints, it repeatedly takes the
+// Datapoint furthest outside some face, removes every face which that
+// Datapoint can see, and joins it to the horizon left behind, discarding the
+// Datapoints which are now inside. It runs in O(n log n) expected time.
+// Datapoints lying on a face or an edge of the hull are not made vertices.
+func Quickhull(ds kdtree.Datapoints) (*Hull3D, error) {
+	h := &hull3{points: make([]geom.Vec3, len(ds)), edges: make(map[edge]int)}
+	for i, d := range ds {
+		v, err := geom.Vec3From(d)
+		if err != nil {
+			return nil, err
+		}
+		h.points[i] = v
+	}
+	simplex, ok := h.simplex()
+	if !ok {
+		return nil, ErrDegenerate
+	}
+	a, b, c, d := simplex[0], simplex[1], simplex[2], simplex[3]
+	if geom.Orient3D(h.points[a], h.points[b], h.points[c], h.points[d]) < 0 {
+		b, c = c, b // d must lie below (inside) face abc
+	}
+	var all []int
+	for i := range h.points {
+		all = append(all, i)
+	}
+	for _, v := range [][3]int{{a, b, c}, {a, d, b}, {b, d, c}, {c, d, a}} {
+		h.addFace(v)
+	}
+	h.assign(all, []int{0, 1, 2, 3})
+
+	for {
+		f, eye := h.furthest()
+		if f < 0 {
+			break
+		}
+		h.add(f, eye)
+	}
+	return h.result(ds), nil
+}
+
+// simplex returns four affinely independent points, as far apart as can
+// cheaply be found, or false if there are none.
+func (h *hull3) simplex() ([4]int, bool) {
+	var s [4]int
+	if len(h.points) < 4 {
+		return s, false
+	}
+	// the most distant pair among the extreme points along each axis
+	var extremes []int
+	for axis := 0; axis < 3; axis++ {
+		lo, hi := 0, 0
+		for i, p := range h.points {
+			if p[axis] < h.points[lo][axis] {
+				lo = i
+			}
+			if p[axis] > h.points[hi][axis] {
+				hi = i
+			}
+		}
+		extremes = append(extremes, lo, hi)
+	}
+	best := -1.0
+	for _, i := range extremes {
+		for _, j := range extremes {
+			if d := h.points[i].DistSq(h.points[j]); d > best {
+				best, s[0], s[1] = d, i, j
+			}
+		}
+	}
+	// the point furthest from their line
+	p, q := h.points[s[0]], h.points[s[1]]
+	best = 0
+	for i, r := range h.points {
+		if d := q.Sub(p).Cross(r.Sub(p)).NormSq(); d > best {
+			best, s[2] = d, i
+		}
+	}
+	if best == 0 {
+		return s, false
+	}
+	// the point furthest from their plane
+	r := h.points[s[2]]
+	best = 0
+	for i, t := range h.points {
+		if d := math.Abs(geom.Orient3D(p, q, r, t)); d > best {
+			best, s[3] = d, i
+		}
+	}
+	return s, best != 0
+}
+
+// outside reports whether point i lies strictly outside face f.
+func (h *hull3) outside(f *face, i int) bool {
+	return geom.Orient3D(h.points[f.v[0]], h.points[f.v[1]], h.points[f.v[2]], h.points[i]) < 0
+}
+
+func (h *hull3) addFace(v [3]int) int {
+	id := len(h.faces)
+	h.faces = append(h.faces, &face{v: v})
+	for k := 0; k < 3; k++ {
+		h.edges[edge{v[k], v[(k+1)%3]}] = id
+	}
+	return id
+}
+
+// assign gives each point to the first of the faces it lies outside of.
+func (h *hull3) assign(points []int, faces []int) {
+	for _, i := range points {
+		for _, id := range faces {
+			f := h.faces[id]
+			if f.v[0] == i || f.v[1] == i || f.v[2] == i {
+				continue
+			}
+			if h.outside(f, i) {
+				f.outside = append(f.outside, i)
+				break
+			}
+		}
+	}
+}
+
+// furthest returns a live face with points outside it and the point
+// furthest beyond it, or -1 when no face has any.
+// A face gains outside points only as it is made, so once passed over it can
+// be skipped for good.
+func (h *hull3) furthest() (int, int) {
+	for ; h.cursor < len(h.faces); h.cursor++ {
+		f := h.faces[h.cursor]
+		if f.dead || len(f.outside) == 0 {
+			continue
+		}
+		a, b, c := h.points[f.v[0]], h.points[f.v[1]], h.points[f.v[2]]
+		normal := b.Sub(a).Cross(c.Sub(a))
+		eye, best := -1, -1.0
+		for _, i := range f.outside {
+			if d := h.points[i].Sub(a).Dot(normal); d > best {
+				eye, best = i, d
+			}
+		}
+		return h.cursor, eye
+	}
+	return -1, -1
+}
+
+// add extends the hull to the eye point, which lies outside face start.
+func (h *hull3) add(start, eye int) {
+	// find every face the eye can see, which form a connected region
+	visible := map[int]bool{start: true}
+	stack := []int{start}
+	for len(stack) > 0 {
+		f := h.faces[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		for k := 0; k < 3; k++ {
+			twin := h.edges[edge{f.v[(k+1)%3], f.v[k]}]
+			if !visible[twin] && h.outside(h.faces[twin], eye) {
+				visible[twin] = true
+				stack = append(stack, twin)
+			}
+		}
+	}
+
+	// the horizon is every edge of a visible face whose twin is not visible
+	var horizon []edge
+	var orphans []int
+	for id := range visible {
+		f := h.faces[id]
+		for k := 0; k < 3; k++ {
+			e := edge{f.v[k], f.v[(k+1)%3]}
+			if !visible[h.edges[edge{e[1], e[0]}]] {
+				horizon = append(horizon, e)
+			}
+		}
+		orphans = append(orphans, f.outside...)
+		f.dead, f.outside = true, nil
+	}
+	for id := range visible {
+		f := h.faces[id]
+		for k := 0; k < 3; k++ {
+			e := edge{f.v[k], f.v[(k+1)%3]}
+			if h.edges[e] == id {
+				delete(h.edges, e)
+			}
+		}
+	}
+
+	var added []int
+	for _, e := range horizon {
+		added = append(added, h.addFace([3]int{e[0], e[1], eye}))
+	}
+	h.assign(orphans, added)
+}
+
+// result collects the live faces and renumbers their vertices.
+func (h *hull3) result(ds kdtree.Datapoints) *Hull3D {
+	hull := &Hull3D{}
+	index := make(map[int]int)
+	for _, f := range h.faces {
+		if f.dead {
+			continue
+		}
+		var v [3]int
+		for k, i := range f.v {
+			j, seen := index[i]
+			if !seen {
+				j = len(hull.vertices)
+				index[i] = j
+				hull.vertices = append(hull.vertices, ds[i])
+			}
+			v[k] = j
+		}
+		hull.faces = append(hull.faces, v)
+	}
+	return hull
+}
@@ -0,0 +1,90 @@
+// Package nearest provides the bounded candidate set shared by the k-nearest
+// neighbour searches of each spatial structure.
+package nearest
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// Candidate pairs an item with its distance from the query.
+type Candidate struct {
+	Item interface{}
+	Dist float64
+}
+
+// Set keeps the k closest candidates offered to it.
+// Internally it is a max-heap on distance, so the current worst candidate
+// can be evicted in O(log k).
+type Set struct {
+	k     int
+	items candidates
+}
+
+// New returns an empty Set which holds at most k candidates.
+func New(k int) *Set {
+	if k < 0 {
+		k = 0
+	}
+	return &Set{k: k, items: make(candidates, 0, k)}
+}
+
+// Push offers an item at the given distance, keeping it only if it is among
+// the k closest seen so far.
+func (s *Set) Push(item interface{}, dist float64) {
+	if s.k == 0 {
+		return
+	}
+	if len(s.items) < s.k {
+		heap.Push(&s.items, Candidate{item, dist})
+		return
+	}
+	if dist < s.items[0].Dist {
+		s.items[0] = Candidate{item, dist}
+		heap.Fix(&s.items, 0)
+	}
+}
+
+// Len returns the number of candidates currently held.
+func (s *Set) Len() int {
+	return len(s.items)
+}
+
+// Full reports whether k candidates are held.
+func (s *Set) Full() bool {
+	return len(s.items) >= s.k
+}
+
+// Worst returns the distance which a new candidate must beat to be kept:
+// +Inf until the set is full.
+func (s *Set) Worst() float64 {
+	if !s.Full() || s.k == 0 {
+		return math.Inf(1)
+	}
+	return s.items[0].Dist
+}
+
+// Sorted returns the held candidates ordered nearest first.
+func (s *Set) Sorted() []Candidate {
+	out := make([]Candidate, len(s.items))
+	copy(out, s.items)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Dist < out[j].Dist
+	})
+	return out
+}
+
+type candidates []Candidate
+
+func (c candidates) Len() int            { return len(c) }
+func (c candidates) Less(i, j int) bool  { return c[i].Dist > c[j].Dist }
+func (c candidates) Swap(i, j int)       { c[i], c[j] = c[j], c[i] }
+func (c *candidates) Push(x interface{}) { *c = append(*c, x.(Candidate)) }
+func (c *candidates) Pop() interface{} {
+	old := *c
+	n := len(old)
+	x := old[n-1]
+	*c = old[:n-1]
+	return x
+}
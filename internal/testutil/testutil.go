@@ -0,0 +1,115 @@
+// Package testutil holds the generators and comparers of Datapoints shared
+// by the tests of the packages built on kdtree. The tests of kdtree itself
+// cannot import it without a cycle, and keep their own.
+package testutil
+
+import (
+	"math/rand"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// ValueFunc draws one coordinate.
+type ValueFunc func(r *rand.Rand) float64
+
+// Coarse draws one of the n values 0, step, 2*step, ..., so that repeated
+// coordinates, distances and Datapoints are common.
+func Coarse(n int, step float64) ValueFunc {
+	return func(r *rand.Rand) float64 {
+		return float64(r.Intn(n)) * step
+	}
+}
+
+// RandomDatapoints returns n Datapoints of the given dimensionality, each
+// coordinate drawn by value, with the index of each as its data.
+func RandomDatapoints(r *rand.Rand, n, dims int, value ValueFunc) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, n)
+	for i := range ds {
+		set := make([]float64, dims)
+		for axis := range set {
+			set[axis] = value(r)
+		}
+		ds[i] = kdtree.NewDatapoint(i, set)
+	}
+	return ds
+}
+
+// Uniform draws coordinates uniformly from [lo, hi).
+func Uniform(lo, hi float64) ValueFunc {
+	return func(r *rand.Rand) float64 {
+		return lo + r.Float64()*(hi-lo)
+	}
+}
+
+// Normal draws coordinates from the standard normal distribution.
+func Normal(r *rand.Rand) float64 {
+	return r.NormFloat64()
+}
+
+// Centres returns n centres of the given dimensionality for Clustered, each
+// coordinate drawn by value.
+func Centres(r *rand.Rand, n, dims int, value ValueFunc) [][]float64 {
+	cs := make([][]float64, n)
+	for i := range cs {
+		cs[i] = make([]float64, dims)
+		for axis := range cs[i] {
+			cs[i][axis] = value(r)
+		}
+	}
+	return cs
+}
+
+// Clustered returns n Datapoints gathered around the centres, normally
+// distributed with the given spread along each axis as real feature vectors
+// and embeddings are, with the index of each as its data.
+func Clustered(r *rand.Rand, n int, centres [][]float64, spread float64) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, n)
+	for i := range ds {
+		c := centres[r.Intn(len(centres))]
+		set := make([]float64, len(c))
+		for axis := range set {
+			set[axis] = c[axis] + spread*r.NormFloat64()
+		}
+		ds[i] = kdtree.NewDatapoint(i, set)
+	}
+	return ds
+}
+
+// SameMembers reports whether got and want hold the same Datapoints, by
+// pointer and in any order.
+func SameMembers(got, want kdtree.Datapoints) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[*kdtree.Datapoint]int)
+	for _, d := range got {
+		seen[d]++
+	}
+	for _, d := range want {
+		seen[d]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Scan returns a brute-force search for the exact k nearest neighbours of a
+// target among ds by a distance, nearest first, to compare an index against.
+func Scan(ds kdtree.Datapoints, dist metric.Func) func(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	return func(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+		set := nearest.New(k)
+		for _, d := range ds {
+			set.Push(d, dist(target, d))
+		}
+		var out kdtree.Datapoints
+		for _, c := range set.Sorted() {
+			out = append(out, c.Item.(*kdtree.Datapoint))
+		}
+		return out
+	}
+}
@@ -0,0 +1,70 @@
+package interval
+
+import "github.com/benjamin-rood/goeometric/kdtree"
+
+// Interval is a closed interval [low, high] on the real line, together with a
+// pointer to any other structure or type which you may wish to associate with it.
+type Interval struct {
+	data      interface{} // ideally a pointer to some other associated thing
+	low, high float64
+}
+
+// New is an explicit constructor for an Interval,
+// swapping the end points if they are given in the wrong order.
+func New(data interface{}, low, high float64) *Interval {
+	if high < low {
+		low, high = high, low
+	}
+	return &Interval{data: data, low: low, high: high}
+}
+
+// FromRange constructs an Interval over the same values as a kdtree.Range.
+func FromRange(data interface{}, r kdtree.Range) *Interval {
+	return New(data, r.Min(), r.Max())
+}
+
+// Data returns the interface value of the object that the Interval is linked with.
+func (iv *Interval) Data() interface{} {
+	return iv.data
+}
+
+// Low returns the lower end point.
+func (iv *Interval) Low() float64 {
+	return iv.low
+}
+
+// High returns the upper end point.
+func (iv *Interval) High() float64 {
+	return iv.high
+}
+
+// Range returns the Interval's end points as a kdtree.Range.
+func (iv *Interval) Range() kdtree.Range {
+	return kdtree.NewRange(iv.low, iv.high)
+}
+
+// Contains reports whether x lies within the Interval (inclusive).
+func (iv *Interval) Contains(x float64) bool {
+	return iv.low <= x && x <= iv.high
+}
+
+// Overlaps reports whether the Interval shares at least one value with [low, high].
+func (iv *Interval) Overlaps(low, high float64) bool {
+	return iv.low <= high && low <= iv.high
+}
+
+// Index is the query surface shared by Tree and SegmentTree.
+// Intervals are identified by pointer, so the same end points
+// may be held any number of times under different Intervals.
+type Index interface {
+	// Insert adds an Interval; adding one already held has no effect.
+	Insert(iv *Interval)
+	// Delete removes the given Interval, reporting whether it was held.
+	Delete(iv *Interval) bool
+	// Stab returns every Interval containing x.
+	Stab(x float64) []*Interval
+	// Overlapping returns every Interval sharing at least one value with [low, high].
+	Overlapping(low, high float64) []*Interval
+	// Len returns the number of Intervals held.
+	Len() int
+}
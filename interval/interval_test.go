@@ -0,0 +1,154 @@
+package interval
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func randomIntervals(r *rand.Rand, n int) []*Interval {
+	ivs := make([]*Interval, n)
+	for i := range ivs {
+		low := float64(r.Intn(200)) // coarse values, so shared end points are common
+		ivs[i] = New(i, low, low+float64(r.Intn(30)))
+	}
+	return ivs
+}
+
+func sameMembers(got, want []*Interval) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[*Interval]int)
+	for _, iv := range got {
+		seen[iv]++
+	}
+	for _, iv := range want {
+		seen[iv]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bruteOverlapping(ivs []*Interval, low, high float64) []*Interval {
+	var found []*Interval
+	for _, iv := range ivs {
+		if iv != nil && iv.Overlaps(low, high) {
+			found = append(found, iv)
+		}
+	}
+	return found
+}
+
+func Test_Interval_Indexes_Match_Brute_Force(t *testing.T) {
+	r := rand.New(rand.NewSource(62))
+	ivs := randomIntervals(r, 400)
+	indexes := map[string]Index{
+		`Tree`:        NewTree(ivs[:300]),
+		`SegmentTree`: NewSegmentTree(ivs[:300]),
+	}
+	for name, index := range indexes {
+		held := make([]*Interval, 300)
+		copy(held, ivs[:300])
+		for _, iv := range ivs[300:] {
+			index.Insert(iv)
+			held = append(held, iv)
+		}
+		index.Insert(ivs[0]) // already held
+		for i := 0; i < len(held); i += 3 {
+			if !index.Delete(held[i]) || index.Delete(held[i]) {
+				t.Fatal(name, ` should delete `, held[i], ` exactly once`)
+			}
+			held[i] = nil
+		}
+		if want := len(held) - (len(held)+2)/3; index.Len() != want {
+			t.Fatal(name, ` want: `, want, `
+			got: `, index.Len())
+		}
+
+		for x := -5.0; x < 240; x += 0.5 {
+			if got, want := index.Stab(x), bruteOverlapping(held, x, x); !sameMembers(got, want) {
+				t.Error(name, ` Stab(`, x, `) want: `, len(want), `
+				got: `, len(got))
+			}
+			low := float64(r.Intn(240)) - 5
+			high := low + float64(r.Intn(20))
+			if got, want := index.Overlapping(low, high), bruteOverlapping(held, low, high); !sameMembers(got, want) {
+				t.Error(name, ` Overlapping(`, low, high, `) want: `, len(want), `
+				got: `, len(got))
+			}
+		}
+	}
+}
+
+func Test_Interval_SegmentTree_Rebuilds_Are_Batched(t *testing.T) {
+	r := rand.New(rand.NewSource(62))
+	ivs := randomIntervals(r, 1000)
+	st := NewSegmentTree(nil)
+	rebuilds := 0
+	for _, iv := range ivs {
+		built := st.built
+		st.Insert(iv)
+		if st.built != built {
+			rebuilds++
+		}
+	}
+	if rebuilds > 12 {
+		t.Error(`want: O(log n) skeleton rebuilds for 1000 new end points
+		got: `, rebuilds)
+	}
+	for _, iv := range ivs[10:] {
+		st.Delete(iv)
+	}
+	if len(st.points) > 4*st.Len() {
+		t.Error(`want: the skeleton to shrink with the Intervals held
+		got: `, len(st.points), ` end points for `, st.Len(), ` Intervals`)
+	}
+	if got, want := st.Overlapping(-5, 240), bruteOverlapping(ivs[:10], -5, 240); !sameMembers(got, want) {
+		t.Error(`want: `, len(want), `
+		got: `, len(got))
+	}
+}
+
+func Test_Interval_Tree_Stab_Is_Ordered(t *testing.T) {
+	tree := NewTree([]*Interval{
+		New(`c`, 3, 9),
+		New(`a`, 1, 5),
+		New(`d`, 4, 4),
+		New(`b`, 2, 8),
+		New(`e`, 6, 7),
+	})
+	got := ``
+	for _, iv := range tree.Stab(4) {
+		got += iv.Data().(string)
+	}
+	if got != `abcd` {
+		t.Error(`want: abcd
+		got: `, got)
+	}
+}
+
+func Test_Interval_Constructors(t *testing.T) {
+	iv := New(nil, 5, -1)
+	if iv.Low() != -1 || iv.High() != 5 {
+		t.Error(`want: [-1, 5]
+		got: `, iv.Low(), iv.High())
+	}
+	iv = FromRange(`payload`, kdtree.NewRange(2, 3))
+	if iv.Data() != `payload` || iv.Range() != kdtree.NewRange(2, 3) || !iv.Contains(3) || iv.Contains(3.5) {
+		t.Error(`FromRange should keep the payload and end points`)
+	}
+	st := NewSegmentTree(nil)
+	if st.Stab(0) != nil || st.Len() != 0 || st.Delete(iv) {
+		t.Error(`empty SegmentTree should hold nothing`)
+	}
+	st.Insert(iv)
+	if got := st.Stab(2.5); len(got) != 1 || got[0] != iv {
+		t.Error(`SegmentTree should grow its skeleton on Insert`)
+	}
+}
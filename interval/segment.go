@@ -0,0 +1,249 @@
+package interval
+
+import "sort"
+
+// SegmentTree is a segment tree over the elementary segments between the
+// end points of its Intervals: each sorted end point p[i] is a segment of its
+// own, as is each open gap (p[i], p[i+1]). Every Interval is stored at the
+// O(log n) canonical nodes which together cover its elementary segments, so a
+// stabbing query is a single root-to-leaf walk costing O(log n + k).
+//
+// Inserting and deleting Intervals whose end points are already known costs
+// O(log n). An Interval with a new end point is kept in a pending list, which
+// every query scans, until the pending list grows as large as the skeleton's
+// Intervals; the skeleton is then rebuilt over all of them in O(n log n), as
+// it is when deletions halve the Intervals held, so both cost O(log n)
+// amortised. Compared with Tree, a SegmentTree answers stabbing queries
+// faster on heavily nested Intervals, but uses O(n log n) space; Tree suits
+// sets whose end points keep changing.
+type SegmentTree struct {
+	root    *segmentNode
+	points  []float64 // the distinct end points, sorted
+	held    map[*Interval]struct{}
+	pending []*Interval // held, but with an end point not in the skeleton
+	built   int         // the number held when the skeleton was last rebuilt
+}
+
+var _ Index = (*SegmentTree)(nil)
+
+// segmentNode covers the elementary segments lo to hi (inclusive), where
+// segment 2i is the end point p[i] and segment 2i+1 is the gap (p[i], p[i+1]).
+type segmentNode struct {
+	lo, hi      int
+	ivs         []*Interval // the Intervals for which this node is canonical
+	left, right *segmentNode
+}
+
+// NewSegmentTree constructs a SegmentTree holding each of the Intervals given.
+func NewSegmentTree(ivs []*Interval) *SegmentTree {
+	st := &SegmentTree{held: make(map[*Interval]struct{})}
+	for _, iv := range ivs {
+		if iv != nil {
+			st.held[iv] = struct{}{}
+		}
+	}
+	st.rebuild()
+	return st
+}
+
+// Len returns the number of Intervals held.
+func (st *SegmentTree) Len() int {
+	return len(st.held)
+}
+
+// rebuild lays out a new skeleton over the end points of every held
+// Interval and stores each Interval at its canonical nodes.
+func (st *SegmentTree) rebuild() {
+	st.pending = nil
+	st.built = len(st.held)
+	st.points = st.points[:0]
+	for iv := range st.held {
+		st.points = append(st.points, iv.low, iv.high)
+	}
+	sort.Float64s(st.points)
+	distinct := st.points[:0]
+	for i, p := range st.points {
+		if i == 0 || p != st.points[i-1] {
+			distinct = append(distinct, p)
+		}
+	}
+	st.points = distinct
+	st.root = nil
+	if len(st.points) == 0 {
+		return
+	}
+	st.root = buildSegments(0, 2*len(st.points)-2)
+	for iv := range st.held {
+		lo, hi := st.segments(iv.low, iv.high)
+		st.root.insert(iv, lo, hi)
+	}
+}
+
+func buildSegments(lo, hi int) *segmentNode {
+	n := &segmentNode{lo: lo, hi: hi}
+	if lo < hi {
+		mid := (lo + hi) / 2
+		n.left = buildSegments(lo, mid)
+		n.right = buildSegments(mid+1, hi)
+	}
+	return n
+}
+
+// known reports whether x is one of the end points in the skeleton.
+func (st *SegmentTree) known(x float64) bool {
+	i := sort.SearchFloat64s(st.points, x)
+	return i < len(st.points) && st.points[i] == x
+}
+
+// segments returns the first and last elementary segments meeting [low, high],
+// with lo > hi when there are none.
+func (st *SegmentTree) segments(low, high float64) (lo, hi int) {
+	n := len(st.points)
+	i := sort.SearchFloat64s(st.points, low) // first end point >= low
+	switch {
+	case i == n:
+		return 1, 0
+	case st.points[i] == low || i == 0:
+		lo = 2 * i
+	default:
+		lo = 2*i - 1
+	}
+	j := sort.Search(n, func(k int) bool { return st.points[k] > high }) - 1 // last end point <= high
+	switch {
+	case j < 0:
+		return 1, 0
+	case st.points[j] == high || j == n-1:
+		hi = 2 * j
+	default:
+		hi = 2*j + 1
+	}
+	return lo, hi
+}
+
+// Insert adds an Interval; adding one already held has no effect.
+func (st *SegmentTree) Insert(iv *Interval) {
+	if iv == nil {
+		return
+	}
+	if _, held := st.held[iv]; held {
+		return
+	}
+	st.held[iv] = struct{}{}
+	if !st.known(iv.low) || !st.known(iv.high) {
+		st.pending = append(st.pending, iv)
+		if len(st.pending) > st.built {
+			st.rebuild()
+		}
+		return
+	}
+	lo, hi := st.segments(iv.low, iv.high)
+	st.root.insert(iv, lo, hi)
+}
+
+// Delete removes the given Interval, reporting whether it was held.
+func (st *SegmentTree) Delete(iv *Interval) bool {
+	if _, held := st.held[iv]; !held {
+		return false
+	}
+	delete(st.held, iv)
+	if 2*len(st.held) < st.built {
+		st.rebuild()
+		return true
+	}
+	for i := range st.pending {
+		if st.pending[i] == iv {
+			st.pending = append(st.pending[:i], st.pending[i+1:]...)
+			return true
+		}
+	}
+	lo, hi := st.segments(iv.low, iv.high)
+	st.root.delete(iv, lo, hi)
+	return true
+}
+
+func (n *segmentNode) insert(iv *Interval, lo, hi int) {
+	if hi < n.lo || n.hi < lo {
+		return
+	}
+	if lo <= n.lo && n.hi <= hi {
+		n.ivs = append(n.ivs, iv)
+		return
+	}
+	n.left.insert(iv, lo, hi)
+	n.right.insert(iv, lo, hi)
+}
+
+func (n *segmentNode) delete(iv *Interval, lo, hi int) {
+	if hi < n.lo || n.hi < lo {
+		return
+	}
+	if lo <= n.lo && n.hi <= hi {
+		for i := range n.ivs {
+			if n.ivs[i] == iv {
+				n.ivs = append(n.ivs[:i], n.ivs[i+1:]...)
+				return
+			}
+		}
+		return
+	}
+	n.left.delete(iv, lo, hi)
+	n.right.delete(iv, lo, hi)
+}
+
+// Stab returns every Interval containing x, in no particular order.
+func (st *SegmentTree) Stab(x float64) []*Interval {
+	var found []*Interval
+	for _, iv := range st.pending {
+		if iv.Contains(x) {
+			found = append(found, iv)
+		}
+	}
+	lo, hi := st.segments(x, x)
+	if lo > hi {
+		return found
+	}
+	for n := st.root; n != nil; {
+		found = append(found, n.ivs...)
+		if n.left != nil && lo <= n.left.hi {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return found
+}
+
+// Overlapping returns every Interval sharing at least one value with [low, high],
+// in no particular order.
+func (st *SegmentTree) Overlapping(low, high float64) []*Interval {
+	if low > high {
+		return nil
+	}
+	var found []*Interval
+	for _, iv := range st.pending {
+		if iv.Overlaps(low, high) {
+			found = append(found, iv)
+		}
+	}
+	lo, hi := st.segments(low, high)
+	if lo > hi {
+		return found
+	}
+	seen := make(map[*Interval]bool)
+	st.root.overlapping(lo, hi, seen, &found)
+	return found
+}
+
+func (n *segmentNode) overlapping(lo, hi int, seen map[*Interval]bool, found *[]*Interval) {
+	if n == nil || hi < n.lo || n.hi < lo {
+		return
+	}
+	for _, iv := range n.ivs {
+		if !seen[iv] {
+			seen[iv] = true
+			*found = append(*found, iv)
+		}
+	}
+	n.left.overlapping(lo, hi, seen, found)
+	n.right.overlapping(lo, hi, seen, found)
+}
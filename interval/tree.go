@@ -0,0 +1,183 @@
+package interval
+
+// Tree is an augmented interval tree: a height-balanced (AVL) binary search
+// tree ordered by the low end points, where every node also records the
+// greatest high end point beneath it. Insertion and deletion cost O(log n),
+// and stabbing or overlap queries cost O(log n + k) for k reported Intervals,
+// which are returned in order of their low end points.
+type Tree struct {
+	root *treeNode
+	seqs map[*Interval]uint64 // breaks ties between equal low end points
+	next uint64
+}
+
+var _ Index = (*Tree)(nil)
+
+type treeNode struct {
+	iv          *Interval
+	seq         uint64
+	max         float64
+	height      int
+	left, right *treeNode
+}
+
+// NewTree constructs a Tree holding each of the Intervals given.
+func NewTree(ivs []*Interval) *Tree {
+	t := &Tree{seqs: make(map[*Interval]uint64)}
+	for _, iv := range ivs {
+		t.Insert(iv)
+	}
+	return t
+}
+
+// Len returns the number of Intervals held.
+func (t *Tree) Len() int {
+	return len(t.seqs)
+}
+
+// Insert adds an Interval; adding one already held has no effect.
+func (t *Tree) Insert(iv *Interval) {
+	if iv == nil {
+		return
+	}
+	if _, held := t.seqs[iv]; held {
+		return
+	}
+	t.next++
+	t.seqs[iv] = t.next
+	t.root = t.root.insert(&treeNode{iv: iv, seq: t.next, max: iv.high, height: 1})
+}
+
+// Delete removes the given Interval, reporting whether it was held.
+func (t *Tree) Delete(iv *Interval) bool {
+	seq, held := t.seqs[iv]
+	if !held {
+		return false
+	}
+	delete(t.seqs, iv)
+	t.root = t.root.delete(iv.low, seq)
+	return true
+}
+
+// Stab returns every Interval containing x.
+func (t *Tree) Stab(x float64) []*Interval {
+	return t.Overlapping(x, x)
+}
+
+// Overlapping returns every Interval sharing at least one value with [low, high].
+func (t *Tree) Overlapping(low, high float64) []*Interval {
+	var found []*Interval
+	t.root.overlapping(low, high, &found)
+	return found
+}
+
+func (n *treeNode) overlapping(low, high float64, found *[]*Interval) {
+	if n == nil || n.max < low {
+		return // nothing beneath reaches as far as low
+	}
+	n.left.overlapping(low, high, found)
+	if n.iv.low > high {
+		return // neither this nor anything to the right starts early enough
+	}
+	if n.iv.high >= low {
+		*found = append(*found, n.iv)
+	}
+	n.right.overlapping(low, high, found)
+}
+
+// before orders nodes by low end point, then by insertion.
+func (n *treeNode) before(low float64, seq uint64) bool {
+	return n.iv.low < low || (n.iv.low == low && n.seq < seq)
+}
+
+func (n *treeNode) insert(in *treeNode) *treeNode {
+	if n == nil {
+		return in
+	}
+	if n.before(in.iv.low, in.seq) {
+		n.right = n.right.insert(in)
+	} else {
+		n.left = n.left.insert(in)
+	}
+	return n.rebalance()
+}
+
+func (n *treeNode) delete(low float64, seq uint64) *treeNode {
+	switch {
+	case n == nil:
+		return nil
+	case n.seq == seq:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		successor := n.right
+		for successor.left != nil {
+			successor = successor.left
+		}
+		n.right = n.right.delete(successor.iv.low, successor.seq)
+		n.iv, n.seq = successor.iv, successor.seq
+	case n.before(low, seq):
+		n.right = n.right.delete(low, seq)
+	default:
+		n.left = n.left.delete(low, seq)
+	}
+	return n.rebalance()
+}
+
+func (n *treeNode) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// update recomputes the height and maximum high end point from the children.
+func (n *treeNode) update() {
+	n.height = 1 + n.left.getHeight()
+	if h := 1 + n.right.getHeight(); h > n.height {
+		n.height = h
+	}
+	n.max = n.iv.high
+	if n.left != nil && n.left.max > n.max {
+		n.max = n.left.max
+	}
+	if n.right != nil && n.right.max > n.max {
+		n.max = n.right.max
+	}
+}
+
+func (n *treeNode) rebalance() *treeNode {
+	n.update()
+	switch balance := n.left.getHeight() - n.right.getHeight(); {
+	case balance > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (n *treeNode) rotateLeft() *treeNode {
+	r := n.right
+	n.right, r.left = r.left, n
+	n.update()
+	r.update()
+	return r
+}
+
+func (n *treeNode) rotateRight() *treeNode {
+	l := n.left
+	n.left, l.right = l.right, n
+	n.update()
+	l.update()
+	return l
+}
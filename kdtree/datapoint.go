@@ -43,6 +43,11 @@ func (d *Datapoint) Set() []float64 {
 	return export
 }
 
+// At returns the value of the Datapoint along a single axis without copying the set.
+func (d *Datapoint) At(axis int) float64 {
+	return d.set[axis]
+}
+
 // Dimensionality returns spatial dimensions the Datapoint fits over.
 func (d *Datapoint) Dimensionality() int {
 	return len(d.set)
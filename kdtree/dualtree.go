@@ -0,0 +1,199 @@
+package kdtree
+
+import (
+	"math"
+	"sort"
+)
+
+// ScoreFunc decides whether a dual-tree traversal should visit a pair of
+// Branches, returning +Inf to prune the pair and everything beneath it.
+// Otherwise the score is a priority: of the pairs formed by splitting one
+// pair, those with lower scores are visited first.
+type ScoreFunc func(query, reference *Branch) float64
+
+// BaseCaseFunc is called by a dual-tree traversal on every pair of
+// Datapoints from a pair of leaves that survives pruning.
+type BaseCaseFunc func(query, reference *Datapoint)
+
+// DualTree traverses a query tree and a reference tree together (which may
+// be the same tree), the pattern shared by all-nearest-neighbours, spatial
+// joins, minimum spanning trees and kernel sums, after Curtin et al.,
+// "Tree-Independent Dual-Tree Algorithms" (2013). Starting from the pair of
+// roots, each pair that score does not prune is split into the pairs of
+// their children (only the non-leaf one is split when the other is a leaf),
+// until pairs of leaves are reached, whose Datapoints are given to baseCase.
+// Child pairs are scored again just before they are visited, since the base
+// cases already run may allow a tighter decision. Branches holding no
+// Datapoints are skipped.
+func DualTree(query, reference *Branch, score ScoreFunc, baseCase BaseCaseFunc) {
+	if query == nil || reference == nil || math.IsInf(score(query, reference), 1) {
+		return
+	}
+	dualTree(query, reference, score, baseCase)
+}
+
+// Join calls fn on every pair of a Datapoint from query and a Datapoint from
+// reference lying within distance r of each other (inclusive), pruning pairs
+// of Branches which are further apart. The trees must share a dimensionality.
+func Join(query, reference *Tree, r float64, fn func(query, reference *Datapoint)) {
+	if query == nil || reference == nil || query.Dims() != reference.Dims() || r < 0 {
+		return
+	}
+	rSq := r * r
+	DualTree(query.Root(), reference.Root(),
+		func(q, ref *Branch) float64 {
+			if distSq := MinDistanceSq(q, ref); distSq <= rSq {
+				return distSq
+			}
+			return math.Inf(1)
+		},
+		func(q, ref *Datapoint) {
+			if DistanceSq(q, ref) <= rSq {
+				fn(q, ref)
+			}
+		})
+}
+
+type branchPair struct {
+	query, reference *Branch
+	score            float64
+}
+
+func dualTree(query, reference *Branch, score ScoreFunc, baseCase BaseCaseFunc) {
+	if len(query.Datapoints) == 0 || len(reference.Datapoints) == 0 {
+		return
+	}
+	if query.IsLeaf() && reference.IsLeaf() {
+		for _, q := range query.Datapoints {
+			for _, r := range reference.Datapoints {
+				if q != nil && r != nil {
+					baseCase(q, r)
+				}
+			}
+		}
+		return
+	}
+
+	queries, references := []*Branch{query}, []*Branch{reference}
+	if !query.IsLeaf() {
+		queries = []*Branch{query.left, query.right}
+	}
+	if !reference.IsLeaf() {
+		references = []*Branch{reference.left, reference.right}
+	}
+	pairs := make([]branchPair, 0, 4)
+	for _, q := range queries {
+		for _, r := range references {
+			if len(q.Datapoints) > 0 && len(r.Datapoints) > 0 {
+				pairs = append(pairs, branchPair{q, r, score(q, r)})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].score < pairs[j].score
+	})
+	for i, p := range pairs {
+		if i > 0 {
+			p.score = score(p.query, p.reference)
+		}
+		if !math.IsInf(p.score, 1) {
+			dualTree(p.query, p.reference, score, baseCase)
+		}
+	}
+}
+
+// Bounds returns the smallest box containing every Datapoint held by the
+// Branch, one Range per axis, or nil if it holds none. It is computed when
+// the Branch is built and kept up to date by a Tree's Insert and Delete, but
+// not by editing the Datapoints of the Branch directly.
+func (branch *Branch) Bounds() []Range {
+	return branch.bounds
+}
+
+// fit sets the bounds of the Branch from those of its children, or from its
+// Datapoints if it is a leaf.
+func (branch *Branch) fit() {
+	if branch.IsLeaf() {
+		branch.bounds = nil
+		for _, d := range branch.Datapoints {
+			branch.bounds = include(branch.bounds, d)
+		}
+		return
+	}
+	var bounds []Range
+	for _, child := range []*Branch{branch.left, branch.right} {
+		if child == nil || child.bounds == nil {
+			continue
+		}
+		if bounds == nil {
+			bounds = make([]Range, len(child.bounds))
+			copy(bounds, child.bounds)
+			continue
+		}
+		for axis, r := range child.bounds {
+			if r.min < bounds[axis].min {
+				bounds[axis].min = r.min
+			}
+			if r.max > bounds[axis].max {
+				bounds[axis].max = r.max
+			}
+		}
+	}
+	branch.bounds = bounds
+}
+
+// include widens the bounds to contain d, allocating them if nil.
+func include(bounds []Range, d *Datapoint) []Range {
+	if d == nil {
+		return bounds
+	}
+	if bounds == nil {
+		bounds = make([]Range, len(d.set))
+		for axis, v := range d.set {
+			bounds[axis] = Range{v, v}
+		}
+		return bounds
+	}
+	for axis, v := range d.set {
+		if v < bounds[axis].min {
+			bounds[axis].min = v
+		} else if v > bounds[axis].max {
+			bounds[axis].max = v
+		}
+	}
+	return bounds
+}
+
+// MinDistanceSq returns the squared distance between the nearest points of
+// the bounding boxes of two Branches: no Datapoint of one is closer than this
+// to any Datapoint of the other.
+func MinDistanceSq(p, q *Branch) float64 {
+	pb, qb := p.Bounds(), q.Bounds()
+	var result float64
+	for axis := range pb {
+		gap := qb[axis].min - pb[axis].max
+		if g := pb[axis].min - qb[axis].max; g > gap {
+			gap = g
+		}
+		if gap > 0 {
+			result += gap * gap
+		}
+	}
+	return result
+}
+
+// MaxDistanceSq returns the squared distance between the furthest points of
+// the bounding boxes of two Branches: no Datapoint of one is further than
+// this from any Datapoint of the other.
+func MaxDistanceSq(p, q *Branch) float64 {
+	pb, qb := p.Bounds(), q.Bounds()
+	var result float64
+	for axis := range pb {
+		span := qb[axis].max - pb[axis].min
+		if s := pb[axis].max - qb[axis].min; s > span {
+			span = s
+		}
+		result += span * span
+	}
+	return result
+}
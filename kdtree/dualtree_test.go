@@ -0,0 +1,133 @@
+package kdtree
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+)
+
+func Test_DualTree_AllKNN(t *testing.T) {
+	r := rand.New(rand.NewSource(70))
+	ds := randomDatapoints(r, 500, 2)
+	tree, _ := NewTree(ds, Median)
+	linear, _ := NewLinear(ds)
+
+	const k = 5
+	sets := make(map[*Datapoint]*nearest.Set, len(ds))
+	for _, d := range ds {
+		sets[d] = nearest.New(k)
+	}
+	baseCases := 0
+	DualTree(tree.Root(), tree.Root(),
+		func(query, reference *Branch) float64 {
+			worst := 0.0 // the largest kth distance of any query below
+			for _, q := range query.Datapoints {
+				worst = math.Max(worst, sets[q].Worst())
+			}
+			if d := MinDistanceSq(query, reference); d <= worst {
+				return d
+			}
+			return math.Inf(1)
+		},
+		func(query, reference *Datapoint) {
+			baseCases++
+			sets[query].Push(reference, DistanceSq(query, reference))
+		})
+
+	for _, d := range ds {
+		if got, want := candidatesToDatapoints(sets[d].Sorted()), linear.KNN(d, k); !sameDistances(d, got, want) {
+			t.Fatal(`KNN want: `, want.PointsSetString(), `
+			got: `, got.PointsSetString())
+		}
+	}
+	if baseCases >= len(ds)*len(ds)/4 {
+		t.Error(`pruning should have skipped most of the `, len(ds)*len(ds), ` pairs, ran `, baseCases)
+	}
+}
+
+func Test_DualTree_Join(t *testing.T) {
+	r := rand.New(rand.NewSource(70))
+	qs, rs := randomDatapoints(r, 300, 3), randomDatapoints(r, 200, 3)
+	queries, _ := NewTree(qs, nil)
+	references, _ := NewTree(rs, Mean)
+
+	const radius = 4
+	found := make(map[[2]*Datapoint]bool)
+	Join(queries, references, radius, func(query, reference *Datapoint) {
+		found[[2]*Datapoint{query, reference}] = true
+	})
+
+	want := 0
+	for _, q := range qs {
+		for _, ref := range rs {
+			if DistanceSq(q, ref) <= radius*radius {
+				want++
+				if !found[[2]*Datapoint{q, ref}] {
+					t.Fatal(`missed the pair `, q, ref)
+				}
+			}
+		}
+	}
+	if len(found) != want {
+		t.Error(`want: `, want, `
+		got: `, len(found))
+	}
+	flat, _ := NewTree(randomDatapoints(r, 10, 2), nil)
+	Join(queries, flat, radius, func(query, reference *Datapoint) {
+		t.Fatal(`trees of different dimensionality should not be joined`)
+	})
+}
+
+func Test_DualTree_Bounds(t *testing.T) {
+	r := rand.New(rand.NewSource(70))
+	tree, _ := NewTree(randomDatapoints(r, 100, 2), nil)
+	root := tree.Root()
+	left, right := root.Left(), root.Right()
+	for _, p := range left.Datapoints {
+		if !InBounds(p, left.Bounds()) {
+			t.Fatal(p, ` lies outside `, left.Bounds())
+		}
+		for _, q := range right.Datapoints {
+			if d := DistanceSq(p, q); d < MinDistanceSq(left, right) || d > MaxDistanceSq(left, right) {
+				t.Fatal(`distance `, d, ` lies outside the bounds between the Branches`)
+			}
+		}
+	}
+
+	far := NewDatapoint(nil, []float64{1000, 1000})
+	tree.Insert(far)
+	if !InBounds(far, root.Bounds()) {
+		t.Error(`Insert should widen the Bounds of the Branches above it`)
+	}
+	tree.Delete(far)
+	if InBounds(far, root.Bounds()) {
+		t.Error(`Delete should narrow the Bounds of the Branches above it`)
+	}
+
+	for _, d := range randomDatapoints(r, 50, 2) {
+		tree.Insert(d)
+	}
+	for _, d := range append(Datapoints(nil), tree.Root().Datapoints[:60]...) {
+		tree.Delete(d)
+	}
+	var walk func(b *Branch)
+	walk = func(b *Branch) {
+		if b == nil {
+			return
+		}
+		var want []Range
+		for _, d := range b.Datapoints {
+			want = include(want, d)
+		}
+		if !reflect.DeepEqual(b.Bounds(), want) {
+			t.Fatal(`depth `, b.Depth(), ` want: `, want, `
+			got: `, b.Bounds())
+		}
+		walk(b.Left())
+		walk(b.Right())
+	}
+	walk(tree.Root())
+}
@@ -0,0 +1,7 @@
+package kdtree
+
+import "errors"
+
+// ErrDimensionMismatch is returned when a Datapoint does not have the same
+// dimensionality as the structure it is being added to.
+var ErrDimensionMismatch = errors.New("kdtree: datapoint dimensionality does not match")
@@ -0,0 +1,234 @@
+package kdtree
+
+import "github.com/benjamin-rood/goeometric/internal/nearest"
+
+// Tree wraps a k-d tree Branch so that it can be used as a mutable SpatialIndex.
+// The root Branch keeps its usual shape (all Datapoints at the leaves,
+// every Branch holding the Datapoints below it), so the free functions such as
+// ANN and RangeQuery can still be applied to Root().
+type Tree struct {
+	root     *Branch
+	pivotDef PivotFunc
+	dims     int
+	size     int
+	held     map[*Datapoint]struct{}
+}
+
+var _ SpatialIndex = (*Tree)(nil)
+
+// NewTree constructs a Tree over a copy of ds using the given PivotFunc
+// (LazyAverage when nil). All Datapoints must share the same dimensionality.
+func NewTree(ds Datapoints, pivotDef PivotFunc) (*Tree, error) {
+	if pivotDef == nil {
+		pivotDef = LazyAverage
+	}
+	t := &Tree{pivotDef: pivotDef, held: make(map[*Datapoint]struct{})}
+	points, err := checkDims(ds)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return t, nil
+	}
+	for _, d := range points {
+		t.held[d] = struct{}{}
+	}
+	t.dims = len(points[0].set)
+	t.size = len(points)
+	t.root = grow(points, 0, pivotDef)
+	return t, nil
+}
+
+// Root returns the root Branch of the underlying k-d tree, nil when empty.
+func (t *Tree) Root() *Branch {
+	return t.root
+}
+
+// Len returns the number of Datapoints held in the Tree.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Dims returns the dimensionality of the Datapoints held in the Tree,
+// or 0 if nothing has been inserted yet.
+func (t *Tree) Dims() int {
+	return t.dims
+}
+
+// Insert adds a Datapoint, descending to the leaf which should hold it and
+// splitting that leaf if it no longer holds a single distinct value.
+// Inserting a Datapoint which is already held has no effect.
+func (t *Tree) Insert(d *Datapoint) error {
+	if d == nil {
+		return ErrDimensionMismatch
+	}
+	if t.size == 0 && t.dims == 0 {
+		t.dims = len(d.set)
+	}
+	if len(d.set) != t.dims {
+		return ErrDimensionMismatch
+	}
+	if _, ok := t.held[d]; ok {
+		return nil
+	}
+	t.held[d] = struct{}{}
+	t.size++
+	if t.root == nil {
+		t.root = &Branch{Datapoints: Datapoints{d}, bounds: include(nil, d)}
+		return nil
+	}
+
+	branch := t.root
+	for {
+		branch.Datapoints = append(branch.Datapoints, d)
+		branch.bounds = include(branch.bounds, d)
+		if branch.left == nil && branch.right == nil {
+			*branch = *grow(withoutNil(branch.Datapoints), branch.depth, t.pivotDef)
+			return nil
+		}
+		if d.set[branch.depth%t.dims] < branch.pivot {
+			branch = branch.left
+		} else {
+			branch = branch.right
+		}
+	}
+}
+
+// Delete removes the given Datapoint from every Branch on its path,
+// reporting whether it was held in the Tree.
+// Emptied leaves are left in place rather than rebalancing the tree.
+// As every Branch holds the Datapoints below it, removing one from the root
+// costs O(n); NodeTree deletes in O(log n) when updates dominate.
+func (t *Tree) Delete(d *Datapoint) bool {
+	if _, ok := t.held[d]; !ok {
+		return false
+	}
+	delete(t.held, d)
+	var path []*Branch
+	branch := t.root
+	for branch != nil {
+		branch.Datapoints = branch.Datapoints.remove(d)
+		path = append(path, branch)
+		if branch.left == nil && branch.right == nil {
+			break
+		}
+		if d.set[branch.depth%t.dims] < branch.pivot {
+			branch = branch.left
+		} else {
+			branch = branch.right
+		}
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].fit()
+	}
+	t.size--
+	if t.size == 0 {
+		t.root = nil
+	}
+	return true
+}
+
+// NN returns the exact nearest neighbour of the target, or nil if the Tree is empty.
+func (t *Tree) NN(target *Datapoint) *Datapoint {
+	ds := t.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k exact nearest neighbours of the target, nearest first.
+func (t *Tree) KNN(target *Datapoint, k int) Datapoints {
+	if t.root == nil || k <= 0 || target == nil || len(target.set) != t.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	t.knn(t.root, target, set)
+	return candidatesToDatapoints(set.Sorted())
+}
+
+func (t *Tree) knn(branch *Branch, target *Datapoint, set *nearest.Set) {
+	if branch == nil {
+		return
+	}
+	if branch.left == nil && branch.right == nil {
+		for _, d := range branch.Datapoints {
+			if d != nil {
+				set.Push(d, DistanceSq(target, d))
+			}
+		}
+		return
+	}
+	diff := target.set[branch.depth%t.dims] - branch.pivot
+	near, far := branch.left, branch.right
+	if diff >= 0 {
+		near, far = far, near
+	}
+	t.knn(near, target, set)
+	if diff*diff < set.Worst() {
+		t.knn(far, target, set)
+	}
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (t *Tree) Range(bounds []Range) Datapoints {
+	if t.root == nil || len(bounds) != t.dims {
+		return nil
+	}
+	var found Datapoints
+	t.rangeSearch(t.root, bounds, &found)
+	return found
+}
+
+func (t *Tree) rangeSearch(branch *Branch, bounds []Range, found *Datapoints) {
+	if branch == nil {
+		return
+	}
+	if branch.left == nil && branch.right == nil {
+		for _, d := range branch.Datapoints {
+			if InBounds(d, bounds) {
+				*found = append(*found, d)
+			}
+		}
+		return
+	}
+	axis := branch.depth % t.dims
+	if bounds[axis].min < branch.pivot {
+		t.rangeSearch(branch.left, bounds, found)
+	}
+	if bounds[axis].max >= branch.pivot {
+		t.rangeSearch(branch.right, bounds, found)
+	}
+}
+
+// grow builds a subtree in the same way as Build, except that whenever
+// pivotDef leaves one side empty it splits at the largest value along the
+// axis instead, so repeated values can never cause endless recursion.
+func grow(ds Datapoints, depth int, pivotDef PivotFunc) *Branch {
+	if len(ds) <= 1 || ds.notDistinct() {
+		leaf := &Branch{Datapoints: ds, depth: depth}
+		leaf.fit()
+		return leaf
+	}
+	axis := depth % len(ds[0].set)
+	pivot := pivotDef(ds, axis)
+	leftSet, rightSet := partition(ds, axis, pivot)
+	if len(leftSet) == 0 || len(rightSet) == 0 {
+		pivot = ds[0].set[axis]
+		for _, d := range ds {
+			if d.set[axis] > pivot {
+				pivot = d.set[axis]
+			}
+		}
+		leftSet, rightSet = partition(ds, axis, pivot)
+	}
+	branch := &Branch{
+		Datapoints: ds,
+		pivot:      pivot,
+		depth:      depth,
+		left:       grow(leftSet, depth+1, pivotDef),
+		right:      grow(rightSet, depth+1, pivotDef),
+	}
+	branch.fit()
+	return branch
+}
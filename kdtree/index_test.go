@@ -0,0 +1,163 @@
+package kdtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomDatapoints(r *rand.Rand, n, dims int) Datapoints {
+	ds := make(Datapoints, n, n)
+	for i := range ds {
+		f := make([]float64, dims, dims)
+		for j := range f {
+			f[j] = float64(r.Intn(50)) // coarse values, so repeats are common
+		}
+		ds[i] = NewDatapoint(i, f)
+	}
+	return ds
+}
+
+func sameDistances(target *Datapoint, got, want Datapoints) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if DistanceSq(target, got[i]) != DistanceSq(target, want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameMembers(got, want Datapoints) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	seen := make(map[*Datapoint]int)
+	for _, d := range got {
+		seen[d]++
+	}
+	for _, d := range want {
+		seen[d]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_Index_Tree_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(51))
+	for _, pivotDef := range []PivotFunc{LazyAverage, Median, Mean} {
+		for dims := 1; dims <= 3; dims++ {
+			ds := randomDatapoints(r, 300, dims)
+			tree, err := NewTree(ds[:200], pivotDef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			linear, _ := NewLinear(ds[:200])
+			for _, d := range ds[200:] {
+				if err := tree.Insert(d); err != nil {
+					t.Fatal(err)
+				}
+				linear.Insert(d)
+			}
+			for i := 0; i < 100; i += 2 {
+				if tree.Delete(ds[i]) != linear.Delete(ds[i]) {
+					t.Fatal(`Delete disagreed for `, ds[i])
+				}
+			}
+			if tree.Len() != linear.Len() || tree.Dims() != dims {
+				t.Fatal(`want: `, linear.Len(), dims, `
+				got: `, tree.Len(), tree.Dims())
+			}
+
+			for q := 0; q < 50; q++ {
+				target := randomDatapoints(r, 1, dims)[0]
+				got, want := tree.KNN(target, 7), linear.KNN(target, 7)
+				if !sameDistances(target, got, want) {
+					t.Error(`KNN want: `, want.PointsSetString(), `
+					got: `, got.PointsSetString())
+				}
+				if DistanceSq(target, tree.NN(target)) != DistanceSq(target, linear.NN(target)) {
+					t.Error(`NN disagreed for `, target)
+				}
+				bounds := make([]Range, dims)
+				for axis := range bounds {
+					lo := float64(r.Intn(50))
+					bounds[axis] = NewRange(lo, lo+float64(r.Intn(20)))
+				}
+				if !sameMembers(tree.Range(bounds), linear.Range(bounds)) {
+					t.Error(`Range disagreed for `, bounds)
+				}
+			}
+		}
+	}
+}
+
+func Test_Index_Tree_Empty_And_Errors(t *testing.T) {
+	tree, err := NewTree(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.NN(NewDatapoint(nil, []float64{1, 2})) != nil || tree.Len() != 0 {
+		t.Error(`empty Tree should hold nothing`)
+	}
+	p := NewDatapoint(nil, []float64{1, 2})
+	if err := tree.Insert(p); err != nil {
+		t.Error(err)
+	}
+	if err := tree.Insert(NewDatapoint(nil, []float64{1, 2, 3})); err != ErrDimensionMismatch {
+		t.Error(`want: `, ErrDimensionMismatch, `
+		got: `, err)
+	}
+	if err := tree.Insert(p); err != nil || tree.Len() != 1 || len(tree.Root().Datapoints) != 1 {
+		t.Error(`inserting a held Datapoint again should have no effect`)
+	}
+	if tree.KNN(nil, 1) != nil || tree.NN(nil) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	if !tree.Delete(p) || tree.Delete(p) || tree.Root() != nil {
+		t.Error(`Delete should remove the only Datapoint exactly once`)
+	}
+	if _, err := NewTree(Datapoints{p, NewDatapoint(nil, []float64{1})}, nil); err != ErrDimensionMismatch {
+		t.Error(`want: `, ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
+
+func Test_Index_Reinsert_And_Nil_Target(t *testing.T) {
+	p := NewDatapoint(nil, []float64{1, 2})
+	q := NewDatapoint(nil, []float64{3, 4})
+	tree, err := NewTree(Datapoints{p, q, p}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linear, err := NewLinear(Datapoints{p, q, p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := NewNodeTree(Datapoints{p, q, p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range []SpatialIndex{tree, linear, nodes} {
+		if index.Len() != 2 {
+			t.Errorf(`%T: want: 2 Datapoints, got: %d`, index, index.Len())
+		}
+		if err := index.Insert(q); err != nil || index.Len() != 2 {
+			t.Errorf(`%T: inserting a held Datapoint again should have no effect`, index)
+		}
+		if got := index.KNN(p, 3); len(got) != 2 {
+			t.Errorf(`%T: want: 2 neighbours, got: %v`, index, got)
+		}
+		if index.NN(nil) != nil || index.KNN(nil, 1) != nil {
+			t.Errorf(`%T: a nil target should find nothing`, index)
+		}
+		if !index.Delete(q) || index.Delete(q) || index.Len() != 1 {
+			t.Errorf(`%T: Delete should remove the Datapoint exactly once`, index)
+		}
+	}
+}
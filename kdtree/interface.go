@@ -9,3 +9,20 @@ type Importable interface {
 type Exportable interface {
 	FromDatapoint(*Datapoint)
 }
+
+// SpatialIndex is the interface implemented by every point-based spatial
+// structure, so that applications can swap one structure for another.
+// Datapoints are identified by pointer: Delete removes the exact *Datapoint
+// which was inserted, not any Datapoint that happens to be EqualTo it, and
+// inserting a *Datapoint which is already held has no effect (constructors
+// likewise hold each one once). NN and KNN return nil for a nil target, or
+// one whose dimensionality differs from the Datapoints held.
+type SpatialIndex interface {
+	Insert(*Datapoint) error
+	Delete(*Datapoint) bool
+	NN(target *Datapoint) *Datapoint
+	KNN(target *Datapoint, k int) Datapoints
+	Range(bounds []Range) Datapoints
+	Len() int
+	Dims() int
+}
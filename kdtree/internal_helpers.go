@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
 )
 
 func randomFloatInRange(min, max float64) float64 {
@@ -33,6 +35,64 @@ func sumValuesAlongAxis(ds Datapoints, axis int) float64 {
 	return sum
 }
 
+// checkDims returns a copy of ds holding each Datapoint once, or
+// ErrDimensionMismatch if any is nil or differs in dimensionality.
+func checkDims(ds Datapoints) (Datapoints, error) {
+	points := make(Datapoints, 0, len(ds))
+	seen := make(map[*Datapoint]struct{}, len(ds))
+	for _, d := range ds {
+		if d == nil || len(d.set) != len(ds[0].set) {
+			return nil, ErrDimensionMismatch
+		}
+		if _, ok := seen[d]; !ok {
+			seen[d] = struct{}{}
+			points = append(points, d)
+		}
+	}
+	return points, nil
+}
+
+func partition(ds Datapoints, axis int, pivot float64) (leftSet, rightSet Datapoints) {
+	leftSet, rightSet = make(Datapoints, 0, len(ds)), make(Datapoints, 0, len(ds))
+	for _, d := range ds {
+		if d.set[axis] < pivot {
+			leftSet = append(leftSet, d)
+		} else {
+			rightSet = append(rightSet, d)
+		}
+	}
+	return leftSet, rightSet
+}
+
+// withoutNil drops the nil placeholders which Build leaves in empty leaves.
+func withoutNil(ds Datapoints) Datapoints {
+	kept := make(Datapoints, 0, len(ds))
+	for _, d := range ds {
+		if d != nil {
+			kept = append(kept, d)
+		}
+	}
+	return kept
+}
+
+// remove deletes the first occurrence of d (by pointer), preserving order.
+func (ds Datapoints) remove(d *Datapoint) Datapoints {
+	for i, p := range ds {
+		if p == d {
+			return append(ds[:i], ds[i+1:]...)
+		}
+	}
+	return ds
+}
+
+func candidatesToDatapoints(cs []nearest.Candidate) Datapoints {
+	ds := make(Datapoints, len(cs), len(cs))
+	for i := range cs {
+		ds[i] = cs[i].Item.(*Datapoint)
+	}
+	return ds
+}
+
 const (
 	void = `()`
 	sc   = `;`
@@ -0,0 +1,104 @@
+package kdtree
+
+import "github.com/benjamin-rood/goeometric/internal/nearest"
+
+// Linear is a brute-force SpatialIndex which answers every query by scanning
+// all of its Datapoints. It is the reference against which the other
+// structures are tested, and is competitive for very small sets.
+type Linear struct {
+	points Datapoints
+	dims   int
+	held   map[*Datapoint]struct{}
+}
+
+var _ SpatialIndex = (*Linear)(nil)
+
+// NewLinear constructs a Linear index over a copy of ds.
+// All Datapoints must share the same dimensionality.
+func NewLinear(ds Datapoints) (*Linear, error) {
+	points, err := checkDims(ds)
+	if err != nil {
+		return nil, err
+	}
+	l := &Linear{points: points, held: make(map[*Datapoint]struct{}, len(points))}
+	for _, d := range points {
+		l.held[d] = struct{}{}
+	}
+	if len(points) > 0 {
+		l.dims = len(points[0].set)
+	}
+	return l, nil
+}
+
+// Len returns the number of Datapoints held.
+func (l *Linear) Len() int {
+	return len(l.points)
+}
+
+// Dims returns the dimensionality of the Datapoints held,
+// or 0 if nothing has been inserted yet.
+func (l *Linear) Dims() int {
+	return l.dims
+}
+
+// Insert appends a Datapoint.
+// Inserting a Datapoint which is already held has no effect.
+func (l *Linear) Insert(d *Datapoint) error {
+	if d == nil {
+		return ErrDimensionMismatch
+	}
+	if _, ok := l.held[d]; ok {
+		return nil
+	}
+	if len(l.points) == 0 && l.dims == 0 {
+		l.dims = len(d.set)
+	}
+	if len(d.set) != l.dims {
+		return ErrDimensionMismatch
+	}
+	l.points = append(l.points, d)
+	l.held[d] = struct{}{}
+	return nil
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+func (l *Linear) Delete(d *Datapoint) bool {
+	if _, ok := l.held[d]; !ok {
+		return false
+	}
+	delete(l.held, d)
+	l.points = l.points.remove(d)
+	return true
+}
+
+// NN returns the nearest neighbour of the target, or nil if the index is empty.
+func (l *Linear) NN(target *Datapoint) *Datapoint {
+	ds := l.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k nearest neighbours of the target, nearest first.
+func (l *Linear) KNN(target *Datapoint, k int) Datapoints {
+	if len(l.points) == 0 || k <= 0 || target == nil || len(target.set) != l.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	for _, d := range l.points {
+		set.Push(d, DistanceSq(target, d))
+	}
+	return candidatesToDatapoints(set.Sorted())
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (l *Linear) Range(bounds []Range) Datapoints {
+	var found Datapoints
+	for _, d := range l.points {
+		if InBounds(d, bounds) {
+			found = append(found, d)
+		}
+	}
+	return found
+}
@@ -0,0 +1,248 @@
+package kdtree
+
+import "github.com/benjamin-rood/goeometric/internal/nearest"
+
+// Node is a k-d tree node in the traditional tree/node structure, where every
+// Node holds exactly one Datapoint and splits space at that Datapoint's value
+// along the axis for its depth: Datapoints less than it go left, the rest right.
+type Node struct {
+	*Datapoint
+	depth       int
+	left, right *Node
+}
+
+// NodeTree is a k-d tree with values at the nodes, implementing SpatialIndex.
+// Compared with Tree (values at the leaves), each insertion and deletion only
+// touches a single root-to-node path and no slices are kept per Branch, so it
+// is the better choice under frequent updates. Tree is the better choice when
+// whole buckets of nearby Datapoints are wanted, as with ANN, since every
+// Branch already holds the Datapoints beneath it.
+type NodeTree struct {
+	root *Node
+	dims int
+	size int
+	held map[*Datapoint]struct{}
+}
+
+var _ SpatialIndex = (*NodeTree)(nil)
+
+// NewNodeTree constructs a balanced NodeTree by splitting at the median along
+// each axis in turn. All Datapoints must share the same dimensionality.
+func NewNodeTree(ds Datapoints) (*NodeTree, error) {
+	points, err := checkDims(ds)
+	if err != nil {
+		return nil, err
+	}
+	nt := &NodeTree{size: len(points), held: make(map[*Datapoint]struct{}, len(points))}
+	for _, d := range points {
+		nt.held[d] = struct{}{}
+	}
+	if len(points) > 0 {
+		nt.dims = len(points[0].set)
+		nt.root = buildNodes(points, 0, nt.dims)
+	}
+	return nt, nil
+}
+
+func buildNodes(ds Datapoints, depth, dims int) *Node {
+	if len(ds) == 0 {
+		return nil
+	}
+	axis := depth % dims
+	By(Comparator(axis)).Sort(ds)
+	mid := len(ds) / 2
+	for mid > 0 && ds[mid-1].set[axis] == ds[mid].set[axis] {
+		mid-- // equal values must all lie to the right
+	}
+	return &Node{
+		Datapoint: ds[mid],
+		depth:     depth,
+		left:      buildNodes(ds[:mid], depth+1, dims),
+		right:     buildNodes(ds[mid+1:], depth+1, dims),
+	}
+}
+
+// Root returns the root Node, nil when empty.
+func (nt *NodeTree) Root() *Node {
+	return nt.root
+}
+
+// Len returns the number of Datapoints held.
+func (nt *NodeTree) Len() int {
+	return nt.size
+}
+
+// Dims returns the dimensionality of the Datapoints held,
+// or 0 if nothing has been inserted yet.
+func (nt *NodeTree) Dims() int {
+	return nt.dims
+}
+
+// MaxDepth returns the depth of the deepest Node from the input node as 'root'
+func (node *Node) MaxDepth() int {
+	if node == nil {
+		return 0
+	}
+	return max(node.depth, max(node.left.MaxDepth(), node.right.MaxDepth()))
+}
+
+// Insert adds a Datapoint as a new leaf Node.
+// Inserting a Datapoint which is already held has no effect.
+func (nt *NodeTree) Insert(d *Datapoint) error {
+	if d == nil {
+		return ErrDimensionMismatch
+	}
+	if _, ok := nt.held[d]; ok {
+		return nil
+	}
+	if nt.size == 0 && nt.dims == 0 {
+		nt.dims = len(d.set)
+	}
+	if len(d.set) != nt.dims {
+		return ErrDimensionMismatch
+	}
+	nt.size++
+	nt.held[d] = struct{}{}
+	link, depth := &nt.root, 0
+	for *link != nil {
+		node := *link
+		if d.set[node.depth%nt.dims] < node.set[node.depth%nt.dims] {
+			link = &node.left
+		} else {
+			link = &node.right
+		}
+		depth++
+	}
+	*link = &Node{Datapoint: d, depth: depth}
+	return nil
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+// A deleted internal Node is replaced by the Datapoint with the minimum value
+// along its axis from the right subtree (moving the left subtree across
+// first when there is no right subtree), which is then deleted recursively.
+func (nt *NodeTree) Delete(d *Datapoint) bool {
+	if _, ok := nt.held[d]; !ok {
+		return false
+	}
+	var found bool
+	nt.root, found = nt.delete(nt.root, d)
+	if found {
+		nt.size--
+		delete(nt.held, d)
+	}
+	return found
+}
+
+func (nt *NodeTree) delete(node *Node, d *Datapoint) (*Node, bool) {
+	if node == nil {
+		return nil, false
+	}
+	axis := node.depth % nt.dims
+	if node.Datapoint != d {
+		var found bool
+		if d.set[axis] < node.set[axis] {
+			node.left, found = nt.delete(node.left, d)
+		} else {
+			node.right, found = nt.delete(node.right, d)
+		}
+		return node, found
+	}
+
+	switch {
+	case node.right != nil:
+		successor := nt.findMin(node.right, axis)
+		node.Datapoint = successor.Datapoint
+		node.right, _ = nt.delete(node.right, successor.Datapoint)
+	case node.left != nil:
+		successor := nt.findMin(node.left, axis)
+		node.Datapoint = successor.Datapoint
+		node.right, _ = nt.delete(node.left, successor.Datapoint)
+		node.left = nil
+	default:
+		return nil, true
+	}
+	return node, true
+}
+
+// findMin returns the Node holding the minimum value along axis in the subtree.
+func (nt *NodeTree) findMin(node *Node, axis int) *Node {
+	if node == nil {
+		return nil
+	}
+	if node.depth%nt.dims == axis {
+		if node.left == nil {
+			return node
+		}
+		return nt.findMin(node.left, axis)
+	}
+	best := node
+	for _, candidate := range []*Node{nt.findMin(node.left, axis), nt.findMin(node.right, axis)} {
+		if candidate != nil && candidate.set[axis] < best.set[axis] {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// NN returns the exact nearest neighbour of the target, or nil if the NodeTree is empty.
+func (nt *NodeTree) NN(target *Datapoint) *Datapoint {
+	ds := nt.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k exact nearest neighbours of the target, nearest first.
+func (nt *NodeTree) KNN(target *Datapoint, k int) Datapoints {
+	if nt.root == nil || k <= 0 || target == nil || len(target.set) != nt.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	nt.knn(nt.root, target, set)
+	return candidatesToDatapoints(set.Sorted())
+}
+
+func (nt *NodeTree) knn(node *Node, target *Datapoint, set *nearest.Set) {
+	if node == nil {
+		return
+	}
+	set.Push(node.Datapoint, DistanceSq(target, node.Datapoint))
+	axis := node.depth % nt.dims
+	diff := target.set[axis] - node.set[axis]
+	near, far := node.left, node.right
+	if diff >= 0 {
+		near, far = far, near
+	}
+	nt.knn(near, target, set)
+	if diff*diff < set.Worst() {
+		nt.knn(far, target, set)
+	}
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (nt *NodeTree) Range(bounds []Range) Datapoints {
+	if nt.root == nil || len(bounds) != nt.dims {
+		return nil
+	}
+	var found Datapoints
+	nt.rangeSearch(nt.root, bounds, &found)
+	return found
+}
+
+func (nt *NodeTree) rangeSearch(node *Node, bounds []Range, found *Datapoints) {
+	if node == nil {
+		return
+	}
+	if InBounds(node.Datapoint, bounds) {
+		*found = append(*found, node.Datapoint)
+	}
+	axis := node.depth % nt.dims
+	if bounds[axis].min < node.set[axis] {
+		nt.rangeSearch(node.left, bounds, found)
+	}
+	if bounds[axis].max >= node.set[axis] {
+		nt.rangeSearch(node.right, bounds, found)
+	}
+}
@@ -0,0 +1,126 @@
+package kdtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func Test_NodeTree_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(55))
+	for dims := 1; dims <= 3; dims++ {
+		ds := randomDatapoints(r, 400, dims)
+		nt, err := NewNodeTree(ds[:100])
+		if err != nil {
+			t.Fatal(err)
+		}
+		linear, _ := NewLinear(ds[:100])
+		for _, d := range ds[100:] {
+			nt.Insert(d)
+			linear.Insert(d)
+		}
+		for _, i := range r.Perm(len(ds))[:250] {
+			if nt.Delete(ds[i]) != linear.Delete(ds[i]) {
+				t.Fatal(`Delete disagreed for `, ds[i])
+			}
+		}
+		if nt.Len() != linear.Len() {
+			t.Fatal(`want: `, linear.Len(), `
+			got: `, nt.Len())
+		}
+
+		for q := 0; q < 50; q++ {
+			target := randomDatapoints(r, 1, dims)[0]
+			got, want := nt.KNN(target, 5), linear.KNN(target, 5)
+			if !sameDistances(target, got, want) {
+				t.Error(`KNN want: `, want.PointsSetString(), `
+				got: `, got.PointsSetString())
+			}
+			bounds := make([]Range, dims)
+			for axis := range bounds {
+				lo := float64(r.Intn(50))
+				bounds[axis] = NewRange(lo, lo+float64(r.Intn(20)))
+			}
+			if !sameMembers(nt.Range(bounds), linear.Range(bounds)) {
+				t.Error(`Range disagreed for `, bounds)
+			}
+		}
+	}
+}
+
+func Test_NodeTree_MaxDepth(t *testing.T) {
+	nt, _ := NewNodeTree(dps1)
+	want := 2
+	got := nt.Root().MaxDepth()
+	if got != want {
+		t.Error(`want: `, want, `
+		got: `, got)
+	}
+}
+
+// The benchmarks below compare NodeTree with Tree on the same workloads.
+// NodeTree builds faster and is an order of magnitude cheaper to update,
+// because Tree's Delete must scan the Datapoints of every Branch on the path;
+// exact KNN performs about the same on both.
+
+func benchmarkDatapoints(n int) Datapoints {
+	r := rand.New(rand.NewSource(1))
+	ds := make(Datapoints, n)
+	for i := range ds {
+		ds[i] = NewDatapoint(nil, []float64{r.Float64(), r.Float64(), r.Float64()})
+	}
+	return ds
+}
+
+func Benchmark_Tree_Build(b *testing.B) {
+	ds := benchmarkDatapoints(10000)
+	for i := 0; i < b.N; i++ {
+		NewTree(ds, Median)
+	}
+}
+
+func Benchmark_NodeTree_Build(b *testing.B) {
+	ds := benchmarkDatapoints(10000)
+	for i := 0; i < b.N; i++ {
+		NewNodeTree(ds)
+	}
+}
+
+func Benchmark_Tree_KNN(b *testing.B) {
+	tree, _ := NewTree(benchmarkDatapoints(10000), Median)
+	targets := benchmarkDatapoints(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.KNN(targets[i%len(targets)], 8)
+	}
+}
+
+func Benchmark_NodeTree_KNN(b *testing.B) {
+	nt, _ := NewNodeTree(benchmarkDatapoints(10000))
+	targets := benchmarkDatapoints(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nt.KNN(targets[i%len(targets)], 8)
+	}
+}
+
+func Benchmark_Tree_Insert_Delete(b *testing.B) {
+	ds := benchmarkDatapoints(10000)
+	tree, _ := NewTree(ds, Median)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := ds[i%len(ds)]
+		tree.Delete(d)
+		tree.Insert(d)
+	}
+}
+
+func Benchmark_NodeTree_Insert_Delete(b *testing.B) {
+	ds := benchmarkDatapoints(10000)
+	nt, _ := NewNodeTree(ds)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := ds[i%len(ds)]
+		nt.Delete(d)
+		nt.Insert(d)
+	}
+}
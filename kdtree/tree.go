@@ -13,6 +13,7 @@ type Branch struct {
 	pivot       float64
 	depth       int
 	left, right *Branch
+	bounds      []Range // the box around Datapoints, see Bounds
 }
 
 // PivotFunc calculates the pivot value
@@ -54,10 +55,14 @@ func Build(ds Datapoints, depth int, pivotDef PivotFunc) *Branch {
 
 	sz := len(ds)
 	if sz <= 1 {
-		return &Branch{ds[:1], 0, depth, nil, nil}
+		leaf := &Branch{Datapoints: ds[:1], depth: depth}
+		leaf.fit()
+		return leaf
 	}
 	if ds.notDistinct() {
-		return &Branch{ds, 0, depth, nil, nil}
+		leaf := &Branch{Datapoints: ds, depth: depth}
+		leaf.fit()
+		return leaf
 	}
 
 	if pivotDef == nil {
@@ -88,6 +93,7 @@ func Build(ds Datapoints, depth int, pivotDef PivotFunc) *Branch {
 
 	branch.left = Build(leftSet, depth+1, pivotDef)
 	branch.right = Build(rightSet, depth+1, pivotDef)
+	branch.fit()
 	return &branch
 }
 
@@ -99,6 +105,34 @@ func (branch *Branch) MaxDepth() int {
 	return max(branch.depth, max(branch.left.MaxDepth(), branch.right.MaxDepth()))
 }
 
+// Left returns the child Branch holding the Datapoints less than the pivot,
+// nil at a leaf.
+func (branch *Branch) Left() *Branch {
+	return branch.left
+}
+
+// Right returns the child Branch holding the Datapoints not less than the
+// pivot, nil at a leaf.
+func (branch *Branch) Right() *Branch {
+	return branch.right
+}
+
+// IsLeaf reports whether the Branch has no children.
+func (branch *Branch) IsLeaf() bool {
+	return branch.left == nil && branch.right == nil
+}
+
+// Pivot returns the value at which the Branch splits its Datapoints, along
+// the axis given by its Depth modulo their dimensionality.
+func (branch *Branch) Pivot() float64 {
+	return branch.pivot
+}
+
+// Depth returns the depth of the Branch, the root being at depth 0.
+func (branch *Branch) Depth() int {
+	return branch.depth
+}
+
 // ANN will very rapidly return the **approximate nearest neighbour** Datapoint
 // in a given k-d tree branch.
 // If we consider the accuracy of ANN as the spatial distance, d, from the exact
@@ -172,6 +206,35 @@ type Range struct {
 	min, max float64
 }
 
+// NewRange constructs the closed range [min,max] along a single axis.
+func NewRange(min, max float64) Range {
+	return Range{min, max}
+}
+
+// Min returns the lower bound of the range.
+func (r Range) Min() float64 {
+	return r.min
+}
+
+// Max returns the upper bound of the range.
+func (r Range) Max() float64 {
+	return r.max
+}
+
+// InBounds reports whether the Datapoint lies within every axis of the bounds
+// (inclusive). Bounds of the wrong dimensionality never contain the Datapoint.
+func InBounds(d *Datapoint, bounds []Range) bool {
+	if d == nil || len(bounds) != len(d.set) {
+		return false
+	}
+	for axis, r := range bounds {
+		if d.set[axis] < r.min || d.set[axis] > r.max {
+			return false
+		}
+	}
+	return true
+}
+
 // RangeQuery returns all Datapoints in a specified bounded area
 func RangeQuery(branch *Branch, bounds []Range) Datapoints {
 	if branch == nil {
@@ -236,10 +299,10 @@ func buildDebug(ds Datapoints, depth int, pivotDef PivotFunc) *Branch {
 	fmt.Println(ds.PointsSetString())
 	time.Sleep(250 * time.Millisecond)
 	if sz <= 1 {
-		return &Branch{ds[:1], 0, depth, nil, nil}
+		return &Branch{Datapoints: ds[:1], depth: depth}
 	}
 	if ds.notDistinct() {
-		return &Branch{ds, 0, depth, nil, nil}
+		return &Branch{Datapoints: ds, depth: depth}
 	}
 
 	if pivotDef == nil {
@@ -64,6 +64,34 @@ func Test_Tree_Branch_MaxDepth(t *testing.T) {
 	}
 }
 
+func Test_Tree_Branch_Accessors(t *testing.T) {
+	tree := Build(dps1, 0, Median)
+	var walk func(branch *Branch)
+	walk = func(branch *Branch) {
+		if branch.IsLeaf() {
+			return
+		}
+		axis := branch.Depth() % dps1[0].Dimensionality()
+		for _, d := range branch.Left().Datapoints {
+			if d.At(axis) >= branch.Pivot() {
+				t.Error(d, ` should lie right of the pivot `, branch.Pivot())
+			}
+		}
+		for _, d := range branch.Right().Datapoints {
+			if d.At(axis) < branch.Pivot() {
+				t.Error(d, ` should lie left of the pivot `, branch.Pivot())
+			}
+		}
+		if branch.Left().Depth() != branch.Depth()+1 {
+			t.Error(`want: `, branch.Depth()+1, `
+			got: `, branch.Left().Depth())
+		}
+		walk(branch.Left())
+		walk(branch.Right())
+	}
+	walk(tree)
+}
+
 func Test_Tree_Branch_Build_Pivot_Mean(t *testing.T) {
 	tree := Build(dps3, 0, Mean)
 	want, err := ioutil.ReadFile("test_fixtures/branch_build_pivot_mean.json")
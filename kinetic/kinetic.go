@@ -0,0 +1,423 @@
+package kinetic
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// ErrTimeReversed is returned when asked to advance to a time before the current time.
+var ErrTimeReversed = errors.New("kinetic: cannot advance to an earlier time")
+
+// Tree is a kinetic k-d tree over points moving linearly in time.
+//
+// Each Datapoint's set is taken as its position at time 0, and it moves with
+// a constant velocity, so its position at time t is set + velocity*t.
+// The tree stores one point per node, splitting at that point's current
+// position along the axis for its depth. For every point and each of its
+// ancestors there is a certificate that the point lies on the correct side of
+// the ancestor; the failure time of each certificate is kept in an event
+// queue. Advancing time processes the failures in order, repairing the tree
+// locally: a point which crosses an ancestor is reinserted beneath it, and
+// only the subtree it leaves behind is rebuilt, which is empty for a leaf.
+// A reinsertion which leaves too long a path rebuilds the smallest subtree
+// that restores the bound, as in a scapegoat tree. Between events the
+// tree is a valid k-d tree for the current positions, so range and nearest
+// neighbour queries are answered directly.
+type Tree struct {
+	root    *node
+	dims    int
+	size    int
+	now     float64
+	events  eventQueue
+	movers  map[*kdtree.Datapoint]*mover
+	handled int
+	rebuilt int
+}
+
+type node struct {
+	m           *mover
+	depth       int
+	parent      *node
+	left, right *node
+}
+
+type mover struct {
+	d                *kdtree.Datapoint
+	origin, velocity []float64
+	node             *node
+	version          int
+}
+
+type event struct {
+	time     float64
+	m        *mover
+	ancestor *node
+	left     bool // whether m should lie to the left of the ancestor
+	version  int
+}
+
+// New constructs a kinetic Tree at time 0, where velocities[i] is the velocity of ds[i].
+func New(ds kdtree.Datapoints, velocities [][]float64) (*Tree, error) {
+	if len(velocities) != len(ds) {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	t := &Tree{movers: make(map[*kdtree.Datapoint]*mover, len(ds))}
+	ms := make([]*mover, len(ds))
+	for i, d := range ds {
+		if d == nil || len(velocities[i]) != d.Dimensionality() || d.Dimensionality() != ds[0].Dimensionality() {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+		v := make([]float64, len(velocities[i]))
+		copy(v, velocities[i])
+		ms[i] = &mover{d: d, origin: d.Set(), velocity: v}
+		t.movers[d] = ms[i]
+	}
+	t.size = len(ms)
+	if t.size == 0 {
+		return t, nil
+	}
+	t.dims = ds[0].Dimensionality()
+	t.root = t.build(ms, 0, nil)
+	for _, m := range ms {
+		t.certify(m)
+	}
+	return t, nil
+}
+
+// Len returns the number of moving points.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Dims returns the dimensionality of the moving points.
+func (t *Tree) Dims() int {
+	return t.dims
+}
+
+// Now returns the current time of the Tree.
+func (t *Tree) Now() float64 {
+	return t.now
+}
+
+// Events returns the number of certificate failures handled so far.
+func (t *Tree) Events() int {
+	return t.handled
+}
+
+// Position returns the position of a moving Datapoint at the current time,
+// or nil if it is not held in the Tree.
+func (t *Tree) Position(d *kdtree.Datapoint) []float64 {
+	m := t.movers[d]
+	if m == nil {
+		return nil
+	}
+	p := make([]float64, t.dims)
+	for axis := range p {
+		p[axis] = m.at(axis, t.now)
+	}
+	return p
+}
+
+// Advance moves the Tree forward to time to, processing every certificate
+// failure up to and including it in order.
+func (t *Tree) Advance(to float64) error {
+	if to < t.now {
+		return ErrTimeReversed
+	}
+	for len(t.events) > 0 && t.events[0].time <= to {
+		e := heap.Pop(&t.events).(*event)
+		if e.version != e.m.version {
+			continue // superseded by a repair
+		}
+		if e.time > t.now {
+			t.now = e.time
+		}
+		if when := t.failure(e.m, e.ancestor, e.left); when > t.now {
+			e.time = when // not yet failed, within rounding
+			heap.Push(&t.events, e)
+			continue
+		}
+		t.handled++
+		t.repair(e.m, e.ancestor)
+	}
+	t.now = to
+	return nil
+}
+
+// repair restores the k-d tree invariant after m has crossed its ancestor.
+// m is taken out of its node, whose other descendants are rebuilt in place,
+// and reinserted beneath the ancestor on the side it has crossed to.
+func (t *Tree) repair(m *mover, ancestor *node) {
+	n := m.node
+	var rest []*mover
+	collect(n.left, &rest)
+	collect(n.right, &rest)
+	t.replace(n, t.build(rest, n.depth, n.parent))
+	t.rebuilt += len(rest)
+	for _, other := range rest {
+		t.certify(other)
+	}
+
+	link, parent := &ancestor.left, ancestor
+	if t.compare(m, ancestor.m, ancestor.depth%t.dims) >= 0 {
+		link = &ancestor.right
+	}
+	for *link != nil {
+		parent = *link
+		if t.compare(m, parent.m, parent.depth%t.dims) < 0 {
+			link = &parent.left
+		} else {
+			link = &parent.right
+		}
+	}
+	n = &node{m: m, depth: parent.depth + 1, parent: parent}
+	m.node = n
+	*link = n
+	if tooDeep(n.depth, t.size) {
+		t.rebuild(t.scapegoat(n))
+		return
+	}
+	t.certify(m)
+}
+
+// scapegoat returns the lowest ancestor of the leaf n which it lies too deep
+// beneath for the size of the ancestor's subtree. Rebuilding only that
+// subtree restores the bound on depth, as in a scapegoat tree, and costs
+// O(log n) amortised over the reinsertions.
+func (t *Tree) scapegoat(n *node) *node {
+	size := 1
+	for child, a := n, n.parent; a != nil; child, a = a, a.parent {
+		sibling := a.left
+		if sibling == child {
+			sibling = a.right
+		}
+		var ms []*mover
+		collect(sibling, &ms)
+		size += 1 + len(ms)
+		if tooDeep(n.depth-a.depth, size) {
+			return a
+		}
+	}
+	return t.root
+}
+
+// tooDeep reports whether a path of the given length is too long for a
+// subtree of the given size.
+func tooDeep(length, size int) bool {
+	return length > 2*int(math.Log2(float64(size)))+2
+}
+
+// Rebuilt returns the number of nodes rebuilt by repairs so far.
+func (t *Tree) Rebuilt() int {
+	return t.rebuilt
+}
+
+// rebuild replaces the subtree rooted at n with a balanced one for the
+// current positions, and recomputes the certificates of its points.
+func (t *Tree) rebuild(n *node) {
+	var ms []*mover
+	collect(n, &ms)
+	t.replace(n, t.build(ms, n.depth, n.parent))
+	t.rebuilt += len(ms)
+	for _, m := range ms {
+		t.certify(m)
+	}
+}
+
+// replace links fresh into the tree in place of n.
+func (t *Tree) replace(n, fresh *node) {
+	switch {
+	case n.parent == nil:
+		t.root = fresh
+	case n.parent.left == n:
+		n.parent.left = fresh
+	default:
+		n.parent.right = fresh
+	}
+}
+
+// collect appends the movers in the subtree rooted at n.
+func collect(n *node, ms *[]*mover) {
+	if n != nil {
+		*ms = append(*ms, n.m)
+		collect(n.left, ms)
+		collect(n.right, ms)
+	}
+}
+
+func (t *Tree) build(ms []*mover, depth int, parent *node) *node {
+	if len(ms) == 0 {
+		return nil
+	}
+	axis := depth % t.dims
+	sort.Slice(ms, func(i, j int) bool {
+		return t.compare(ms[i], ms[j], axis) < 0
+	})
+	mid := len(ms) / 2
+	for mid > 0 && t.compare(ms[mid-1], ms[mid], axis) == 0 {
+		mid--
+	}
+	n := &node{m: ms[mid], depth: depth, parent: parent}
+	ms[mid].node = n
+	n.left = t.build(ms[:mid], depth+1, n)
+	n.right = t.build(ms[mid+1:], depth+1, n)
+	return n
+}
+
+// certify schedules the failure of each of m's certificates, superseding any
+// already queued.
+func (t *Tree) certify(m *mover) {
+	m.version++
+	child := m.node
+	for a := child.parent; a != nil; child, a = a, a.parent {
+		left := a.left == child
+		if when := t.failure(m, a, left); !math.IsInf(when, 1) {
+			heap.Push(&t.events, &event{when, m, a, left, m.version})
+		}
+	}
+}
+
+// failure returns the time at which m stops lying on the given side of the
+// ancestor: now if it already has, +Inf if it never will.
+func (t *Tree) failure(m *mover, ancestor *node, left bool) float64 {
+	axis := ancestor.depth % t.dims
+	c := t.compare(m, ancestor.m, axis)
+	gap := ancestor.m.at(axis, t.now) - m.at(axis, t.now)
+	closing := ancestor.m.velocity[axis] - m.velocity[axis]
+	if !left {
+		c, gap, closing = -c, -gap, -closing
+	}
+	if c > 0 {
+		return t.now
+	}
+	if closing >= 0 {
+		return math.Inf(1)
+	}
+	return t.now + math.Max(gap, 0)/-closing
+}
+
+// compare orders two movers along an axis at the current time. Positions
+// within rounding of each other are ordered by velocity, so that a point
+// which has just crossed a split is placed on the side it is moving into.
+func (t *Tree) compare(m, n *mover, axis int) int {
+	x, y := m.at(axis, t.now), n.at(axis, t.now)
+	tolerance := 1e-9 * (1 + math.Max(math.Abs(x), math.Abs(y)))
+	switch {
+	case x < y-tolerance:
+		return -1
+	case x > y+tolerance:
+		return 1
+	case m.velocity[axis] < n.velocity[axis]:
+		return -1
+	case m.velocity[axis] > n.velocity[axis]:
+		return 1
+	}
+	return 0
+}
+
+func (m *mover) at(axis int, t float64) float64 {
+	return m.origin[axis] + m.velocity[axis]*t
+}
+
+func (m *mover) distanceSq(target *kdtree.Datapoint, t float64) float64 {
+	var sq float64
+	for axis := range m.origin {
+		d := m.at(axis, t) - target.At(axis)
+		sq += d * d
+	}
+	return sq
+}
+
+// NN returns the point nearest the target at the current time, or nil if the Tree is empty.
+func (t *Tree) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := t.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k points nearest the target at the current time, nearest first.
+func (t *Tree) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if t.root == nil || k <= 0 || target.Dimensionality() != t.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	t.knn(t.root, target, set)
+	sorted := set.Sorted()
+	ds := make(kdtree.Datapoints, len(sorted))
+	for i := range sorted {
+		ds[i] = sorted[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
+
+func (t *Tree) knn(n *node, target *kdtree.Datapoint, set *nearest.Set) {
+	if n == nil {
+		return
+	}
+	set.Push(n.m.d, n.m.distanceSq(target, t.now))
+	axis := n.depth % t.dims
+	diff := target.At(axis) - n.m.at(axis, t.now)
+	near, far := n.left, n.right
+	if diff >= 0 {
+		near, far = far, near
+	}
+	t.knn(near, target, set)
+	if diff*diff <= set.Worst() { // points level with the split may lie on either side
+		t.knn(far, target, set)
+	}
+}
+
+// Range returns every point lying within the bounds (inclusive) at the current time.
+func (t *Tree) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	if len(bounds) != t.dims {
+		return nil
+	}
+	var found kdtree.Datapoints
+	t.rangeSearch(t.root, bounds, &found)
+	return found
+}
+
+func (t *Tree) rangeSearch(n *node, bounds []kdtree.Range, found *kdtree.Datapoints) {
+	if n == nil {
+		return
+	}
+	inside := true
+	for axis, r := range bounds {
+		if x := n.m.at(axis, t.now); x < r.Min() || x > r.Max() {
+			inside = false
+			break
+		}
+	}
+	if inside {
+		*found = append(*found, n.m.d)
+	}
+	axis := n.depth % t.dims
+	split := n.m.at(axis, t.now)
+	if bounds[axis].Min() <= split {
+		t.rangeSearch(n.left, bounds, found)
+	}
+	if bounds[axis].Max() >= split {
+		t.rangeSearch(n.right, bounds, found)
+	}
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int            { return len(q) }
+func (q eventQueue) Less(i, j int) bool  { return q[i].time < q[j].time }
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[:n-1]
+	return x
+}
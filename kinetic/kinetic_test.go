@@ -0,0 +1,151 @@
+package kinetic
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// traffic places vehicles on a 2-D plane, each travelling in a straight line.
+func traffic(r *rand.Rand, n int) (kdtree.Datapoints, [][]float64) {
+	ds := make(kdtree.Datapoints, n)
+	vs := make([][]float64, n)
+	for i := range ds {
+		ds[i] = kdtree.NewDatapoint(i, []float64{r.Float64() * 100, r.Float64() * 100})
+		vs[i] = []float64{r.NormFloat64() * 5, r.NormFloat64() * 5}
+		if i%10 == 0 {
+			vs[i] = vs[0] // convoys share a velocity
+		}
+	}
+	return ds, vs
+}
+
+// verify checks the k-d tree invariant for every node at the current time.
+func verify(t *testing.T, tree *Tree) {
+	var walk func(n *node) []*mover
+	walk = func(n *node) []*mover {
+		if n == nil {
+			return nil
+		}
+		left, right := walk(n.left), walk(n.right)
+		axis := n.depth % tree.dims
+		for _, m := range left {
+			if tree.compare(m, n.m, axis) > 0 {
+				t.Fatal(`time `, tree.Now(), `: left descendant beyond its ancestor`)
+			}
+		}
+		for _, m := range right {
+			if tree.compare(m, n.m, axis) < 0 {
+				t.Fatal(`time `, tree.Now(), `: right descendant before its ancestor`)
+			}
+		}
+		return append(append(left, right...), n.m)
+	}
+	if got := len(walk(tree.root)); got != tree.Len() {
+		t.Fatal(`want: `, tree.Len(), ` points
+		got: `, got)
+	}
+}
+
+func Test_Kinetic_Tree_Queries_Over_Time(t *testing.T) {
+	r := rand.New(rand.NewSource(57))
+	ds, vs := traffic(r, 300)
+	tree, err := New(ds, vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for step := 1; step <= 20; step++ {
+		if err := tree.Advance(float64(step) * 0.5); err != nil {
+			t.Fatal(err)
+		}
+		verify(t, tree)
+
+		// brute force over the current positions
+		now := make(kdtree.Datapoints, len(ds))
+		for i, d := range ds {
+			now[i] = kdtree.NewDatapoint(d, tree.Position(d))
+		}
+		linear, _ := kdtree.NewLinear(now)
+		for q := 0; q < 20; q++ {
+			target := kdtree.NewDatapoint(nil, []float64{r.Float64() * 100, r.Float64() * 100})
+			want := linear.KNN(target, 4)
+			got := tree.KNN(target, 4)
+			for i := range want {
+				if want[i].Data() != got[i] && kdtree.Distance(target, want[i]) != kdtree.Distance(target, kdtree.NewDatapoint(nil, tree.Position(got[i]))) {
+					t.Error(`time `, tree.Now(), `: KNN disagreed at rank `, i)
+				}
+			}
+			bounds := []kdtree.Range{kdtree.NewRange(target.At(0)-10, target.At(0)+10), kdtree.NewRange(target.At(1)-5, target.At(1)+5)}
+			var wantIDs, gotIDs []int
+			for _, d := range linear.Range(bounds) {
+				wantIDs = append(wantIDs, d.Data().(*kdtree.Datapoint).Data().(int))
+			}
+			for _, d := range tree.Range(bounds) {
+				gotIDs = append(gotIDs, d.Data().(int))
+			}
+			sort.Ints(wantIDs)
+			sort.Ints(gotIDs)
+			if !reflect.DeepEqual(wantIDs, gotIDs) {
+				t.Error(`time `, tree.Now(), `: Range want: `, wantIDs, `
+				got: `, gotIDs)
+			}
+		}
+	}
+	if tree.Events() == 0 {
+		t.Error(`expected some certificate failures while vehicles overtake one another`)
+	}
+}
+
+func Test_Kinetic_Tree_Repairs_Are_Local(t *testing.T) {
+	r := rand.New(rand.NewSource(57))
+	ds, vs := traffic(r, 2000)
+	tree, _ := New(ds, vs)
+	for step := 1; step <= 10; step++ {
+		before, rebuilt := tree.Events(), tree.Rebuilt()
+		tree.Advance(float64(step) * 0.1)
+		events := tree.Events() - before
+		if events == 0 {
+			continue
+		}
+		verify(t, tree)
+		perEvent := float64(tree.Rebuilt()-rebuilt) / float64(events)
+		if bound := 2 * math.Log2(float64(tree.Len())); perEvent > bound {
+			t.Error(`time `, tree.Now(), ` want: at most `, bound, ` nodes rebuilt per event
+			got: `, perEvent, ` over `, events, ` events`)
+		}
+	}
+	if tree.Events() == 0 {
+		t.Error(`expected some certificate failures while vehicles overtake one another`)
+	}
+}
+
+func Test_Kinetic_Tree_Crossing(t *testing.T) {
+	ds := kdtree.Datapoints{
+		kdtree.NewDatapoint("a", []float64{0}),
+		kdtree.NewDatapoint("b", []float64{10}),
+		kdtree.NewDatapoint("c", []float64{20}),
+	}
+	tree, _ := New(ds, [][]float64{{3}, {0}, {-3}})
+	target := kdtree.NewDatapoint(nil, []float64{0})
+	if tree.NN(target).Data() != "a" {
+		t.Error(`want: a at time 0`)
+	}
+	tree.Advance(5)
+	verify(t, tree)
+	if got := tree.NN(target).Data(); got != "c" {
+		t.Error(`want: c at time 5, when a and c have passed b
+		got: `, got)
+	}
+	if tree.Events() != 2 {
+		t.Error(`want: 2 events
+		got: `, tree.Events())
+	}
+	if err := tree.Advance(1); err != ErrTimeReversed {
+		t.Error(`want: `, ErrTimeReversed, `
+		got: `, err)
+	}
+}
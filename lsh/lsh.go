@@ -0,0 +1,316 @@
+package lsh
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// Family is the family of hash functions, which decides the distance under
+// which nearby Datapoints are likely to share a bucket.
+type Family int
+
+const (
+	// Hyperplane hashes each Datapoint to the side it lies on of random
+	// hyperplanes through the origin, so that two Datapoints share a bit with
+	// probability 1 - θ/π for the angle θ between them (Charikar, 2002).
+	// Neighbours are ranked by metric.Angular, suiting cosine similarity;
+	// the zero vector has no angle and is refused.
+	Hyperplane Family = iota
+	// PStable hashes each Datapoint to the slot of width Width it falls in
+	// along random Gaussian projections, so that nearby Datapoints share a
+	// slot with a probability falling off with their Euclidean distance
+	// (Datar et al., 2004). Neighbours are ranked by metric.Euclidean.
+	PStable
+)
+
+// Defaults used when non-positive options are given.
+const (
+	DefaultTables = 8
+	DefaultHashes = 12
+	DefaultWidth  = 4.0
+)
+
+// ErrTooManyHashes is returned when a Hyperplane key is asked for more bits
+// than fit in a bucket key.
+var ErrTooManyHashes = errors.New("lsh: a hyperplane key holds at most 64 hashes")
+
+// ErrUnknownFamily is returned when the Family is neither Hyperplane nor PStable.
+var ErrUnknownFamily = errors.New("lsh: unknown hash family")
+
+// ErrZeroVector is returned when the zero vector, which has no angle to
+// rank by, is inserted into a Hyperplane Index.
+var ErrZeroVector = errors.New("lsh: a hyperplane index cannot hold the zero vector")
+
+// Options configures the construction of an Index.
+type Options struct {
+	Family Family
+	// Tables is the number of hash tables. Each table is another chance for
+	// a neighbour to share a bucket, raising recall and the cost of queries.
+	Tables int
+	// Hashes is the number of hash functions concatenated into the key of each
+	// table. More make buckets smaller and more selective, lowering recall.
+	Hashes int
+	// Width is the width of the slots along each PStable projection, which
+	// is best on the order of the distance to the neighbours sought.
+	Width float64
+	// Seed seeds the random hash functions, so that an Index can be rebuilt
+	// with the same buckets.
+	Seed int64
+}
+
+// Index is a locality-sensitive hashing index for approximate nearest
+// neighbour search in very high dimensions, where the pruning of trees fails
+// and nearly every Datapoint would be visited. A query gathers the
+// Datapoints sharing its bucket in any table and ranks only those, so it
+// may miss true neighbours; Recall measures how often.
+type Index struct {
+	opts   Options
+	dims   int
+	dist   metric.Func
+	tables []*table
+	slots  map[*kdtree.Datapoint][]uint64 // the key of each Datapoint in each table
+}
+
+type table struct {
+	projections [][]float64
+	offsets     []float64 // PStable only
+	buckets     map[uint64]kdtree.Datapoints
+}
+
+// New returns an empty Index over Datapoints of the given dimensionality.
+func New(dims int, opts Options) (*Index, error) {
+	if dims <= 0 {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	if opts.Family != Hyperplane && opts.Family != PStable {
+		return nil, ErrUnknownFamily
+	}
+	if opts.Tables <= 0 {
+		opts.Tables = DefaultTables
+	}
+	if opts.Hashes <= 0 {
+		opts.Hashes = DefaultHashes
+	}
+	if opts.Width <= 0 {
+		opts.Width = DefaultWidth
+	}
+	if opts.Family == Hyperplane && opts.Hashes > 64 {
+		return nil, ErrTooManyHashes
+	}
+	idx := &Index{
+		opts:   opts,
+		dims:   dims,
+		dist:   metric.Angular,
+		tables: make([]*table, opts.Tables),
+		slots:  make(map[*kdtree.Datapoint][]uint64),
+	}
+	if opts.Family == PStable {
+		idx.dist = metric.Euclidean
+	}
+	r := rand.New(rand.NewSource(opts.Seed))
+	for i := range idx.tables {
+		t := &table{
+			projections: make([][]float64, opts.Hashes),
+			buckets:     make(map[uint64]kdtree.Datapoints),
+		}
+		for h := range t.projections {
+			t.projections[h] = make([]float64, dims)
+			for axis := range t.projections[h] {
+				t.projections[h][axis] = r.NormFloat64()
+			}
+			if opts.Family == PStable {
+				t.offsets = append(t.offsets, r.Float64()*opts.Width)
+			}
+		}
+		idx.tables[i] = t
+	}
+	return idx, nil
+}
+
+// Build returns an Index holding every Datapoint in ds, which must share
+// the same dimensionality.
+func Build(ds kdtree.Datapoints, opts Options) (*Index, error) {
+	if len(ds) == 0 || ds[0] == nil {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	idx, err := New(ds[0].Dimensionality(), opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		if err := idx.Insert(d); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+// Len returns the number of Datapoints held.
+func (idx *Index) Len() int {
+	return len(idx.slots)
+}
+
+// Dims returns the dimensionality of the Datapoints the Index holds.
+func (idx *Index) Dims() int {
+	return idx.dims
+}
+
+// Distance returns the distance by which neighbours are ranked.
+func (idx *Index) Distance() metric.Func {
+	return idx.dist
+}
+
+// key returns the bucket of a Datapoint in the table.
+func (idx *Index) key(t *table, d *kdtree.Datapoint) uint64 {
+	var key uint64
+	for h, projection := range t.projections {
+		var dot float64
+		for axis, v := range projection {
+			dot += v * d.At(axis)
+		}
+		if idx.opts.Family == Hyperplane {
+			key <<= 1
+			if dot >= 0 {
+				key |= 1
+			}
+			continue
+		}
+		// an FNV-1a style mix of the slots, whose collisions only add
+		// candidates
+		slot := int64(math.Floor((dot + t.offsets[h]) / idx.opts.Width))
+		key = (key ^ uint64(slot)) * 1099511628211
+	}
+	return key
+}
+
+// Insert adds a Datapoint to its bucket in every table.
+// Inserting a Datapoint which is already held has no effect.
+func (idx *Index) Insert(d *kdtree.Datapoint) error {
+	if d == nil || d.Dimensionality() != idx.dims {
+		return kdtree.ErrDimensionMismatch
+	}
+	if idx.opts.Family == Hyperplane && isZero(d) {
+		return ErrZeroVector
+	}
+	if _, held := idx.slots[d]; held {
+		return nil
+	}
+	keys := make([]uint64, len(idx.tables))
+	for i, t := range idx.tables {
+		keys[i] = idx.key(t, d)
+		t.buckets[keys[i]] = append(t.buckets[keys[i]], d)
+	}
+	idx.slots[d] = keys
+	return nil
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+func (idx *Index) Delete(d *kdtree.Datapoint) bool {
+	keys, held := idx.slots[d]
+	if !held {
+		return false
+	}
+	for i, t := range idx.tables {
+		bucket := t.buckets[keys[i]]
+		for j := range bucket {
+			if bucket[j] == d {
+				bucket[j] = bucket[len(bucket)-1]
+				bucket = bucket[:len(bucket)-1]
+				break
+			}
+		}
+		if len(bucket) == 0 {
+			delete(t.buckets, keys[i])
+		} else {
+			t.buckets[keys[i]] = bucket
+		}
+	}
+	delete(idx.slots, d)
+	return true
+}
+
+// Candidates returns the Datapoints sharing a bucket with the target in any
+// table, each once, which are all a query will rank.
+func (idx *Index) Candidates(target *kdtree.Datapoint) kdtree.Datapoints {
+	if !idx.accepts(target) {
+		return nil
+	}
+	seen := make(map[*kdtree.Datapoint]bool)
+	var ds kdtree.Datapoints
+	for _, t := range idx.tables {
+		for _, d := range t.buckets[idx.key(t, target)] {
+			if !seen[d] {
+				seen[d] = true
+				ds = append(ds, d)
+			}
+		}
+	}
+	return ds
+}
+
+// NN returns the approximate nearest neighbour of the target, or nil if no
+// Datapoint shares a bucket with it.
+func (idx *Index) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := idx.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns up to k approximate nearest neighbours of the target, nearest
+// first. Fewer are returned when fewer share a bucket with the target.
+func (idx *Index) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if k <= 0 {
+		return nil
+	}
+	return idx.rank(target, idx.Candidates(target), k)
+}
+
+// Exact returns the true k nearest neighbours of the target by scanning
+// every Datapoint held, nearest first, for comparison with KNN.
+func (idx *Index) Exact(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if k <= 0 || !idx.accepts(target) {
+		return nil
+	}
+	ds := make(kdtree.Datapoints, 0, len(idx.slots))
+	for d := range idx.slots {
+		ds = append(ds, d)
+	}
+	return idx.rank(target, ds, k)
+}
+
+// accepts reports whether the target can be queried: it must match the
+// dimensionality of the Index and, for Hyperplane, have an angle.
+func (idx *Index) accepts(target *kdtree.Datapoint) bool {
+	if target == nil || target.Dimensionality() != idx.dims {
+		return false
+	}
+	return idx.opts.Family != Hyperplane || !isZero(target)
+}
+
+func isZero(d *kdtree.Datapoint) bool {
+	for axis := 0; axis < d.Dimensionality(); axis++ {
+		if d.At(axis) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (idx *Index) rank(target *kdtree.Datapoint, ds kdtree.Datapoints, k int) kdtree.Datapoints {
+	set := nearest.New(k)
+	for _, d := range ds {
+		set.Push(d, idx.dist(target, d))
+	}
+	var out kdtree.Datapoints
+	for _, c := range set.Sorted() {
+		out = append(out, c.Item.(*kdtree.Datapoint))
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package lsh
+
+import "github.com/benjamin-rood/goeometric/kdtree"
+
+// SearchFunc answers a k-nearest neighbour query, nearest first, as the KNN
+// method of each index does.
+type SearchFunc func(target *kdtree.Datapoint, k int) kdtree.Datapoints
+
+// Recall returns the fraction of the true k nearest neighbours that an
+// approximate search finds, counted over all the queries together: 1 when it
+// finds them all. exact gives the true neighbours, such as the KNN of a
+// kdtree.Linear or the Exact method of an Index.
+func Recall(queries kdtree.Datapoints, k int, approx, exact SearchFunc) float64 {
+	want, found := 0, 0
+	for _, q := range queries {
+		truth := make(map[*kdtree.Datapoint]bool)
+		for _, d := range exact(q, k) {
+			truth[d] = true
+		}
+		want += len(truth)
+		for _, d := range approx(q, k) {
+			if truth[d] {
+				found++
+			}
+		}
+	}
+	if want == 0 {
+		return 1
+	}
+	return float64(found) / float64(want)
+}
+
+// Recall returns the recall of KNN against Exact over the queries.
+func (idx *Index) Recall(queries kdtree.Datapoints, k int) float64 {
+	return Recall(queries, k, idx.KNN, idx.Exact)
+}
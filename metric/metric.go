@@ -0,0 +1,107 @@
+package metric
+
+import (
+	"math"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Func is a distance function between two Datapoints of equal dimensionality.
+// Structures which prune their search by the triangle inequality (vantage-point,
+// ball and cover trees) need it to be a true metric: non-negative, symmetric,
+// zero only between identical points, and obeying d(p,r) <= d(p,q) + d(q,r).
+type Func func(p, q *kdtree.Datapoint) float64
+
+// ItemFunc is a distance function between arbitrary items, for data which has
+// no coordinates at all: strings, sets, graphs and so on. The same metric
+// requirements as for Func apply.
+type ItemFunc func(a, b interface{}) float64
+
+// Set of pre-defined metrics which match the prototype of `Func`
+var (
+	// Euclidean is the straight-line (L2) distance.
+	Euclidean Func = kdtree.Distance
+
+	// Manhattan is the taxicab (L1) distance.
+	Manhattan Func = func(p, q *kdtree.Datapoint) float64 {
+		var d float64
+		for axis := 0; axis < p.Dimensionality(); axis++ {
+			d += math.Abs(p.At(axis) - q.At(axis))
+		}
+		return d
+	}
+
+	// Chebyshev is the chessboard (L∞) distance.
+	Chebyshev Func = func(p, q *kdtree.Datapoint) float64 {
+		var d float64
+		for axis := 0; axis < p.Dimensionality(); axis++ {
+			d = math.Max(d, math.Abs(p.At(axis)-q.At(axis)))
+		}
+		return d
+	}
+
+	// Angular is the angle in radians between two non-zero vectors. Unlike the
+	// cosine distance (1 - cosine similarity), it obeys the triangle inequality.
+	Angular Func = func(p, q *kdtree.Datapoint) float64 {
+		var dot, pp, qq float64
+		for axis := 0; axis < p.Dimensionality(); axis++ {
+			dot += p.At(axis) * q.At(axis)
+			pp += p.At(axis) * p.At(axis)
+			qq += q.At(axis) * q.At(axis)
+		}
+		cos := dot / math.Sqrt(pp*qq)
+		return math.Acos(math.Max(-1, math.Min(1, cos)))
+	}
+)
+
+// Minkowski returns the L-p distance, which is a metric for p >= 1.
+func Minkowski(p float64) Func {
+	return func(a, b *kdtree.Datapoint) float64 {
+		var d float64
+		for axis := 0; axis < a.Dimensionality(); axis++ {
+			d += math.Pow(math.Abs(a.At(axis)-b.At(axis)), p)
+		}
+		return math.Pow(d, 1/p)
+	}
+}
+
+// Items adapts a Func to an ItemFunc whose items are *kdtree.Datapoint.
+func (f Func) Items() ItemFunc {
+	return func(a, b interface{}) float64 {
+		return f(a.(*kdtree.Datapoint), b.(*kdtree.Datapoint))
+	}
+}
+
+// Levenshtein is the edit distance between two strings: the least number of
+// single rune insertions, deletions and substitutions turning one into the other.
+var Levenshtein ItemFunc = func(a, b interface{}) float64 {
+	s, t := []rune(a.(string)), []rune(b.(string))
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			above := row[j]
+			row[j] = min3(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = above
+		}
+	}
+	return float64(row[len(t)])
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,54 @@
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func Test_Metric_Datapoint_Distances(t *testing.T) {
+	p := kdtree.NewDatapoint(nil, []float64{1, 2, 3})
+	q := kdtree.NewDatapoint(nil, []float64{4, -2, 3})
+	metricTests := []struct {
+		name string
+		f    Func
+		want float64
+	}{
+		{"Euclidean", Euclidean, 5},
+		{"Manhattan", Manhattan, 7},
+		{"Chebyshev", Chebyshev, 4},
+		{"Minkowski(1)", Minkowski(1), 7},
+		{"Minkowski(2)", Minkowski(2), 5},
+		{"Angular", Angular, math.Acos(9 / (math.Sqrt(14) * math.Sqrt(29)))},
+	}
+	for _, mt := range metricTests {
+		got := mt.f(p, q)
+		if math.Abs(got-mt.want) > 1e-12 {
+			t.Error(mt.name, ` want: `, mt.want, `
+			got: `, got)
+		}
+		if got != mt.f.Items()(q, p) {
+			t.Error(mt.name, ` is not symmetric through Items()`)
+		}
+	}
+}
+
+func Test_Metric_Levenshtein(t *testing.T) {
+	levenshteinTests := []struct {
+		a, b string
+		want float64
+	}{
+		{"kitten", "sitting", 3},
+		{"", "geode", 5},
+		{"flaw", "lawn", 2},
+		{"kōwhai", "kowhai", 1},
+		{"same", "same", 0},
+	}
+	for _, lt := range levenshteinTests {
+		if got := Levenshtein(lt.a, lt.b); got != lt.want {
+			t.Error(lt.a, `/`, lt.b, ` want: `, lt.want, `
+			got: `, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package octree
+
+import (
+	"math"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func position(d *kdtree.Datapoint) [3]float64 {
+	return [3]float64{d.At(0), d.At(1), d.At(2)}
+}
+
+func sortedDatapoints(set *nearest.Set) kdtree.Datapoints {
+	sorted := set.Sorted()
+	ds := make(kdtree.Datapoints, len(sorted))
+	for i := range sorted {
+		ds[i] = sorted[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
+
+func emptyBox() box {
+	inf := math.Inf(1)
+	return box{[3]float64{inf, inf, inf}, [3]float64{-inf, -inf, -inf}}
+}
+
+func (b *box) extend(p [3]float64) {
+	for axis := range p {
+		b.lo[axis] = math.Min(b.lo[axis], p[axis])
+		b.hi[axis] = math.Max(b.hi[axis], p[axis])
+	}
+}
+
+func (b box) centre() [3]float64 {
+	return [3]float64{
+		(b.lo[0] + b.hi[0]) / 2,
+		(b.lo[1] + b.hi[1]) / 2,
+		(b.lo[2] + b.hi[2]) / 2,
+	}
+}
+
+func centreSlice(b box) []float64 {
+	c := b.centre()
+	return c[:]
+}
+
+func (b box) contains(p [3]float64) bool {
+	for axis := range p {
+		if p[axis] < b.lo[axis] || p[axis] > b.hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b box) intersects(o box) bool {
+	for axis := 0; axis < 3; axis++ {
+		if b.lo[axis] > o.hi[axis] || o.lo[axis] > b.hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// distanceSq returns the squared distance from p to the nearest point of the box.
+func (b box) distanceSq(p [3]float64) float64 {
+	var sq float64
+	for axis := range p {
+		d := math.Max(0, math.Max(b.lo[axis]-p[axis], p[axis]-b.hi[axis]))
+		sq += d * d
+	}
+	return sq
+}
+
+func toRanges(b box) []kdtree.Range {
+	return []kdtree.Range{
+		kdtree.NewRange(b.lo[0], b.hi[0]),
+		kdtree.NewRange(b.lo[1], b.hi[1]),
+		kdtree.NewRange(b.lo[2], b.hi[2]),
+	}
+}
@@ -0,0 +1,539 @@
+package octree
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Defaults used when a non-positive leaf capacity or maximum depth is given.
+const (
+	DefaultCapacity = 8
+	DefaultMaxDepth = 21
+)
+
+// MaxVoxelDepth is the deepest level Voxels divides into, at which the
+// 2^depth voxels along each axis still fit an int64 key.
+const MaxVoxelDepth = 62
+
+// ErrOutOfBounds is returned when a Datapoint lies outside the region covered by the Octree.
+var ErrOutOfBounds = errors.New("octree: datapoint lies outside the octree bounds")
+
+// Options configures the construction of an Octree.
+type Options struct {
+	// Capacity is the number of Datapoints a leaf holds before it is split.
+	Capacity int
+	// MaxDepth limits subdivision; leaves at MaxDepth hold any number of Datapoints.
+	MaxDepth int
+	// FitBounds prunes queries against the tight bounding box of the
+	// Datapoints each Octant actually holds (its TightBounds), rather than
+	// against its cell.
+	// This prunes much more of a sparse scan, where most of each cell is empty space.
+	FitBounds bool
+}
+
+// Octree is a point-region octree over 3-D Datapoints.
+type Octree struct {
+	root *Octant
+	opts Options
+	size int
+	held map[*kdtree.Datapoint]struct{}
+}
+
+// Octant is a single cuboid cell of the Octree.
+// Children are indexed by bit: 1 for the upper half in x, 2 in y and 4 in z.
+type Octant struct {
+	kdtree.Datapoints // only populated at the leaves
+	cell              box
+	tight             box
+	sum               [3]float64
+	depth             int
+	cardinality       int
+	children          *[8]*Octant
+}
+
+type box struct {
+	lo, hi [3]float64
+}
+
+var _ kdtree.SpatialIndex = (*Octree)(nil)
+
+// New constructs an empty Octree covering the 3-D bounds.
+func New(bounds []kdtree.Range, opts Options) (*Octree, error) {
+	if len(bounds) != 3 {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	if opts.Capacity <= 0 {
+		opts.Capacity = DefaultCapacity
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
+	var cell box
+	for axis, r := range bounds {
+		cell.lo[axis], cell.hi[axis] = r.Min(), r.Max()
+	}
+	return &Octree{
+		root: &Octant{cell: cell, tight: emptyBox()},
+		opts: opts,
+		held: make(map[*kdtree.Datapoint]struct{}),
+	}, nil
+}
+
+// Build constructs an Octree over the bounding cube of ds and inserts every Datapoint.
+func Build(ds kdtree.Datapoints, opts Options) (*Octree, error) {
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for _, d := range ds {
+		if d == nil || d.Dimensionality() != 3 {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+		for axis := 0; axis < 3; axis++ {
+			lo[axis] = math.Min(lo[axis], d.At(axis))
+			hi[axis] = math.Max(hi[axis], d.At(axis))
+		}
+	}
+	if len(ds) == 0 {
+		lo, hi = [3]float64{}, [3]float64{1, 1, 1}
+	}
+	side := math.Max(hi[0]-lo[0], math.Max(hi[1]-lo[1], hi[2]-lo[2]))
+	if side == 0 {
+		side = 1
+	}
+	bounds := make([]kdtree.Range, 3)
+	for axis := range bounds {
+		bounds[axis] = kdtree.NewRange(lo[axis], lo[axis]+side)
+	}
+	ot, err := New(bounds, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		if err := ot.Insert(d); err != nil {
+			return nil, err
+		}
+	}
+	return ot, nil
+}
+
+// Convert uses the Importable interface to cleanly produce an Octree
+// from a slice of some type which has implemented ToDatapoint().
+func Convert(c []kdtree.Importable, opts Options) (*Octree, error) {
+	var points = make(kdtree.Datapoints, len(c), len(c))
+	for i := range c {
+		points[i] = c[i].ToDatapoint()
+	}
+	return Build(points, opts)
+}
+
+// Root returns the Octant covering the whole Octree.
+func (ot *Octree) Root() *Octant {
+	return ot.root
+}
+
+// Len returns the number of Datapoints held.
+func (ot *Octree) Len() int {
+	return ot.size
+}
+
+// Dims always returns 3.
+func (ot *Octree) Dims() int {
+	return 3
+}
+
+// Insert adds a Datapoint to the leaf covering it, splitting the leaf when it
+// exceeds the leaf capacity. Inserting a Datapoint which is already held has
+// no effect.
+func (ot *Octree) Insert(d *kdtree.Datapoint) error {
+	if d == nil || d.Dimensionality() != 3 {
+		return kdtree.ErrDimensionMismatch
+	}
+	if _, ok := ot.held[d]; ok {
+		return nil
+	}
+	p := position(d)
+	if !ot.root.cell.contains(p) {
+		return ErrOutOfBounds
+	}
+	o := ot.root
+	for {
+		o.add(p)
+		if o.children == nil {
+			break
+		}
+		o = o.children[o.octant(p)]
+	}
+	o.Datapoints = append(o.Datapoints, d)
+	ot.size++
+	ot.held[d] = struct{}{}
+	ot.split(o)
+	return nil
+}
+
+func (o *Octant) add(p [3]float64) {
+	o.cardinality++
+	for axis := range p {
+		o.sum[axis] += p[axis]
+		o.tight.lo[axis] = math.Min(o.tight.lo[axis], p[axis])
+		o.tight.hi[axis] = math.Max(o.tight.hi[axis], p[axis])
+	}
+}
+
+func (ot *Octree) split(o *Octant) {
+	if len(o.Datapoints) <= ot.opts.Capacity || o.depth >= ot.opts.MaxDepth {
+		return
+	}
+	mid := o.cell.centre()
+	o.children = new([8]*Octant)
+	for i := range o.children {
+		child := &Octant{depth: o.depth + 1, tight: emptyBox()}
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<uint(axis)) == 0 {
+				child.cell.lo[axis], child.cell.hi[axis] = o.cell.lo[axis], mid[axis]
+			} else {
+				child.cell.lo[axis], child.cell.hi[axis] = mid[axis], o.cell.hi[axis]
+			}
+		}
+		o.children[i] = child
+	}
+	for _, d := range o.Datapoints {
+		p := position(d)
+		child := o.children[o.octant(p)]
+		child.add(p)
+		child.Datapoints = append(child.Datapoints, d)
+	}
+	o.Datapoints = nil
+	for _, child := range o.children {
+		ot.split(child)
+	}
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+// Octants whose subtree falls back within capacity are merged into a single leaf.
+func (ot *Octree) Delete(d *kdtree.Datapoint) bool {
+	if _, ok := ot.held[d]; !ok {
+		return false
+	}
+	if !ot.root.delete(d, position(d), ot.opts.Capacity) {
+		return false
+	}
+	delete(ot.held, d)
+	ot.size--
+	return true
+}
+
+func (o *Octant) delete(d *kdtree.Datapoint, p [3]float64, capacity int) bool {
+	if o.children == nil {
+		i := -1
+		for k := range o.Datapoints {
+			if o.Datapoints[k] == d {
+				i = k
+				break
+			}
+		}
+		if i < 0 {
+			return false
+		}
+		o.Datapoints = append(o.Datapoints[:i], o.Datapoints[i+1:]...)
+	} else {
+		if !o.children[o.octant(p)].delete(d, p, capacity) {
+			return false
+		}
+		if o.cardinality-1 <= capacity {
+			o.Datapoints = o.collect(make(kdtree.Datapoints, 0, o.cardinality))
+			o.children = nil
+		}
+	}
+	o.cardinality--
+	for axis := range p {
+		o.sum[axis] -= p[axis]
+	}
+	o.refit()
+	return true
+}
+
+// refit recomputes the tight bounds from the Octant's leaves or children.
+func (o *Octant) refit() {
+	o.tight = emptyBox()
+	if o.children == nil {
+		for _, d := range o.Datapoints {
+			o.tight.extend(position(d))
+		}
+		return
+	}
+	for _, child := range o.children {
+		if child.cardinality > 0 {
+			o.tight.extend(child.tight.lo)
+			o.tight.extend(child.tight.hi)
+		}
+	}
+}
+
+func (o *Octant) collect(ds kdtree.Datapoints) kdtree.Datapoints {
+	if o.children == nil {
+		return append(ds, o.Datapoints...)
+	}
+	for _, child := range o.children {
+		ds = child.collect(ds)
+	}
+	return ds
+}
+
+// extent is the box used to prune queries: the tight bounds of the held
+// Datapoints when the Octree was built with Options.FitBounds, otherwise the cell.
+func (ot *Octree) extent(o *Octant) box {
+	if ot.opts.FitBounds {
+		return o.tight
+	}
+	return o.cell
+}
+
+// NN returns the nearest neighbour of the target, or nil if the Octree is empty.
+func (ot *Octree) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := ot.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k nearest neighbours of the target, nearest first.
+func (ot *Octree) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if ot.size == 0 || k <= 0 || target == nil || target.Dimensionality() != 3 {
+		return nil
+	}
+	set := nearest.New(k)
+	ot.knn(ot.root, position(target), target, set)
+	return sortedDatapoints(set)
+}
+
+func (ot *Octree) knn(o *Octant, p [3]float64, target *kdtree.Datapoint, set *nearest.Set) {
+	if o.children == nil {
+		for _, d := range o.Datapoints {
+			set.Push(d, kdtree.DistanceSq(target, d))
+		}
+		return
+	}
+	var order [8]int
+	var dist [8]float64
+	for i, child := range o.children {
+		order[i], dist[i] = i, ot.extent(child).distanceSq(p)
+	}
+	sort.Slice(order[:], func(i, j int) bool {
+		return dist[order[i]] < dist[order[j]]
+	})
+	for _, i := range order {
+		child := o.children[i]
+		if child.cardinality > 0 && dist[i] < set.Worst() {
+			ot.knn(child, p, target, set)
+		}
+	}
+}
+
+// Radius returns every Datapoint within distance r of the centre (inclusive).
+func (ot *Octree) Radius(centre *kdtree.Datapoint, r float64) kdtree.Datapoints {
+	if centre == nil || centre.Dimensionality() != 3 || r < 0 {
+		return nil
+	}
+	var found kdtree.Datapoints
+	ot.radiusSearch(ot.root, position(centre), centre, r*r, &found)
+	return found
+}
+
+func (ot *Octree) radiusSearch(o *Octant, p [3]float64, centre *kdtree.Datapoint, rSq float64, found *kdtree.Datapoints) {
+	if o.cardinality == 0 || ot.extent(o).distanceSq(p) > rSq {
+		return
+	}
+	if o.children == nil {
+		for _, d := range o.Datapoints {
+			if kdtree.DistanceSq(centre, d) <= rSq {
+				*found = append(*found, d)
+			}
+		}
+		return
+	}
+	for _, child := range o.children {
+		ot.radiusSearch(child, p, centre, rSq, found)
+	}
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (ot *Octree) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	if len(bounds) != 3 {
+		return nil
+	}
+	var query box
+	for axis, r := range bounds {
+		query.lo[axis], query.hi[axis] = r.Min(), r.Max()
+	}
+	var found kdtree.Datapoints
+	ot.rangeSearch(ot.root, query, bounds, &found)
+	return found
+}
+
+func (ot *Octree) rangeSearch(o *Octant, query box, bounds []kdtree.Range, found *kdtree.Datapoints) {
+	if o.cardinality == 0 || !ot.extent(o).intersects(query) {
+		return
+	}
+	if o.children == nil {
+		for _, d := range o.Datapoints {
+			if kdtree.InBounds(d, bounds) {
+				*found = append(*found, d)
+			}
+		}
+		return
+	}
+	for _, child := range o.children {
+		ot.rangeSearch(child, query, bounds, found)
+	}
+}
+
+// LevelOfDetail calls fn, in depth-first order, for every non-empty Octant at
+// the given depth, and for every non-empty leaf shallower than it.
+// Together these Octants partition the Datapoints, so rendering the Centroid
+// of each gives a simplified view of the cloud at that level.
+// Returning false from fn stops the traversal.
+func (ot *Octree) LevelOfDetail(depth int, fn func(*Octant) bool) {
+	ot.root.levelOfDetail(depth, fn)
+}
+
+func (o *Octant) levelOfDetail(depth int, fn func(*Octant) bool) bool {
+	if o.cardinality == 0 {
+		return true
+	}
+	if o.depth >= depth || o.children == nil {
+		return fn(o)
+	}
+	for _, child := range o.children {
+		if !child.levelOfDetail(depth, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Voxels calls fn with the centre of every occupied voxel at the given depth,
+// where the root cell is divided into 2^depth voxels along each axis, together
+// with the number of Datapoints in that voxel. Leaves shallower than depth are
+// subdivided on the fly, so the voxel size does not depend on the leaf capacity.
+// depth is clamped to [0, MaxVoxelDepth].
+// Returning false from fn stops the iteration.
+func (ot *Octree) Voxels(depth int, fn func(centre *kdtree.Datapoint, count int) bool) {
+	if depth < 0 {
+		depth = 0
+	}
+	if depth > MaxVoxelDepth {
+		depth = MaxVoxelDepth
+	}
+	ot.LevelOfDetail(depth, func(o *Octant) bool {
+		if o.depth >= depth {
+			return fn(kdtree.NewDatapoint(nil, centreSlice(o.cell)), o.cardinality)
+		}
+		scale := float64(uint64(1) << uint(depth-o.depth))
+		counts := make(map[[3]int64]int)
+		for _, d := range o.Datapoints {
+			var key [3]int64
+			for axis := 0; axis < 3; axis++ {
+				width := (o.cell.hi[axis] - o.cell.lo[axis]) / scale
+				k := int64(math.Floor((d.At(axis) - o.cell.lo[axis]) / width))
+				if k >= int64(scale) { // the upper face belongs to the last voxel
+					k = int64(scale) - 1
+				}
+				key[axis] = k
+			}
+			counts[key]++
+		}
+		keys := make([][3]int64, 0, len(counts))
+		for key := range counts {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			for axis := 2; axis >= 0; axis-- {
+				if keys[i][axis] != keys[j][axis] {
+					return keys[i][axis] < keys[j][axis]
+				}
+			}
+			return false
+		})
+		for _, key := range keys {
+			centre := make([]float64, 3)
+			for axis := range centre {
+				width := (o.cell.hi[axis] - o.cell.lo[axis]) / scale
+				centre[axis] = o.cell.lo[axis] + (float64(key[axis])+0.5)*width
+			}
+			if !fn(kdtree.NewDatapoint(nil, centre), counts[key]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Cardinality returns the number of Datapoints held beneath the Octant.
+func (o *Octant) Cardinality() int {
+	return o.cardinality
+}
+
+// Depth returns the depth of the Octant, where the root is at depth 0.
+func (o *Octant) Depth() int {
+	return o.depth
+}
+
+// Centroid returns the mean position of the Datapoints held beneath the Octant,
+// or nil if it is empty.
+func (o *Octant) Centroid() *kdtree.Datapoint {
+	if o.cardinality == 0 {
+		return nil
+	}
+	n := float64(o.cardinality)
+	return kdtree.NewDatapoint(nil, []float64{o.sum[0] / n, o.sum[1] / n, o.sum[2] / n})
+}
+
+// Bounds returns the cell covered by the Octant.
+func (o *Octant) Bounds() []kdtree.Range {
+	return toRanges(o.cell)
+}
+
+// TightBounds returns the bounding box of the Datapoints held beneath the
+// Octant, or nil if it is empty.
+func (o *Octant) TightBounds() []kdtree.Range {
+	if o.cardinality == 0 {
+		return nil
+	}
+	return toRanges(o.tight)
+}
+
+// MaxDepth returns the depth of the deepest leaf beneath the Octant.
+func (o *Octant) MaxDepth() int {
+	deepest := o.depth
+	if o.children != nil {
+		for _, child := range o.children {
+			if d := child.MaxDepth(); d > deepest {
+				deepest = d
+			}
+		}
+	}
+	return deepest
+}
+
+// Child returns one of the eight child Octants, or nil at a leaf.
+func (o *Octant) Child(i int) *Octant {
+	if o.children == nil {
+		return nil
+	}
+	return o.children[i]
+}
+
+func (o *Octant) octant(p [3]float64) int {
+	mid := o.cell.centre()
+	i := 0
+	for axis := 0; axis < 3; axis++ {
+		if p[axis] >= mid[axis] {
+			i |= 1 << uint(axis)
+		}
+	}
+	return i
+}
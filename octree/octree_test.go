@@ -0,0 +1,225 @@
+package octree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// scan produces a noisy ground plane with a few dense clusters above it,
+// roughly what a single LiDAR sweep looks like.
+func scan(r *rand.Rand, n int) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, 0, n)
+	for i := 0; i < n; i++ {
+		var f []float64
+		if i%4 == 0 {
+			c := float64(i % 3 * 20)
+			f = []float64{c + r.NormFloat64(), c + r.NormFloat64(), 5 + r.NormFloat64()}
+		} else {
+			f = []float64{r.Float64() * 64, r.Float64() * 64, r.Float64() * 0.2}
+		}
+		ds = append(ds, kdtree.NewDatapoint(i, f))
+	}
+	return ds
+}
+
+func Test_Octree_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(53))
+	ds := scan(r, 2000)
+	for _, tight := range []bool{false, true} {
+		ot, err := Build(ds, Options{Capacity: 6, FitBounds: tight})
+		if err != nil {
+			t.Fatal(err)
+		}
+		linear, _ := kdtree.NewLinear(ds)
+		for i := 0; i < len(ds); i += 5 {
+			if ot.Delete(ds[i]) != linear.Delete(ds[i]) {
+				t.Fatal(`Delete disagreed for `, ds[i])
+			}
+		}
+		if ot.Len() != linear.Len() {
+			t.Fatal(`want: `, linear.Len(), `
+			got: `, ot.Len())
+		}
+
+		for q := 0; q < 100; q++ {
+			target := kdtree.NewDatapoint(nil, []float64{r.Float64() * 70, r.Float64() * 70, r.Float64() * 8})
+			got, want := ot.KNN(target, 6), linear.KNN(target, 6)
+			for i := range want {
+				if kdtree.DistanceSq(target, got[i]) != kdtree.DistanceSq(target, want[i]) {
+					t.Error(`tight=`, tight, ` KNN want: `, want.PointsSetString(), `
+					got: `, got.PointsSetString())
+					break
+				}
+			}
+
+			radius := r.Float64() * 5
+			var inside int
+			for _, d := range linear.Range([]kdtree.Range{
+				kdtree.NewRange(target.At(0)-radius, target.At(0)+radius),
+				kdtree.NewRange(target.At(1)-radius, target.At(1)+radius),
+				kdtree.NewRange(target.At(2)-radius, target.At(2)+radius),
+			}) {
+				if kdtree.Distance(target, d) <= radius {
+					inside++
+				}
+			}
+			if got := len(ot.Radius(target, radius)); got != inside {
+				t.Error(`tight=`, tight, ` Radius want: `, inside, `
+				got: `, got)
+			}
+
+			bounds := []kdtree.Range{
+				kdtree.NewRange(target.At(0), target.At(0)+10),
+				kdtree.NewRange(target.At(1), target.At(1)+10),
+				kdtree.NewRange(0, target.At(2)),
+			}
+			if len(ot.Range(bounds)) != len(linear.Range(bounds)) {
+				t.Error(`tight=`, tight, ` Range disagreed for `, bounds)
+			}
+		}
+	}
+}
+
+func Test_Octree_LevelOfDetail_Partitions_Datapoints(t *testing.T) {
+	ot, _ := Build(scan(rand.New(rand.NewSource(1)), 500), Options{Capacity: 4})
+	for depth := 0; depth <= ot.Root().MaxDepth(); depth++ {
+		var total int
+		ot.LevelOfDetail(depth, func(o *Octant) bool {
+			if o.Depth() > depth || (o.Depth() < depth && o.Child(0) != nil) {
+				t.Error(`unexpected Octant at depth `, o.Depth(), ` for level `, depth)
+			}
+			total += o.Cardinality()
+			return true
+		})
+		if total != ot.Len() {
+			t.Error(`want: `, ot.Len(), `
+			got: `, total)
+		}
+	}
+}
+
+func Test_Octree_Voxels(t *testing.T) {
+	ds := kdtree.Datapoints{
+		kdtree.NewDatapoint(nil, []float64{0, 0, 0}),
+		kdtree.NewDatapoint(nil, []float64{0.1, 0.1, 0.1}),
+		kdtree.NewDatapoint(nil, []float64{3.9, 3.9, 3.9}),
+		kdtree.NewDatapoint(nil, []float64{4, 4, 4}),
+		kdtree.NewDatapoint(nil, []float64{2.5, 0, 1}),
+	}
+	want := []struct {
+		centre []float64
+		count  int
+	}{
+		{[]float64{0.5, 0.5, 0.5}, 2},
+		{[]float64{2.5, 0.5, 1.5}, 1},
+		{[]float64{3.5, 3.5, 3.5}, 2},
+	}
+	for _, capacity := range []int{1, 100} {
+		ot, _ := Build(ds, Options{Capacity: capacity})
+		var i int
+		ot.Voxels(2, func(centre *kdtree.Datapoint, count int) bool {
+			if i >= len(want) || !centre.EqualTo(kdtree.NewDatapoint(nil, want[i].centre)) || count != want[i].count {
+				t.Error(`capacity=`, capacity, ` got: `, centre.Set(), count)
+			}
+			i++
+			return true
+		})
+		if i != len(want) {
+			t.Error(`want: `, len(want), ` voxels
+			got: `, i)
+		}
+	}
+
+	ot, _ := Build(ds, Options{Capacity: 100})
+	for _, depth := range []int{-3, 100} {
+		voxels, total := 0, 0
+		ot.Voxels(depth, func(centre *kdtree.Datapoint, count int) bool {
+			if !kdtree.InBounds(centre, ot.Root().Bounds()) {
+				t.Error(`depth=`, depth, ` a voxel centre should lie in the Octree: `, centre.Set())
+			}
+			voxels++
+			total += count
+			return true
+		})
+		if total != len(ds) || depth < 0 && voxels != 1 || depth > 0 && voxels != len(ds) {
+			t.Error(`depth=`, depth, ` want: every Datapoint counted once
+			got: `, voxels, ` voxels holding `, total)
+		}
+	}
+}
+
+func Test_Octree_Centroid_And_Tight_Bounds(t *testing.T) {
+	ds := kdtree.Datapoints{
+		kdtree.NewDatapoint(nil, []float64{1, 2, 3}),
+		kdtree.NewDatapoint(nil, []float64{3, 2, 1}),
+		kdtree.NewDatapoint(nil, []float64{2, 8, 2}),
+	}
+	ot, _ := New([]kdtree.Range{kdtree.NewRange(0, 10), kdtree.NewRange(0, 10), kdtree.NewRange(0, 10)}, Options{Capacity: 1, FitBounds: true})
+	for _, d := range ds {
+		ot.Insert(d)
+	}
+	if got := ot.Root().Centroid().Set(); got[0] != 2 || got[1] != 4 || got[2] != 2 {
+		t.Error(`want: [2 4 2]
+		got: `, got)
+	}
+	ot.Delete(ds[2])
+	tb := ot.Root().TightBounds()
+	if tb[1].Min() != 2 || tb[1].Max() != 2 || tb[0].Min() != 1 || tb[0].Max() != 3 {
+		t.Error(`want: [1,3] [2,2] [1,3]
+		got: `, tb)
+	}
+}
+
+func Test_Octree_Reinsert_And_Nil_Target(t *testing.T) {
+	p := kdtree.NewDatapoint(nil, []float64{1, 2, 3})
+	q := kdtree.NewDatapoint(nil, []float64{3, 2, 1})
+	ot, err := Build(kdtree.Datapoints{p, q, p}, Options{Capacity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ot.Insert(q); err != nil {
+		t.Fatal(err)
+	}
+	if got := ot.Root().Centroid().Set(); ot.Len() != 2 || got[0] != 2 || got[1] != 2 || got[2] != 2 {
+		t.Error(`want: 2 Datapoints with centroid [2 2 2]
+		got: `, ot.Len(), got)
+	}
+	if ot.NN(nil) != nil || ot.KNN(nil, 1) != nil || ot.Radius(nil, 1) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	if !ot.Delete(q) || ot.Delete(q) || ot.Len() != 1 {
+		t.Error(`Delete should remove the Datapoint exactly once`)
+	}
+}
+
+type lidarReturn struct {
+	x, y, z   float64
+	intensity uint16
+}
+
+func (l *lidarReturn) ToDatapoint() *kdtree.Datapoint {
+	return kdtree.NewDatapoint(l, []float64{l.x, l.y, l.z})
+}
+
+func Test_Octree_Convert_with_Importable_Interface(t *testing.T) {
+	returns := []kdtree.Importable{
+		&lidarReturn{0, 0, 0, 10},
+		&lidarReturn{1, 1, 1, 20},
+		&lidarReturn{5, 5, 5, 30},
+	}
+	ot, err := Convert(returns, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ot.NN(kdtree.NewDatapoint(nil, []float64{4, 4, 4}))
+	if got.Data().(*lidarReturn).intensity != 30 {
+		t.Error(`want: 30
+		got: `, got.Data())
+	}
+	if err := ot.Insert(kdtree.NewDatapoint(nil, []float64{6, 0, 0})); err != ErrOutOfBounds {
+		t.Error(`want: `, ErrOutOfBounds, `
+		got: `, err)
+	}
+}
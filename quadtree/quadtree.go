@@ -0,0 +1,375 @@
+package quadtree
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Defaults used when a non-positive bucket capacity or maximum depth is given.
+const (
+	DefaultCapacity = 4
+	DefaultMaxDepth = 16
+)
+
+// ErrOutOfBounds is returned when a Datapoint lies outside the region covered by the Quadtree.
+var ErrOutOfBounds = errors.New("quadtree: datapoint lies outside the quadtree bounds")
+
+// Quadrants of a Quad, in the order its children are stored.
+const (
+	SouthWest = iota
+	SouthEast
+	NorthWest
+	NorthEast
+)
+
+// Quadtree is a point-region (PR) quadtree over 2-D Datapoints.
+// Each leaf Quad holds up to capacity Datapoints before it is split into four
+// equal quadrants; leaves at maxDepth hold any number of Datapoints.
+type Quadtree struct {
+	root     *Quad
+	capacity int
+	maxDepth int
+	size     int
+	held     map[*kdtree.Datapoint]struct{}
+}
+
+// Quad is a single square (or rectangular) region of the Quadtree.
+type Quad struct {
+	kdtree.Datapoints // only populated at the leaves
+	bounds            box
+	depth             int
+	cardinality       int
+	children          *[4]*Quad
+}
+
+type box struct {
+	minX, minY, maxX, maxY float64
+}
+
+var _ kdtree.SpatialIndex = (*Quadtree)(nil)
+
+// New constructs an empty Quadtree covering the 2-D bounds.
+func New(bounds []kdtree.Range, capacity, maxDepth int) (*Quadtree, error) {
+	if len(bounds) != 2 {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	b := box{bounds[0].Min(), bounds[1].Min(), bounds[0].Max(), bounds[1].Max()}
+	return &Quadtree{
+		root:     &Quad{bounds: b},
+		capacity: capacity,
+		maxDepth: maxDepth,
+		held:     make(map[*kdtree.Datapoint]struct{}),
+	}, nil
+}
+
+// Build constructs a Quadtree covering the bounds and inserts every Datapoint in ds.
+func Build(ds kdtree.Datapoints, bounds []kdtree.Range, capacity, maxDepth int) (*Quadtree, error) {
+	qt, err := New(bounds, capacity, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		if err := qt.Insert(d); err != nil {
+			return nil, err
+		}
+	}
+	return qt, nil
+}
+
+// Convert uses the Importable interface to cleanly produce a Quadtree
+// from a slice of some type which has implemented ToDatapoint().
+func Convert(c []kdtree.Importable, bounds []kdtree.Range, capacity, maxDepth int) (*Quadtree, error) {
+	var points = make(kdtree.Datapoints, len(c), len(c))
+	for i := range c {
+		points[i] = c[i].ToDatapoint()
+	}
+	return Build(points, bounds, capacity, maxDepth)
+}
+
+// Root returns the Quad covering the whole Quadtree.
+func (qt *Quadtree) Root() *Quad {
+	return qt.root
+}
+
+// Len returns the number of Datapoints held.
+func (qt *Quadtree) Len() int {
+	return qt.size
+}
+
+// Dims always returns 2.
+func (qt *Quadtree) Dims() int {
+	return 2
+}
+
+// Insert adds a Datapoint to the leaf covering it, splitting the leaf when it
+// exceeds the bucket capacity. Inserting a Datapoint which is already held
+// has no effect.
+func (qt *Quadtree) Insert(d *kdtree.Datapoint) error {
+	if d == nil || d.Dimensionality() != 2 {
+		return kdtree.ErrDimensionMismatch
+	}
+	if _, ok := qt.held[d]; ok {
+		return nil
+	}
+	if !qt.root.bounds.contains(d.At(0), d.At(1)) {
+		return ErrOutOfBounds
+	}
+	q := qt.root
+	for q.children != nil {
+		q.cardinality++
+		q = q.children[q.quadrant(d)]
+	}
+	q.cardinality++
+	q.Datapoints = append(q.Datapoints, d)
+	qt.size++
+	qt.held[d] = struct{}{}
+	qt.split(q)
+	return nil
+}
+
+// split subdivides an over-full leaf until every resulting leaf is within
+// capacity or at the maximum depth.
+func (qt *Quadtree) split(q *Quad) {
+	if len(q.Datapoints) <= qt.capacity || q.depth >= qt.maxDepth {
+		return
+	}
+	midX, midY := q.bounds.centre()
+	b := q.bounds
+	q.children = &[4]*Quad{
+		SouthWest: {bounds: box{b.minX, b.minY, midX, midY}, depth: q.depth + 1},
+		SouthEast: {bounds: box{midX, b.minY, b.maxX, midY}, depth: q.depth + 1},
+		NorthWest: {bounds: box{b.minX, midY, midX, b.maxY}, depth: q.depth + 1},
+		NorthEast: {bounds: box{midX, midY, b.maxX, b.maxY}, depth: q.depth + 1},
+	}
+	for _, d := range q.Datapoints {
+		child := q.children[q.quadrant(d)]
+		child.Datapoints = append(child.Datapoints, d)
+		child.cardinality++
+	}
+	q.Datapoints = nil
+	for _, child := range q.children {
+		qt.split(child)
+	}
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+// Quads whose subtree falls back within capacity are merged into a single leaf.
+func (qt *Quadtree) Delete(d *kdtree.Datapoint) bool {
+	if _, ok := qt.held[d]; !ok {
+		return false
+	}
+	if !qt.root.delete(d, qt.capacity) {
+		return false
+	}
+	delete(qt.held, d)
+	qt.size--
+	return true
+}
+
+func (q *Quad) delete(d *kdtree.Datapoint, capacity int) bool {
+	if q.children == nil {
+		for i, p := range q.Datapoints {
+			if p == d {
+				q.Datapoints = append(q.Datapoints[:i], q.Datapoints[i+1:]...)
+				q.cardinality--
+				return true
+			}
+		}
+		return false
+	}
+	if !q.children[q.quadrant(d)].delete(d, capacity) {
+		return false
+	}
+	q.cardinality--
+	if q.cardinality <= capacity {
+		q.Datapoints = q.collect(make(kdtree.Datapoints, 0, q.cardinality))
+		q.children = nil
+	}
+	return true
+}
+
+func (q *Quad) collect(ds kdtree.Datapoints) kdtree.Datapoints {
+	if q.children == nil {
+		return append(ds, q.Datapoints...)
+	}
+	for _, child := range q.children {
+		ds = child.collect(ds)
+	}
+	return ds
+}
+
+// NN returns the nearest neighbour of the target, or nil if the Quadtree is empty.
+func (qt *Quadtree) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := qt.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k nearest neighbours of the target, nearest first.
+// Quads are visited closest first and skipped once they cannot hold a better candidate.
+func (qt *Quadtree) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if qt.size == 0 || k <= 0 || target == nil || target.Dimensionality() != 2 {
+		return nil
+	}
+	set := nearest.New(k)
+	qt.root.knn(target.At(0), target.At(1), target, set)
+	sorted := set.Sorted()
+	ds := make(kdtree.Datapoints, len(sorted))
+	for i := range sorted {
+		ds[i] = sorted[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
+
+func (q *Quad) knn(x, y float64, target *kdtree.Datapoint, set *nearest.Set) {
+	if q.children == nil {
+		for _, d := range q.Datapoints {
+			set.Push(d, kdtree.DistanceSq(target, d))
+		}
+		return
+	}
+	var order [4]int
+	var dist [4]float64
+	for i, child := range q.children {
+		order[i], dist[i] = i, child.bounds.distanceSq(x, y)
+	}
+	// insertion sort of four children by distance
+	for i := 1; i < 4; i++ {
+		for j := i; j > 0 && dist[order[j]] < dist[order[j-1]]; j-- {
+			order[j], order[j-1] = order[j-1], order[j]
+		}
+	}
+	for _, i := range order {
+		child := q.children[i]
+		if child.cardinality > 0 && dist[i] < set.Worst() {
+			child.knn(x, y, target, set)
+		}
+	}
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (qt *Quadtree) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	if len(bounds) != 2 {
+		return nil
+	}
+	query := box{bounds[0].Min(), bounds[1].Min(), bounds[0].Max(), bounds[1].Max()}
+	var found kdtree.Datapoints
+	qt.root.rangeSearch(query, bounds, &found)
+	return found
+}
+
+func (q *Quad) rangeSearch(query box, bounds []kdtree.Range, found *kdtree.Datapoints) {
+	if q.cardinality == 0 || !q.bounds.intersects(query) {
+		return
+	}
+	if q.children == nil {
+		for _, d := range q.Datapoints {
+			if kdtree.InBounds(d, bounds) {
+				*found = append(*found, d)
+			}
+		}
+		return
+	}
+	for _, child := range q.children {
+		child.rangeSearch(query, bounds, found)
+	}
+}
+
+// MaxDepth returns the depth of the deepest leaf Quad.
+func (q *Quad) MaxDepth() int {
+	if q.children == nil {
+		return q.depth
+	}
+	deepest := q.depth
+	for _, child := range q.children {
+		if d := child.MaxDepth(); d > deepest {
+			deepest = d
+		}
+	}
+	return deepest
+}
+
+// Bounds returns the region covered by the Quad.
+func (q *Quad) Bounds() []kdtree.Range {
+	return []kdtree.Range{
+		kdtree.NewRange(q.bounds.minX, q.bounds.maxX),
+		kdtree.NewRange(q.bounds.minY, q.bounds.maxY),
+	}
+}
+
+// Child returns the Quad for one of the four quadrants, or nil at a leaf.
+func (q *Quad) Child(quadrant int) *Quad {
+	if q.children == nil {
+		return nil
+	}
+	return q.children[quadrant]
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (q *Quad) MarshalJSON() ([]byte, error) {
+	representation := map[string]interface{}{
+		"Depth":       q.depth,
+		"Cardinality": q.cardinality,
+		"Datapoints":  q.Datapoints,
+		"Bounds":      [2][2]float64{{q.bounds.minX, q.bounds.maxX}, {q.bounds.minY, q.bounds.maxY}},
+		"southWest":   nil,
+		"southEast":   nil,
+		"northWest":   nil,
+		"northEast":   nil,
+	}
+	if q.children != nil {
+		representation["southWest"] = q.children[SouthWest]
+		representation["southEast"] = q.children[SouthEast]
+		representation["northWest"] = q.children[NorthWest]
+		representation["northEast"] = q.children[NorthEast]
+	}
+	return json.Marshal(representation)
+}
+
+// MarshalJSON implements json.Marshaler interface
+func (qt *Quadtree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(qt.root)
+}
+
+func (q *Quad) quadrant(d *kdtree.Datapoint) int {
+	midX, midY := q.bounds.centre()
+	quadrant := SouthWest
+	if d.At(0) >= midX {
+		quadrant++
+	}
+	if d.At(1) >= midY {
+		quadrant += 2
+	}
+	return quadrant
+}
+
+func (b box) centre() (float64, float64) {
+	return (b.minX + b.maxX) / 2, (b.minY + b.maxY) / 2
+}
+
+func (b box) contains(x, y float64) bool {
+	return x >= b.minX && x <= b.maxX && y >= b.minY && y <= b.maxY
+}
+
+func (b box) intersects(o box) bool {
+	return b.minX <= o.maxX && o.minX <= b.maxX && b.minY <= o.maxY && o.minY <= b.maxY
+}
+
+// distanceSq returns the squared distance from (x,y) to the nearest point of the box.
+func (b box) distanceSq(x, y float64) float64 {
+	dx := math.Max(0, math.Max(b.minX-x, x-b.maxX))
+	dy := math.Max(0, math.Max(b.minY-y, y-b.maxY))
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,163 @@
+package quadtree
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func Test_Quadtree_json_Marshaller_Interface(t *testing.T) {
+	fixtures := []struct {
+		ds       kdtree.Datapoints
+		capacity int
+		maxDepth int
+		file     string
+	}{
+		{dps3, 2, 0, "test_fixtures/dps3_capacity2.json"},
+		{nonDistinctDps, 1, 3, "test_fixtures/nonDistinctDatapoints_capacity1_depth3.json"},
+	}
+
+	for _, f := range fixtures {
+		qt, err := Build(f.ds, unitBounds, f.capacity, f.maxDepth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.MarshalIndent(qt, "", "    ")
+		if err != nil {
+			t.Error(err)
+		}
+		want, err := ioutil.ReadFile(f.file)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(got) != string(want) {
+			t.Error(f.file, ` want: `, string(want), `
+			got: `, string(got))
+		}
+	}
+}
+
+func Test_Quadtree_MaxDepth(t *testing.T) {
+	qt, _ := Build(nonDistinctDps, unitBounds, 1, 3)
+	want := 3
+	got := qt.Root().MaxDepth()
+	if got != want {
+		t.Error(`want: `, want, `
+		got: `, got)
+	}
+}
+
+func Test_Quadtree_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(52))
+	var ds kdtree.Datapoints
+	for i := 0; i < 500; i++ {
+		ds = append(ds, kdtree.NewDatapoint(i, []float64{float64(r.Intn(100)) / 10, float64(r.Intn(100)) / 10}))
+	}
+	qt, err := Build(ds, unitBounds, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linear, _ := kdtree.NewLinear(ds)
+	for i := 0; i < len(ds); i += 3 {
+		if qt.Delete(ds[i]) != linear.Delete(ds[i]) {
+			t.Fatal(`Delete disagreed for `, ds[i])
+		}
+	}
+	if qt.Delete(ds[0]) {
+		t.Error(`Delete should fail for a Datapoint already removed`)
+	}
+	if qt.Len() != linear.Len() || qt.Root().cardinality != linear.Len() {
+		t.Fatal(`want: `, linear.Len(), `
+		got: `, qt.Len())
+	}
+
+	for q := 0; q < 100; q++ {
+		target := kdtree.NewDatapoint(nil, []float64{r.Float64() * 12, r.Float64()*12 - 1})
+		got, want := qt.KNN(target, 5), linear.KNN(target, 5)
+		for i := range want {
+			if kdtree.DistanceSq(target, got[i]) != kdtree.DistanceSq(target, want[i]) {
+				t.Error(`KNN want: `, want.PointsSetString(), `
+				got: `, got.PointsSetString())
+				break
+			}
+		}
+		lo, hi := r.Float64()*10, r.Float64()*10
+		bounds := []kdtree.Range{kdtree.NewRange(lo, lo+3), kdtree.NewRange(hi, hi+1)}
+		if len(qt.Range(bounds)) != len(linear.Range(bounds)) {
+			t.Error(`Range disagreed for `, bounds)
+		}
+	}
+}
+
+func Test_Quadtree_Delete_Merges_Quads(t *testing.T) {
+	qt, _ := Build(dps3, unitBounds, 2, 0)
+	for _, d := range dps3[2:] {
+		qt.Delete(d)
+	}
+	want := dps3[:2]
+	if qt.Root().Child(SouthWest) != nil || len(qt.Root().Datapoints) != 2 {
+		t.Error(`want a single leaf holding `, want.PointsSetString(), `
+		got: `, qt.Root().Datapoints)
+	}
+}
+
+func Test_Quadtree_Insert_Errors(t *testing.T) {
+	qt, _ := New(unitBounds, 0, 0)
+	if err := qt.Insert(kdtree.NewDatapoint(nil, []float64{11, 5})); err != ErrOutOfBounds {
+		t.Error(`want: `, ErrOutOfBounds, `
+		got: `, err)
+	}
+	if err := qt.Insert(kdtree.NewDatapoint(nil, []float64{1, 2, 3})); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
+
+func Test_Quadtree_Reinsert_And_Nil_Target(t *testing.T) {
+	qt, _ := New(unitBounds, 1, 0)
+	p := kdtree.NewDatapoint(nil, []float64{1, 2})
+	for i := 0; i < 3; i++ {
+		if err := qt.Insert(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if qt.Len() != 1 || qt.Root().Child(SouthWest) != nil || len(qt.Root().Datapoints) != 1 {
+		t.Error(`inserting a held Datapoint again should have no effect`)
+	}
+	if qt.NN(nil) != nil || qt.KNN(nil, 1) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	if !qt.Delete(p) || qt.Delete(p) || qt.Len() != 0 {
+		t.Error(`Delete should remove the Datapoint exactly once`)
+	}
+}
+
+type city struct {
+	name     string
+	lon, lat float64
+}
+
+func (c *city) ToDatapoint() *kdtree.Datapoint {
+	return kdtree.NewDatapoint(c, []float64{c.lon, c.lat})
+}
+
+func Test_Quadtree_Convert_with_Importable_Interface(t *testing.T) {
+	cities := []kdtree.Importable{
+		&city{"Auckland", 174.76, -36.85},
+		&city{"Wellington", 174.78, -41.29},
+		&city{"Christchurch", 172.64, -43.53},
+	}
+	bounds := []kdtree.Range{kdtree.NewRange(-180, 180), kdtree.NewRange(-90, 90)}
+	qt, err := Convert(cities, bounds, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := qt.NN(kdtree.NewDatapoint(nil, []float64{175, -40}))
+	if got.Data().(*city).name != "Wellington" {
+		t.Error(`want: Wellington
+		got: `, got.Data())
+	}
+}
@@ -0,0 +1,31 @@
+package quadtree
+
+import "github.com/benjamin-rood/goeometric/kdtree"
+
+var (
+	unitBounds = []kdtree.Range{kdtree.NewRange(0, 10), kdtree.NewRange(0, 10)}
+
+	dps3 = kdtree.Datapoints{
+		kdtree.NewDatapoint(nil, []float64{1, 9}),
+		kdtree.NewDatapoint(nil, []float64{2, 3}),
+		kdtree.NewDatapoint(nil, []float64{4, 1}),
+		kdtree.NewDatapoint(nil, []float64{3, 7}),
+		kdtree.NewDatapoint(nil, []float64{5, 4}),
+		kdtree.NewDatapoint(nil, []float64{6, 8}),
+		kdtree.NewDatapoint(nil, []float64{7, 2}),
+		kdtree.NewDatapoint(nil, []float64{8, 8}),
+		kdtree.NewDatapoint(nil, []float64{7, 9}),
+		kdtree.NewDatapoint(nil, []float64{9, 6}),
+	}
+
+	nonDistinctDps = kdtree.Datapoints{
+		kdtree.NewDatapoint(nil, []float64{1, 9}),
+		kdtree.NewDatapoint(nil, []float64{1, 9}),
+		kdtree.NewDatapoint(nil, []float64{1, 9}),
+		kdtree.NewDatapoint(nil, []float64{1, 9}),
+		kdtree.NewDatapoint(nil, []float64{6, 8}),
+		kdtree.NewDatapoint(nil, []float64{7, 2}),
+		kdtree.NewDatapoint(nil, []float64{0, 0}),
+		kdtree.NewDatapoint(nil, []float64{10, 10}),
+	}
+)
@@ -0,0 +1,237 @@
+package rangetree
+
+import (
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// RangeTree is a static, layered orthogonal range tree.
+// The first d-2 axes are handled by nested balanced trees, each node of which
+// holds an associated tree over the following axis; the last two axes are
+// handled by a single tree whose nodes hold their Datapoints sorted by the
+// final axis, linked by fractional cascading. A query in d dimensions
+// therefore costs O(log^(d-1) n + k) for k reported Datapoints,
+// using O(n log^(d-1) n) space.
+type RangeTree struct {
+	top  *level
+	dims int
+	size int
+}
+
+// level is the structure over the Datapoints for one axis onwards.
+// On the final axis it is a plain sorted array; otherwise a balanced tree.
+type level struct {
+	axis   int
+	sorted *cascade // only on the final axis
+	root   *node
+}
+
+type node struct {
+	min, max    float64 // extent of the subtree along the level's axis
+	left, right *node
+	next        *level   // associated structure, above the last two axes
+	cascade     *cascade // on the second to last axis
+}
+
+// cascade holds Datapoints sorted along the final axis. For each position i
+// (including len), leftIdx[i] and rightIdx[i] give the first position in the
+// corresponding child's cascade whose key is >= keys[i], so a binary search
+// at the root is enough for the whole descent.
+type cascade struct {
+	points            kdtree.Datapoints
+	keys              []float64
+	leftIdx, rightIdx []int
+}
+
+// Build constructs a RangeTree over ds. The Datapoints are not copied, but ds
+// itself is not reordered. All Datapoints must share the same dimensionality.
+func Build(ds kdtree.Datapoints) (*RangeTree, error) {
+	if len(ds) == 0 {
+		return &RangeTree{}, nil
+	}
+	if ds[0] == nil {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	dims := ds[0].Dimensionality()
+	for _, d := range ds {
+		if d == nil || d.Dimensionality() != dims || dims == 0 {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+	}
+	points := make(kdtree.Datapoints, len(ds))
+	copy(points, ds)
+	return &RangeTree{
+		top:  buildLevel(points, 0, dims),
+		dims: dims,
+		size: len(ds),
+	}, nil
+}
+
+// Len returns the number of Datapoints held.
+func (rt *RangeTree) Len() int {
+	return rt.size
+}
+
+// Dims returns the dimensionality of the Datapoints held.
+func (rt *RangeTree) Dims() int {
+	return rt.dims
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (rt *RangeTree) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	if rt.top == nil || len(bounds) != rt.dims {
+		return nil
+	}
+	var found kdtree.Datapoints
+	rt.top.query(bounds, func(ds kdtree.Datapoints) {
+		found = append(found, ds...)
+	})
+	return found
+}
+
+// Count returns the number of Datapoints lying within the bounds (inclusive),
+// in O(log^(d-1) n) without reporting them.
+func (rt *RangeTree) Count(bounds []kdtree.Range) int {
+	if rt.top == nil || len(bounds) != rt.dims {
+		return 0
+	}
+	var count int
+	rt.top.query(bounds, func(ds kdtree.Datapoints) {
+		count += len(ds)
+	})
+	return count
+}
+
+func buildLevel(ds kdtree.Datapoints, axis, dims int) *level {
+	l := &level{axis: axis}
+	if axis == dims-1 {
+		l.sorted = sortedCascade(ds, axis)
+		return l
+	}
+	kdtree.By(kdtree.Comparator(axis)).Sort(ds)
+	l.root = buildNode(ds, axis, dims)
+	return l
+}
+
+// buildNode builds a balanced tree over ds, which must be sorted along axis.
+func buildNode(ds kdtree.Datapoints, axis, dims int) *node {
+	n := &node{min: ds[0].At(axis), max: ds[len(ds)-1].At(axis)}
+	if len(ds) > 1 {
+		mid := len(ds) / 2
+		n.left = buildNode(ds[:mid], axis, dims)
+		n.right = buildNode(ds[mid:], axis, dims)
+	}
+	if axis == dims-2 {
+		if n.left == nil {
+			n.cascade = sortedCascade(ds, dims-1)
+		} else {
+			n.cascade = mergeCascades(n.left.cascade, n.right.cascade)
+		}
+		return n
+	}
+	associated := make(kdtree.Datapoints, len(ds))
+	copy(associated, ds)
+	n.next = buildLevel(associated, axis+1, dims)
+	return n
+}
+
+func sortedCascade(ds kdtree.Datapoints, axis int) *cascade {
+	c := &cascade{
+		points: make(kdtree.Datapoints, len(ds)),
+		keys:   make([]float64, len(ds)),
+	}
+	copy(c.points, ds)
+	kdtree.By(kdtree.Comparator(axis)).Sort(c.points)
+	for i, d := range c.points {
+		c.keys[i] = d.At(axis)
+	}
+	return c
+}
+
+func mergeCascades(left, right *cascade) *cascade {
+	sz := len(left.points) + len(right.points)
+	c := &cascade{
+		points:   make(kdtree.Datapoints, 0, sz),
+		keys:     make([]float64, 0, sz),
+		leftIdx:  make([]int, sz+1),
+		rightIdx: make([]int, sz+1),
+	}
+	i, j := 0, 0
+	for i < len(left.points) || j < len(right.points) {
+		if j == len(right.points) || (i < len(left.points) && left.keys[i] <= right.keys[j]) {
+			c.points, c.keys = append(c.points, left.points[i]), append(c.keys, left.keys[i])
+			i++
+		} else {
+			c.points, c.keys = append(c.points, right.points[j]), append(c.keys, right.keys[j])
+			j++
+		}
+	}
+	i, j = 0, 0
+	for k, key := range c.keys {
+		for i < len(left.keys) && left.keys[i] < key {
+			i++
+		}
+		for j < len(right.keys) && right.keys[j] < key {
+			j++
+		}
+		c.leftIdx[k], c.rightIdx[k] = i, j
+	}
+	c.leftIdx[sz], c.rightIdx[sz] = len(left.keys), len(right.keys)
+	return c
+}
+
+// span returns the positions [lo,hi) of the keys lying within r.
+func (c *cascade) span(r kdtree.Range) (int, int) {
+	lo := sort.SearchFloat64s(c.keys, r.Min())
+	hi := sort.Search(len(c.keys), func(i int) bool {
+		return c.keys[i] > r.Max()
+	})
+	return lo, hi
+}
+
+func (l *level) query(bounds []kdtree.Range, report func(kdtree.Datapoints)) {
+	if l.sorted != nil {
+		lo, hi := l.sorted.span(bounds[l.axis])
+		if lo < hi {
+			report(l.sorted.points[lo:hi])
+		}
+		return
+	}
+	if l.root.cascade != nil {
+		lo, hi := l.root.cascade.span(bounds[l.axis+1])
+		l.root.cascaded(bounds[l.axis], lo, hi, report)
+		return
+	}
+	l.root.nested(bounds, l.axis, report)
+}
+
+// nested reports the canonical subtrees along axis, querying the associated
+// structure of each on the remaining axes.
+func (n *node) nested(bounds []kdtree.Range, axis int, report func(kdtree.Datapoints)) {
+	r := bounds[axis]
+	if n.max < r.Min() || n.min > r.Max() {
+		return
+	}
+	if r.Min() <= n.min && n.max <= r.Max() {
+		n.next.query(bounds, report)
+		return
+	}
+	n.left.nested(bounds, axis, report)
+	n.right.nested(bounds, axis, report)
+}
+
+// cascaded reports the canonical subtrees along r, where [lo,hi) are the
+// positions in the node's cascade lying within the final axis' range.
+func (n *node) cascaded(r kdtree.Range, lo, hi int, report func(kdtree.Datapoints)) {
+	if lo >= hi || n.max < r.Min() || n.min > r.Max() {
+		return
+	}
+	if r.Min() <= n.min && n.max <= r.Max() {
+		report(n.cascade.points[lo:hi])
+		return
+	}
+	c := n.cascade
+	n.left.cascaded(r, c.leftIdx[lo], c.leftIdx[hi], report)
+	n.right.cascaded(r, c.rightIdx[lo], c.rightIdx[hi], report)
+}
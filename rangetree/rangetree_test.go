@@ -0,0 +1,99 @@
+package rangetree
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/testutil"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func randomBounds(r *rand.Rand, dims int) []kdtree.Range {
+	bounds := make([]kdtree.Range, dims)
+	for axis := range bounds {
+		lo := float64(r.Intn(45)) - 5
+		bounds[axis] = kdtree.NewRange(lo, lo+float64(r.Intn(25)))
+	}
+	return bounds
+}
+
+func Test_RangeTree_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(54))
+	for dims := 1; dims <= 4; dims++ {
+		ds := testutil.RandomDatapoints(r, 400, dims, testutil.Coarse(40, 1))
+		rt, err := Build(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		linear, _ := kdtree.NewLinear(ds)
+		for q := 0; q < 200; q++ {
+			bounds := randomBounds(r, dims)
+			want := linear.Range(bounds)
+			got := rt.Range(bounds)
+			seen := make(map[*kdtree.Datapoint]bool)
+			for _, d := range got {
+				if seen[d] || !kdtree.InBounds(d, bounds) {
+					t.Fatal(`dims=`, dims, ` duplicate or out of bounds Datapoint `, d.Set())
+				}
+				seen[d] = true
+			}
+			if len(got) != len(want) || rt.Count(bounds) != len(want) {
+				t.Error(`dims=`, dims, ` want: `, len(want), `
+				got: `, len(got), rt.Count(bounds))
+			}
+		}
+	}
+}
+
+func Test_RangeTree_Build(t *testing.T) {
+	rt, err := Build(nil)
+	if err != nil || rt.Len() != 0 || rt.Range([]kdtree.Range{kdtree.NewRange(0, 1)}) != nil {
+		t.Error(`an empty RangeTree should report nothing`)
+	}
+	ds := kdtree.Datapoints{
+		kdtree.NewDatapoint(nil, []float64{3, 1}),
+		kdtree.NewDatapoint(nil, []float64{1, 2}),
+		kdtree.NewDatapoint(nil, []float64{2}),
+	}
+	if _, err := Build(ds); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	if _, err := Build(kdtree.Datapoints{nil, ds[0]}); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	first := ds[0]
+	Build(ds[:2])
+	if ds[0] != first {
+		t.Error(`Build should not reorder its input`)
+	}
+}
+
+func Benchmark_RangeTree_Range_3D(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	ds := make(kdtree.Datapoints, 20000)
+	for i := range ds {
+		ds[i] = kdtree.RandomDatapointInRange(3, 0, 1000)
+	}
+	rt, _ := Build(ds)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lo := r.Float64() * 900
+		rt.Range([]kdtree.Range{kdtree.NewRange(lo, lo+100), kdtree.NewRange(lo, lo+100), kdtree.NewRange(0, 1000)})
+	}
+}
+
+func Benchmark_KdTree_Range_3D(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	ds := make(kdtree.Datapoints, 20000)
+	for i := range ds {
+		ds[i] = kdtree.RandomDatapointInRange(3, 0, 1000)
+	}
+	tree, _ := kdtree.NewTree(ds, kdtree.Median)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lo := r.Float64() * 900
+		tree.Range([]kdtree.Range{kdtree.NewRange(lo, lo+100), kdtree.NewRange(lo, lo+100), kdtree.NewRange(0, 1000)})
+	}
+}
@@ -0,0 +1,86 @@
+package rtree
+
+import (
+	"math"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// BulkLoad constructs an RTree over items using Sort-Tile-Recursive packing:
+// the entries are sorted by centre along the first axis and cut into slabs,
+// each slab sorted along the next axis and cut again, and so on, until each
+// tile fills one node. The same packing is repeated for each level up.
+// The result has nearly full nodes with little overlap, and is far faster to
+// build than inserting the Items one at a time.
+func BulkLoad(items []*Item, dims, maxEntries int) (*RTree, error) {
+	t := New(dims, maxEntries)
+	entries := make([]entry, len(items))
+	for i, it := range items {
+		if it == nil || len(it.bounds.lo) != dims {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+		entries[i] = entry{bounds: it.bounds, item: it}
+	}
+	t.size = len(items)
+	if len(entries) == 0 {
+		return t, nil
+	}
+
+	level := 0
+	for {
+		var nodes []*node
+		for _, tile := range t.tile(entries, 0) {
+			n := &node{level: level, entries: make([]entry, len(tile), t.maxEntries+1)}
+			copy(n.entries, tile)
+			nodes = append(nodes, n)
+		}
+		if len(nodes) == 1 {
+			t.root = nodes[0]
+			return t, nil
+		}
+		entries = make([]entry, len(nodes))
+		for i, n := range nodes {
+			entries[i] = entry{bounds: n.bounds(), child: n}
+		}
+		level++
+	}
+}
+
+// tile recursively partitions es into groups of at most maxEntries.
+func (t *RTree) tile(es []entry, axis int) [][]entry {
+	if len(es) <= t.maxEntries {
+		return [][]entry{es}
+	}
+	leaves := math.Ceil(float64(len(es)) / float64(t.maxEntries))
+	if axis == t.dims-1 {
+		var tiles [][]entry
+		sortByCentre(es, axis)
+		for i := 0; i < len(es); i += t.maxEntries {
+			end := i + t.maxEntries
+			if end > len(es) {
+				end = len(es)
+			}
+			tiles = append(tiles, es[i:end])
+		}
+		return tiles
+	}
+	slabs := math.Ceil(math.Pow(leaves, 1/float64(t.dims-axis)))
+	slabSize := int(math.Ceil(leaves/slabs)) * t.maxEntries
+	sortByCentre(es, axis)
+	var tiles [][]entry
+	for i := 0; i < len(es); i += slabSize {
+		end := i + slabSize
+		if end > len(es) {
+			end = len(es)
+		}
+		tiles = append(tiles, t.tile(es[i:end], axis+1)...)
+	}
+	return tiles
+}
+
+func sortByCentre(es []entry, axis int) {
+	sort.SliceStable(es, func(i, j int) bool {
+		return es[i].bounds.centre(axis) < es[j].bounds.centre(axis)
+	})
+}
@@ -0,0 +1,116 @@
+package rtree
+
+import "github.com/benjamin-rood/goeometric/kdtree"
+
+// Points adapts an RTree to hold Datapoints as degenerate Items, so that it
+// can be used anywhere a kdtree.SpatialIndex is expected.
+type Points struct {
+	tree  *RTree
+	items map[*kdtree.Datapoint]*Item
+}
+
+var _ kdtree.SpatialIndex = (*Points)(nil)
+
+// NewPoints constructs a Points index over ds, bulk loaded with STR.
+// All Datapoints must share the same dimensionality; any repeated are held once.
+func NewPoints(ds kdtree.Datapoints, maxEntries int) (*Points, error) {
+	dims := 0
+	if len(ds) > 0 && ds[0] != nil {
+		dims = ds[0].Dimensionality()
+	}
+	p := &Points{items: make(map[*kdtree.Datapoint]*Item, len(ds))}
+	items := make([]*Item, 0, len(ds))
+	for _, d := range ds {
+		if d == nil || d.Dimensionality() != dims {
+			return nil, kdtree.ErrDimensionMismatch
+		}
+		if _, held := p.items[d]; held {
+			continue
+		}
+		it := ItemFromDatapoint(d)
+		items = append(items, it)
+		p.items[d] = it
+	}
+	tree, err := BulkLoad(items, dims, maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	p.tree = tree
+	return p, nil
+}
+
+// Tree returns the underlying RTree.
+func (p *Points) Tree() *RTree {
+	return p.tree
+}
+
+// Len returns the number of Datapoints held.
+func (p *Points) Len() int {
+	return p.tree.Len()
+}
+
+// Dims returns the dimensionality of the Datapoints held.
+func (p *Points) Dims() int {
+	return p.tree.Dims()
+}
+
+// Insert adds a Datapoint. An empty index takes the dimensionality of the
+// first Datapoint inserted. Inserting a Datapoint which is already held has
+// no effect.
+func (p *Points) Insert(d *kdtree.Datapoint) error {
+	if d == nil {
+		return kdtree.ErrDimensionMismatch
+	}
+	if _, held := p.items[d]; held {
+		return nil
+	}
+	if p.tree.size == 0 && p.tree.dims != d.Dimensionality() {
+		p.tree = New(d.Dimensionality(), p.tree.maxEntries)
+	}
+	it := ItemFromDatapoint(d)
+	if err := p.tree.Insert(it); err != nil {
+		return err
+	}
+	p.items[d] = it
+	return nil
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+func (p *Points) Delete(d *kdtree.Datapoint) bool {
+	it, exists := p.items[d]
+	if !exists {
+		return false
+	}
+	delete(p.items, d)
+	return p.tree.Delete(it)
+}
+
+// NN returns the nearest neighbour of the target, or nil if the index is empty.
+func (p *Points) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	it := p.tree.NN(target)
+	if it == nil {
+		return nil
+	}
+	return it.data.(*kdtree.Datapoint)
+}
+
+// KNN returns the k nearest neighbours of the target, nearest first.
+func (p *Points) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	return toDatapoints(p.tree.KNN(target, k))
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (p *Points) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	return toDatapoints(p.tree.Intersecting(bounds))
+}
+
+func toDatapoints(items []*Item) kdtree.Datapoints {
+	if items == nil {
+		return nil
+	}
+	ds := make(kdtree.Datapoints, len(items))
+	for i, it := range items {
+		ds[i] = it.data.(*kdtree.Datapoint)
+	}
+	return ds
+}
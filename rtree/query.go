@@ -0,0 +1,119 @@
+package rtree
+
+import (
+	"container/heap"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Intersecting returns every Item whose bounds intersect the query bounds.
+func (t *RTree) Intersecting(bounds []kdtree.Range) []*Item {
+	if len(bounds) != t.dims {
+		return nil
+	}
+	q := toRect(bounds)
+	var found []*Item
+	t.search(t.root, q.intersects, q.intersects, &found)
+	return found
+}
+
+// Within returns every Item whose bounds lie entirely within the query bounds.
+func (t *RTree) Within(bounds []kdtree.Range) []*Item {
+	if len(bounds) != t.dims {
+		return nil
+	}
+	q := toRect(bounds)
+	var found []*Item
+	t.search(t.root, q.intersects, q.contains, &found)
+	return found
+}
+
+// Containing returns every Item whose bounds entirely contain the query bounds,
+// e.g. the footprints containing a single location.
+func (t *RTree) Containing(bounds []kdtree.Range) []*Item {
+	if len(bounds) != t.dims {
+		return nil
+	}
+	q := toRect(bounds)
+	contains := func(r rect) bool { return r.contains(q) }
+	var found []*Item
+	t.search(t.root, contains, contains, &found)
+	return found
+}
+
+// search descends into entries satisfying branch, and reports Items satisfying leaf.
+func (t *RTree) search(n *node, branch, leaf func(rect) bool, found *[]*Item) {
+	for _, e := range n.entries {
+		if n.level == 0 {
+			if leaf(e.bounds) {
+				*found = append(*found, e.item)
+			}
+		} else if branch(e.bounds) {
+			t.search(e.child, branch, leaf, found)
+		}
+	}
+}
+
+// NN returns the Item nearest the target, measured to the closest point of its bounds,
+// or nil if the RTree is empty.
+func (t *RTree) NN(target *kdtree.Datapoint) *Item {
+	items := t.KNN(target, 1)
+	if len(items) == 0 {
+		return nil
+	}
+	return items[0]
+}
+
+// KNN returns the k Items nearest the target, nearest first, measured to the
+// closest point of their bounds. Nodes are explored best-first in order of
+// their distance from the target, so only the nodes which could hold one of
+// the k results are opened.
+func (t *RTree) KNN(target *kdtree.Datapoint, k int) []*Item {
+	if t.size == 0 || k <= 0 || target == nil || target.Dimensionality() != t.dims {
+		return nil
+	}
+	queue := &frontier{{dist: 0, n: t.root}}
+	var found []*Item
+	for queue.Len() > 0 && len(found) < k {
+		c := heap.Pop(queue).(candidate)
+		if c.n == nil {
+			found = append(found, c.item)
+			continue
+		}
+		for _, e := range c.n.entries {
+			next := candidate{dist: e.bounds.distanceSq(target)}
+			if c.n.level == 0 {
+				next.item = e.item
+			} else {
+				next.n = e.child
+			}
+			heap.Push(queue, next)
+		}
+	}
+	return found
+}
+
+type candidate struct {
+	dist float64
+	n    *node
+	item *Item
+}
+
+type frontier []candidate
+
+func (f frontier) Len() int { return len(f) }
+func (f frontier) Less(i, j int) bool {
+	if f[i].dist == f[j].dist {
+		return f[i].n == nil && f[j].n != nil // Items before nodes at equal distance
+	}
+	return f[i].dist < f[j].dist
+}
+func (f frontier) Swap(i, j int)       { f[i], f[j] = f[j], f[i] }
+func (f *frontier) Push(x interface{}) { *f = append(*f, x.(candidate)) }
+func (f *frontier) Pop() interface{} {
+	old := *f
+	n := len(old)
+	x := old[n-1]
+	*f = old[:n-1]
+	return x
+}
@@ -0,0 +1,130 @@
+package rtree
+
+import (
+	"math"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// rect is an axis-aligned box, closed on every side.
+type rect struct {
+	lo, hi []float64
+}
+
+func toRect(bounds []kdtree.Range) rect {
+	r := rect{make([]float64, len(bounds)), make([]float64, len(bounds))}
+	for axis, b := range bounds {
+		r.lo[axis], r.hi[axis] = b.Min(), b.Max()
+	}
+	return r
+}
+
+func (r rect) ranges() []kdtree.Range {
+	bounds := make([]kdtree.Range, len(r.lo))
+	for axis := range bounds {
+		bounds[axis] = kdtree.NewRange(r.lo[axis], r.hi[axis])
+	}
+	return bounds
+}
+
+func (r rect) clone() rect {
+	c := rect{make([]float64, len(r.lo)), make([]float64, len(r.hi))}
+	copy(c.lo, r.lo)
+	copy(c.hi, r.hi)
+	return c
+}
+
+// union returns the smallest rect covering both r and s.
+func (r rect) union(s rect) rect {
+	u := r.clone()
+	u.extend(s)
+	return u
+}
+
+func (r *rect) extend(s rect) {
+	for axis := range r.lo {
+		r.lo[axis] = math.Min(r.lo[axis], s.lo[axis])
+		r.hi[axis] = math.Max(r.hi[axis], s.hi[axis])
+	}
+}
+
+func (r rect) area() float64 {
+	a := 1.0
+	for axis := range r.lo {
+		a *= r.hi[axis] - r.lo[axis]
+	}
+	return a
+}
+
+// margin is the sum of the edge lengths, the R*-tree's measure of squareness.
+func (r rect) margin() float64 {
+	var m float64
+	for axis := range r.lo {
+		m += r.hi[axis] - r.lo[axis]
+	}
+	return m
+}
+
+func (r rect) overlap(s rect) float64 {
+	a := 1.0
+	for axis := range r.lo {
+		side := math.Min(r.hi[axis], s.hi[axis]) - math.Max(r.lo[axis], s.lo[axis])
+		if side <= 0 {
+			return 0
+		}
+		a *= side
+	}
+	return a
+}
+
+func (r rect) intersects(s rect) bool {
+	for axis := range r.lo {
+		if r.lo[axis] > s.hi[axis] || s.lo[axis] > r.hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// contains reports whether s lies entirely within r.
+func (r rect) contains(s rect) bool {
+	for axis := range r.lo {
+		if s.lo[axis] < r.lo[axis] || s.hi[axis] > r.hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r rect) equal(s rect) bool {
+	for axis := range r.lo {
+		if r.lo[axis] != s.lo[axis] || r.hi[axis] != s.hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r rect) centre(axis int) float64 {
+	return (r.lo[axis] + r.hi[axis]) / 2
+}
+
+// distanceSq returns the squared distance from p to the nearest point of r.
+func (r rect) distanceSq(p *kdtree.Datapoint) float64 {
+	var sq float64
+	for axis := range r.lo {
+		x := p.At(axis)
+		d := math.Max(0, math.Max(r.lo[axis]-x, x-r.hi[axis]))
+		sq += d * d
+	}
+	return sq
+}
+
+func (r rect) centreDistanceSq(s rect) float64 {
+	var sq float64
+	for axis := range r.lo {
+		d := r.centre(axis) - s.centre(axis)
+		sq += d * d
+	}
+	return sq
+}
@@ -0,0 +1,369 @@
+package rtree
+
+import (
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Defaults used when a non-positive maximum node size is given.
+const (
+	DefaultMaxEntries = 16
+	minMaxEntries     = 4
+)
+
+// Item is an axis-aligned bounding box with an associated payload: the
+// extended-object counterpart of a Datapoint.
+type Item struct {
+	data   interface{}
+	bounds rect
+}
+
+// NewItem constructs an Item covering the bounds, one Range per axis.
+func NewItem(data interface{}, bounds []kdtree.Range) *Item {
+	return &Item{data: data, bounds: toRect(bounds)}
+}
+
+// ItemFromDatapoint constructs a degenerate Item covering a single Datapoint,
+// carrying the Datapoint as its data.
+func ItemFromDatapoint(d *kdtree.Datapoint) *Item {
+	set := d.Set()
+	return &Item{data: d, bounds: rect{set, d.Set()}}
+}
+
+// Data returns the payload associated with the Item.
+func (it *Item) Data() interface{} {
+	return it.data
+}
+
+// Bounds returns a copy of the Item's bounding box.
+func (it *Item) Bounds() []kdtree.Range {
+	return it.bounds.ranges()
+}
+
+// RTree is an R*-tree over Items.
+// Insertion chooses subtrees by least overlap enlargement at the level above
+// the leaves and least area enlargement elsewhere, and an overflowing node is
+// first relieved by reinserting its outermost entries (once per level per
+// insertion) before it is split along the axis of least margin.
+type RTree struct {
+	root       *node
+	dims       int
+	size       int
+	maxEntries int
+	minEntries int
+}
+
+type node struct {
+	level   int // 0 at the leaves
+	entries []entry
+}
+
+type entry struct {
+	bounds rect
+	child  *node // nil at the leaves
+	item   *Item
+}
+
+// New constructs an empty RTree over dims dimensions, with at most
+// maxEntries entries per node (DefaultMaxEntries when non-positive).
+func New(dims, maxEntries int) *RTree {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if maxEntries < minMaxEntries {
+		maxEntries = minMaxEntries
+	}
+	return &RTree{
+		root:       &node{},
+		dims:       dims,
+		maxEntries: maxEntries,
+		minEntries: maxEntries * 2 / 5,
+	}
+}
+
+// Len returns the number of Items held.
+func (t *RTree) Len() int {
+	return t.size
+}
+
+// Dims returns the dimensionality of the RTree.
+func (t *RTree) Dims() int {
+	return t.dims
+}
+
+// Bounds returns the bounding box of every Item held, or nil when empty.
+func (t *RTree) Bounds() []kdtree.Range {
+	if t.size == 0 {
+		return nil
+	}
+	return t.root.bounds().ranges()
+}
+
+// Height returns the number of levels in the tree.
+func (t *RTree) Height() int {
+	return t.root.level + 1
+}
+
+func (n *node) bounds() rect {
+	r := n.entries[0].bounds.clone()
+	for _, e := range n.entries[1:] {
+		r.extend(e.bounds)
+	}
+	return r
+}
+
+// Insert adds an Item.
+func (t *RTree) Insert(it *Item) error {
+	if it == nil || len(it.bounds.lo) != t.dims {
+		return kdtree.ErrDimensionMismatch
+	}
+	t.insert(entry{bounds: it.bounds, item: it}, 0, make(map[int]bool))
+	t.size++
+	return nil
+}
+
+// insert places e in a node at the given level, then resolves overflow
+// from that node back up to the root.
+func (t *RTree) insert(e entry, level int, reinserted map[int]bool) {
+	path := []*node{t.root}
+	for n := t.root; n.level > level; {
+		n = n.entries[t.chooseSubtree(n, e.bounds)].child
+		path = append(path, n)
+	}
+	target := path[len(path)-1]
+	target.entries = append(target.entries, e)
+
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		if len(n.entries) > t.maxEntries {
+			if i > 0 && !reinserted[n.level] {
+				reinserted[n.level] = true
+				removed := t.pickReinsert(n)
+				t.refit(path[:i+1])
+				for _, r := range removed {
+					t.insert(r, n.level, reinserted)
+				}
+				return
+			}
+			sibling := t.split(n)
+			if i == 0 {
+				t.root = &node{level: n.level + 1, entries: []entry{
+					{bounds: n.bounds(), child: n},
+					{bounds: sibling.bounds(), child: sibling},
+				}}
+				return
+			}
+			path[i-1].entries = append(path[i-1].entries, entry{bounds: sibling.bounds(), child: sibling})
+		}
+		if i > 0 {
+			path[i-1].setChildBounds(n)
+		}
+	}
+}
+
+// refit recomputes the bounds of each node's entry in its parent, from the bottom of the path up.
+func (t *RTree) refit(path []*node) {
+	for i := len(path) - 1; i > 0; i-- {
+		path[i-1].setChildBounds(path[i])
+	}
+}
+
+func (n *node) setChildBounds(child *node) {
+	for i := range n.entries {
+		if n.entries[i].child == child {
+			n.entries[i].bounds = child.bounds()
+			return
+		}
+	}
+}
+
+func (t *RTree) chooseSubtree(n *node, r rect) int {
+	best := 0
+	if n.level == 1 {
+		// children are leaves: minimise overlap enlargement
+		bestOverlap, bestEnlargement, bestArea := -1.0, 0.0, 0.0
+		for i, e := range n.entries {
+			grown := e.bounds.union(r)
+			var overlap float64
+			for j, o := range n.entries {
+				if j != i {
+					overlap += grown.overlap(o.bounds) - e.bounds.overlap(o.bounds)
+				}
+			}
+			enlargement, area := grown.area()-e.bounds.area(), e.bounds.area()
+			if bestOverlap < 0 || overlap < bestOverlap ||
+				(overlap == bestOverlap && (enlargement < bestEnlargement ||
+					(enlargement == bestEnlargement && area < bestArea))) {
+				best, bestOverlap, bestEnlargement, bestArea = i, overlap, enlargement, area
+			}
+		}
+		return best
+	}
+	bestEnlargement, bestArea := -1.0, 0.0
+	for i, e := range n.entries {
+		area := e.bounds.area()
+		enlargement := e.bounds.union(r).area() - area
+		if bestEnlargement < 0 || enlargement < bestEnlargement ||
+			(enlargement == bestEnlargement && area < bestArea) {
+			best, bestEnlargement, bestArea = i, enlargement, area
+		}
+	}
+	return best
+}
+
+// pickReinsert removes the 30% of entries whose centres lie furthest from the
+// centre of the node, returning them nearest first for a "close reinsert".
+func (t *RTree) pickReinsert(n *node) []entry {
+	centre := n.bounds()
+	sort.SliceStable(n.entries, func(i, j int) bool {
+		return n.entries[i].bounds.centreDistanceSq(centre) < n.entries[j].bounds.centreDistanceSq(centre)
+	})
+	p := len(n.entries) * 3 / 10
+	if p < 1 {
+		p = 1
+	}
+	keep := len(n.entries) - p
+	removed := make([]entry, p)
+	copy(removed, n.entries[keep:])
+	n.entries = n.entries[:keep]
+	return removed
+}
+
+// split divides an overflowing node in two, returning the new sibling.
+// The split axis is the one whose candidate distributions have the least total
+// margin; along it, the distribution with least overlap (then least area) wins.
+func (t *RTree) split(n *node) *node {
+	m, sz := t.minEntries, len(n.entries)
+	if m < 1 {
+		m = 1
+	}
+	bestAxis, bestMargin := 0, -1.0
+	for axis := 0; axis < t.dims; axis++ {
+		var margin float64
+		for _, byUpper := range []bool{false, true} {
+			sortEntries(n.entries, axis, byUpper)
+			for k := m; k <= sz-m; k++ {
+				margin += boundsOf(n.entries[:k]).margin() + boundsOf(n.entries[k:]).margin()
+			}
+		}
+		if bestMargin < 0 || margin < bestMargin {
+			bestAxis, bestMargin = axis, margin
+		}
+	}
+
+	bestK, bestUpper, bestOverlap, bestArea := m, false, -1.0, 0.0
+	for _, byUpper := range []bool{false, true} {
+		sortEntries(n.entries, bestAxis, byUpper)
+		for k := m; k <= sz-m; k++ {
+			a, b := boundsOf(n.entries[:k]), boundsOf(n.entries[k:])
+			overlap, area := a.overlap(b), a.area()+b.area()
+			if bestOverlap < 0 || overlap < bestOverlap || (overlap == bestOverlap && area < bestArea) {
+				bestK, bestUpper, bestOverlap, bestArea = k, byUpper, overlap, area
+			}
+		}
+	}
+	sortEntries(n.entries, bestAxis, bestUpper)
+	sibling := &node{level: n.level, entries: make([]entry, sz-bestK, t.maxEntries+1)}
+	copy(sibling.entries, n.entries[bestK:])
+	n.entries = n.entries[:bestK]
+	return sibling
+}
+
+func sortEntries(es []entry, axis int, byUpper bool) {
+	sort.SliceStable(es, func(i, j int) bool {
+		if byUpper {
+			return es[i].bounds.hi[axis] < es[j].bounds.hi[axis]
+		}
+		return es[i].bounds.lo[axis] < es[j].bounds.lo[axis]
+	})
+}
+
+func boundsOf(es []entry) rect {
+	r := es[0].bounds.clone()
+	for _, e := range es[1:] {
+		r.extend(e.bounds)
+	}
+	return r
+}
+
+// Delete removes the given Item, reporting whether it was held.
+// Nodes left with too few entries are dissolved and their entries reinserted.
+func (t *RTree) Delete(it *Item) bool {
+	if it == nil || len(it.bounds.lo) != t.dims {
+		return false
+	}
+	path := t.findLeaf(t.root, it, nil)
+	if path == nil {
+		return false
+	}
+	leaf := path[len(path)-1]
+	for i := range leaf.entries {
+		if leaf.entries[i].item == it {
+			leaf.entries = append(leaf.entries[:i], leaf.entries[i+1:]...)
+			break
+		}
+	}
+	t.size--
+
+	var orphans []entry
+	for i := len(path) - 1; i > 0; i-- {
+		n, parent := path[i], path[i-1]
+		if len(n.entries) < t.minEntries || len(n.entries) == 0 {
+			for j := range parent.entries {
+				if parent.entries[j].child == n {
+					parent.entries = append(parent.entries[:j], parent.entries[j+1:]...)
+					break
+				}
+			}
+			orphans = append(orphans, n.entries...)
+		} else {
+			parent.setChildBounds(n)
+		}
+	}
+	for t.root.level > 0 && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	if t.root.level > 0 && len(t.root.entries) == 0 {
+		t.root = &node{}
+	}
+	for _, o := range orphans {
+		level := 0
+		if o.child != nil {
+			level = o.child.level + 1
+		}
+		if level > t.root.level {
+			// the tree shrank beneath the orphan's level: reinsert its Items
+			t.reinsertItems(o.child)
+			continue
+		}
+		t.insert(o, level, map[int]bool{level: true})
+	}
+	return true
+}
+
+func (t *RTree) reinsertItems(n *node) {
+	for _, e := range n.entries {
+		if e.child != nil {
+			t.reinsertItems(e.child)
+		} else {
+			t.insert(e, 0, map[int]bool{0: true})
+		}
+	}
+}
+
+func (t *RTree) findLeaf(n *node, it *Item, path []*node) []*node {
+	path = append(path, n)
+	for _, e := range n.entries {
+		if n.level == 0 {
+			if e.item == it {
+				return path
+			}
+		} else if e.bounds.contains(it.bounds) {
+			if found := t.findLeaf(e.child, it, path); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package rtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// footprints produces small axis-aligned rectangles, like building outlines on a map.
+func footprints(r *rand.Rand, n int) []*Item {
+	items := make([]*Item, n)
+	for i := range items {
+		x, y := r.Float64()*1000, r.Float64()*1000
+		items[i] = NewItem(i, []kdtree.Range{
+			kdtree.NewRange(x, x+1+r.Float64()*20),
+			kdtree.NewRange(y, y+1+r.Float64()*20),
+		})
+	}
+	return items
+}
+
+// verify checks that every node lies within its entry in the parent, that
+// all leaves are at level 0, and that no node overflows.
+func verify(t *testing.T, tree *RTree) {
+	var count int
+	var walk func(n *node, within rect)
+	walk = func(n *node, within rect) {
+		if len(n.entries) > tree.maxEntries {
+			t.Fatal(`node at level `, n.level, ` overflows with `, len(n.entries), ` entries`)
+		}
+		for _, e := range n.entries {
+			if !within.contains(e.bounds) {
+				t.Fatal(`entry escapes its parent's bounds at level `, n.level)
+			}
+			if n.level == 0 {
+				count++
+				continue
+			}
+			if e.child.level != n.level-1 || !e.bounds.equal(e.child.bounds()) {
+				t.Fatal(`inconsistent child at level `, n.level)
+			}
+			walk(e.child, e.bounds)
+		}
+	}
+	if tree.size > 0 {
+		walk(tree.root, tree.root.bounds())
+	}
+	if count != tree.Len() {
+		t.Fatal(`want: `, tree.Len(), ` Items
+		got: `, count)
+	}
+}
+
+func ids(items []*Item) []int {
+	var s []int
+	for _, it := range items {
+		s = append(s, it.Data().(int))
+	}
+	sort.Ints(s)
+	return s
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_RTree_Queries_Match_Brute_Force(t *testing.T) {
+	r := rand.New(rand.NewSource(58))
+	items := footprints(r, 1500)
+	inserted := New(2, 8)
+	for _, it := range items {
+		if err := inserted.Insert(it); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bulk, err := BulkLoad(items, 2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := make(map[*Item]bool)
+	for _, it := range items {
+		live[it] = true
+	}
+	for i := 0; i < len(items); i += 3 {
+		if !inserted.Delete(items[i]) || !bulk.Delete(items[i]) {
+			t.Fatal(`failed to delete `, items[i].Data())
+		}
+		delete(live, items[i])
+	}
+
+	for name, tree := range map[string]*RTree{"inserted": inserted, "bulk": bulk} {
+		verify(t, tree)
+		for q := 0; q < 100; q++ {
+			x, y := r.Float64()*1000, r.Float64()*1000
+			query := []kdtree.Range{kdtree.NewRange(x, x+r.Float64()*100), kdtree.NewRange(y, y+r.Float64()*100)}
+			qr := toRect(query)
+			var intersecting, within, containing []*Item
+			for it := range live {
+				if it.bounds.intersects(qr) {
+					intersecting = append(intersecting, it)
+				}
+				if qr.contains(it.bounds) {
+					within = append(within, it)
+				}
+				if it.bounds.contains(toRect([]kdtree.Range{kdtree.NewRange(x, x), kdtree.NewRange(y, y)})) {
+					containing = append(containing, it)
+				}
+			}
+			if !sameIDs(ids(tree.Intersecting(query)), ids(intersecting)) {
+				t.Error(name, ` Intersecting disagreed for `, query)
+			}
+			if !sameIDs(ids(tree.Within(query)), ids(within)) {
+				t.Error(name, ` Within disagreed for `, query)
+			}
+			if !sameIDs(ids(tree.Containing([]kdtree.Range{kdtree.NewRange(x, x), kdtree.NewRange(y, y)})), ids(containing)) {
+				t.Error(name, ` Containing disagreed at `, x, y)
+			}
+
+			target := kdtree.NewDatapoint(nil, []float64{x, y})
+			got := tree.KNN(target, 5)
+			var dists []float64
+			for it := range live {
+				dists = append(dists, it.bounds.distanceSq(target))
+			}
+			sort.Float64s(dists)
+			for i := range got {
+				if got[i].bounds.distanceSq(target) != dists[i] {
+					t.Error(name, ` KNN disagreed at rank `, i)
+				}
+			}
+		}
+	}
+}
+
+func Test_RTree_Delete_Everything(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	items := footprints(r, 300)
+	tree, _ := BulkLoad(items, 2, 4)
+	for _, i := range r.Perm(len(items)) {
+		if !tree.Delete(items[i]) {
+			t.Fatal(`failed to delete `, i)
+		}
+		verify(t, tree)
+	}
+	if tree.Len() != 0 || tree.Height() != 1 || tree.Delete(items[0]) {
+		t.Error(`want an empty tree of height 1
+		got: `, tree.Len(), tree.Height())
+	}
+}
+
+func Test_RTree_Points_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	var ds kdtree.Datapoints
+	for i := 0; i < 500; i++ {
+		ds = append(ds, kdtree.NewDatapoint(i, []float64{r.Float64(), r.Float64(), r.Float64()}))
+	}
+	p, err := NewPoints(ds[:250], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linear, _ := kdtree.NewLinear(ds[:250])
+	for _, d := range ds[250:] {
+		p.Insert(d)
+		linear.Insert(d)
+	}
+	for i := 0; i < len(ds); i += 4 {
+		p.Delete(ds[i])
+		linear.Delete(ds[i])
+	}
+	if p.Len() != linear.Len() {
+		t.Fatal(`want: `, linear.Len(), `
+		got: `, p.Len())
+	}
+	for q := 0; q < 50; q++ {
+		target := kdtree.RandomDatapoint(3)
+		got, want := p.KNN(target, 3), linear.KNN(target, 3)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Error(`KNN disagreed at rank `, i)
+			}
+		}
+		bounds := []kdtree.Range{kdtree.NewRange(0, target.At(0)), kdtree.NewRange(target.At(1), 1), kdtree.NewRange(0, 0.5)}
+		if len(p.Range(bounds)) != len(linear.Range(bounds)) {
+			t.Error(`Range disagreed for `, bounds)
+		}
+	}
+}
+
+func Test_RTree_Points_Duplicate_Insert(t *testing.T) {
+	d := kdtree.NewDatapoint(nil, []float64{1, 2})
+	p, _ := NewPoints(kdtree.Datapoints{d, d}, 0)
+	if p.Len() != 1 {
+		t.Error(`want: 1
+		got: `, p.Len())
+	}
+	p.Insert(d)
+	if p.Len() != 1 {
+		t.Error(`want: 1 after inserting again
+		got: `, p.Len())
+	}
+	if p.NN(nil) != nil || p.KNN(nil, 1) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	if !p.Delete(d) || p.Len() != 0 || p.NN(d) != nil {
+		t.Error(`deleting a Datapoint inserted twice should leave nothing behind`)
+	}
+}
+
+func Test_RTree_Errors(t *testing.T) {
+	tree := New(2, 0)
+	if err := tree.Insert(NewItem(nil, []kdtree.Range{kdtree.NewRange(0, 1)})); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	if tree.NN(kdtree.NewDatapoint(nil, []float64{0, 0})) != nil || tree.Bounds() != nil {
+		t.Error(`an empty RTree should hold nothing`)
+	}
+}
+
+func Benchmark_RTree_Insert(b *testing.B) {
+	items := footprints(rand.New(rand.NewSource(1)), 10000)
+	for i := 0; i < b.N; i++ {
+		tree := New(2, 0)
+		for _, it := range items {
+			tree.Insert(it)
+		}
+	}
+}
+
+func Benchmark_RTree_BulkLoad(b *testing.B) {
+	items := footprints(rand.New(rand.NewSource(1)), 10000)
+	for i := 0; i < b.N; i++ {
+		BulkLoad(items, 2, 0)
+	}
+}
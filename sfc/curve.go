@@ -0,0 +1,163 @@
+package sfc
+
+import "errors"
+
+// ErrTooManyBits is returned when a curve is asked for fewer than one
+// dimension or bit, or for more than 64 bits of index in all.
+var ErrTooManyBits = errors.New("sfc: dimensions times bits must lie between 1 and 64")
+
+// Curve is a space-filling curve through every cell of a grid of Dims
+// dimensions with 2^Bits cells along each axis, numbering them from 0 to
+// 2^(Dims*Bits) - 1. Every aligned sub-cube of the grid with 2^k cells per
+// side is numbered by one contiguous run of the curve.
+type Curve interface {
+	Encode(cell []uint64) uint64
+	Decode(index uint64) []uint64
+	Dims() int
+	Bits() uint
+}
+
+type grid struct {
+	dims int
+	bits uint
+}
+
+func newGrid(dims int, bits uint) (grid, error) {
+	if dims < 1 || bits < 1 || uint(dims)*bits > 64 {
+		return grid{}, ErrTooManyBits
+	}
+	return grid{dims, bits}, nil
+}
+
+// Dims returns the dimensionality of the grid.
+func (g grid) Dims() int {
+	return g.dims
+}
+
+// Bits returns the number of bits along each axis of the grid.
+func (g grid) Bits() uint {
+	return g.bits
+}
+
+// interleave takes the bits of each axis in turn, from the most significant
+// down, with axis 0 the most significant of each group.
+func (g grid) interleave(cell []uint64) uint64 {
+	var index uint64
+	for b := int(g.bits) - 1; b >= 0; b-- {
+		for _, c := range cell {
+			index = index<<1 | (c>>uint(b))&1
+		}
+	}
+	return index
+}
+
+func (g grid) deinterleave(index uint64) []uint64 {
+	cell := make([]uint64, g.dims)
+	for b := uint(0); b < g.bits; b++ {
+		for axis := g.dims - 1; axis >= 0; axis-- {
+			cell[axis] |= (index & 1) << b
+			index >>= 1
+		}
+	}
+	return cell
+}
+
+// Morton is the Z-order curve, which interleaves the bits of the cell's
+// coordinates. It is the cheaper curve to compute, but jumps across the
+// grid between the sub-cubes it visits.
+type Morton struct {
+	grid
+}
+
+// NewMorton returns the Morton curve through a grid of dims dimensions with
+// 2^bits cells along each axis.
+func NewMorton(dims int, bits uint) (*Morton, error) {
+	g, err := newGrid(dims, bits)
+	if err != nil {
+		return nil, err
+	}
+	return &Morton{g}, nil
+}
+
+// Encode returns the position of the cell along the curve.
+func (m *Morton) Encode(cell []uint64) uint64 {
+	return m.interleave(cell)
+}
+
+// Decode returns the cell at a position along the curve.
+func (m *Morton) Decode(index uint64) []uint64 {
+	return m.deinterleave(index)
+}
+
+// Hilbert is the Hilbert curve, which only ever steps between neighbouring
+// cells, so it keeps nearby positions closer together than Morton order.
+// It follows Skilling, "Programming the Hilbert curve" (2004), which
+// transforms the coordinates in place so that interleaving their bits gives
+// the Hilbert index.
+type Hilbert struct {
+	grid
+}
+
+// NewHilbert returns the Hilbert curve through a grid of dims dimensions
+// with 2^bits cells along each axis.
+func NewHilbert(dims int, bits uint) (*Hilbert, error) {
+	g, err := newGrid(dims, bits)
+	if err != nil {
+		return nil, err
+	}
+	return &Hilbert{g}, nil
+}
+
+// Encode returns the position of the cell along the curve.
+func (h *Hilbert) Encode(cell []uint64) uint64 {
+	x := append([]uint64(nil), cell...)
+	top := uint64(1) << (h.bits - 1)
+	for q := top; q > 1; q >>= 1 { // undo the inverse transform
+		p := q - 1
+		for i := range x {
+			if x[i]&q != 0 {
+				x[0] ^= p // invert
+			} else {
+				t := (x[0] ^ x[i]) & p // exchange
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+	for i := 1; i < len(x); i++ { // Gray encode
+		x[i] ^= x[i-1]
+	}
+	var t uint64
+	for q := top; q > 1; q >>= 1 {
+		if x[len(x)-1]&q != 0 {
+			t ^= q - 1
+		}
+	}
+	for i := range x {
+		x[i] ^= t
+	}
+	return h.interleave(x)
+}
+
+// Decode returns the cell at a position along the curve.
+func (h *Hilbert) Decode(index uint64) []uint64 {
+	x := h.deinterleave(index)
+	t := x[len(x)-1] >> 1 // Gray decode
+	for i := len(x) - 1; i > 0; i-- {
+		x[i] ^= x[i-1]
+	}
+	x[0] ^= t
+	for q := uint64(2); q != uint64(1)<<h.bits; q <<= 1 { // undo the excess work
+		p := q - 1
+		for i := len(x) - 1; i >= 0; i-- {
+			if x[i]&q != 0 {
+				x[0] ^= p
+			} else {
+				t := (x[0] ^ x[i]) & p
+				x[0] ^= t
+				x[i] ^= t
+			}
+		}
+	}
+	return x
+}
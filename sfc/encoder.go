@@ -0,0 +1,167 @@
+package sfc
+
+import (
+	"math"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Encoder places Datapoints on a Curve by quantising each of their values
+// within fixed bounds to one of the 2^Bits cells along its axis. Values
+// outside the bounds are clamped to the nearest cell, so keys stay stable as
+// Datapoints come and go, as sharding keys must.
+type Encoder struct {
+	curve  Curve
+	bounds []kdtree.Range
+}
+
+// NewEncoder returns an Encoder for the curve over the given bounds, one
+// Range per axis.
+func NewEncoder(curve Curve, bounds []kdtree.Range) (*Encoder, error) {
+	if len(bounds) != curve.Dims() {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	return &Encoder{curve: curve, bounds: append([]kdtree.Range(nil), bounds...)}, nil
+}
+
+// Curve returns the curve the Encoder places Datapoints on.
+func (e *Encoder) Curve() Curve {
+	return e.curve
+}
+
+// Bounds returns the bounds over which the Encoder quantises.
+func (e *Encoder) Bounds() []kdtree.Range {
+	return append([]kdtree.Range(nil), e.bounds...)
+}
+
+// quantise returns the cell along axis holding the value v.
+func (e *Encoder) quantise(axis int, v float64) uint64 {
+	r := e.bounds[axis]
+	if !(r.Max() > r.Min()) || v <= r.Min() {
+		return 0
+	}
+	last := mask(e.curve.Bits())
+	cell := (v - r.Min()) / (r.Max() - r.Min()) * math.Ldexp(1, int(e.curve.Bits()))
+	if cell >= float64(last) {
+		return last
+	}
+	return uint64(cell)
+}
+
+// Cell returns the coordinates of the grid cell holding a Datapoint.
+func (e *Encoder) Cell(d *kdtree.Datapoint) ([]uint64, error) {
+	if d == nil || d.Dimensionality() != len(e.bounds) {
+		return nil, kdtree.ErrDimensionMismatch
+	}
+	cell := make([]uint64, len(e.bounds))
+	for axis := range cell {
+		cell[axis] = e.quantise(axis, d.At(axis))
+	}
+	return cell, nil
+}
+
+// Encode returns the position along the curve of the cell holding a
+// Datapoint.
+func (e *Encoder) Encode(d *kdtree.Datapoint) (uint64, error) {
+	cell, err := e.Cell(d)
+	if err != nil {
+		return 0, err
+	}
+	return e.curve.Encode(cell), nil
+}
+
+// Decode returns a Datapoint, with no data, at the centre of the cell at a
+// position along the curve.
+func (e *Encoder) Decode(index uint64) *kdtree.Datapoint {
+	cell := e.curve.Decode(index)
+	set := make([]float64, len(cell))
+	for axis, c := range cell {
+		r := e.bounds[axis]
+		set[axis] = r.Min() + (float64(c)+0.5)*math.Ldexp(r.Max()-r.Min(), -int(e.curve.Bits()))
+	}
+	return kdtree.NewDatapoint(nil, set)
+}
+
+// Sort orders ds in place along the curve. Datapoints in the same cell keep
+// their relative order.
+func (e *Encoder) Sort(ds kdtree.Datapoints) error {
+	keys := make([]uint64, len(ds))
+	for i, d := range ds {
+		key, err := e.Encode(d)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+	sort.Stable(byKey{ds, keys})
+	return nil
+}
+
+type byKey struct {
+	ds   kdtree.Datapoints
+	keys []uint64
+}
+
+func (b byKey) Len() int           { return len(b.ds) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.ds[i], b.ds[j] = b.ds[j], b.ds[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// Sort orders ds in place along the curve, quantised over the bounding box
+// of ds itself. All Datapoints must share the curve's dimensionality.
+func Sort(ds kdtree.Datapoints, curve Curve) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	e, err := NewEncoder(curve, boundsOf(ds))
+	if err != nil {
+		return err
+	}
+	return e.Sort(ds)
+}
+
+// Build is a convenience wrapper which sorts ds in place along the curve and
+// then calls kdtree.Build over it. kdtree.Build keeps the order of the
+// Datapoints it partitions, so the slice of *Datapoint held by every Branch
+// lists neighbouring Datapoints next to one another; the Datapoints
+// themselves stay wherever they were allocated. Median re-sorts each Branch
+// along its axis, undoing the curve order, so LazyAverage or Mean suit this
+// best.
+func Build(ds kdtree.Datapoints, curve Curve, pivotDef kdtree.PivotFunc) (*kdtree.Branch, error) {
+	if err := Sort(ds, curve); err != nil {
+		return nil, err
+	}
+	return kdtree.Build(ds, 0, pivotDef), nil
+}
+
+// boundsOf returns the bounding box of ds, or nil if the Datapoints differ
+// in dimensionality.
+func boundsOf(ds kdtree.Datapoints) []kdtree.Range {
+	dims := ds[0].Dimensionality()
+	lo, hi := append([]float64(nil), ds[0].Set()...), append([]float64(nil), ds[0].Set()...)
+	for _, d := range ds[1:] {
+		if d.Dimensionality() != dims {
+			return nil
+		}
+		for axis := 0; axis < dims; axis++ {
+			if v := d.At(axis); v < lo[axis] {
+				lo[axis] = v
+			} else if v > hi[axis] {
+				hi[axis] = v
+			}
+		}
+	}
+	bounds := make([]kdtree.Range, dims)
+	for axis := range bounds {
+		bounds[axis] = kdtree.NewRange(lo[axis], hi[axis])
+	}
+	return bounds
+}
+
+// mask returns the lowest s bits set, for s up to 64.
+func mask(s uint) uint64 {
+	return uint64(1)<<s - 1
+}
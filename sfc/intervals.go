@@ -0,0 +1,98 @@
+package sfc
+
+import (
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// Interval is an inclusive run of positions along a curve.
+type Interval struct {
+	Lo, Hi uint64
+}
+
+// Intervals decomposes a query box, one Range per axis, into the sorted,
+// disjoint runs of the curve which cover every cell it overlaps, so that a
+// store keyed by Encode can answer the query with a handful of range scans.
+// Cells only partly inside the box are included, so the Datapoints found
+// should still be checked against it. When maxIntervals is positive the
+// runs separated by the smallest gaps are merged until no more than
+// maxIntervals remain, which trades extra cells scanned for fewer scans.
+// A box which misses the Encoder's bounds gives no intervals.
+func (e *Encoder) Intervals(box []kdtree.Range, maxIntervals int) []Interval {
+	if len(box) != len(e.bounds) {
+		return nil
+	}
+	lo, hi := make([]uint64, len(box)), make([]uint64, len(box))
+	for axis, r := range box {
+		if r.Max() < e.bounds[axis].Min() || r.Min() > e.bounds[axis].Max() || r.Max() < r.Min() {
+			return nil
+		}
+		lo[axis], hi[axis] = e.quantise(axis, r.Min()), e.quantise(axis, r.Max())
+	}
+	var runs []Interval
+	e.descend(0, e.curve.Bits(), lo, hi, &runs)
+	if maxIntervals > 0 && len(runs) > maxIntervals {
+		runs = coarsen(runs, maxIntervals)
+	}
+	return runs
+}
+
+// descend adds the runs within the aligned sub-cube of 2^level cells per
+// side whose run of the curve starts at start.
+func (e *Encoder) descend(start uint64, level uint, lo, hi []uint64, runs *[]Interval) {
+	corner := e.curve.Decode(start)
+	side := mask(level)
+	contained := true
+	for axis, c := range corner {
+		c &^= side
+		if c+side < lo[axis] || c > hi[axis] {
+			return
+		}
+		if c < lo[axis] || c+side > hi[axis] {
+			contained = false
+		}
+	}
+	span := uint(e.curve.Dims()) * level
+	if contained {
+		run := Interval{start, start + mask(span)}
+		if n := len(*runs); n > 0 && (*runs)[n-1].Hi+1 == run.Lo {
+			(*runs)[n-1].Hi = run.Hi
+		} else {
+			*runs = append(*runs, run)
+		}
+		return
+	}
+	child := span - uint(e.curve.Dims())
+	last := mask(uint(e.curve.Dims()))
+	for c := uint64(0); ; c++ {
+		e.descend(start+c<<child, level-1, lo, hi, runs)
+		if c == last {
+			break
+		}
+	}
+}
+
+// coarsen merges the runs either side of all but the widest n-1 gaps.
+func coarsen(runs []Interval, n int) []Interval {
+	gaps := make([]int, len(runs)-1)
+	for i := range gaps {
+		gaps[i] = i
+	}
+	sort.SliceStable(gaps, func(i, j int) bool {
+		return runs[gaps[i]+1].Lo-runs[gaps[i]].Hi > runs[gaps[j]+1].Lo-runs[gaps[j]].Hi
+	})
+	kept := make([]bool, len(runs)-1)
+	for _, g := range gaps[:n-1] {
+		kept[g] = true
+	}
+	merged := []Interval{runs[0]}
+	for i, run := range runs[1:] {
+		if kept[i] {
+			merged = append(merged, run)
+		} else {
+			merged[len(merged)-1].Hi = run.Hi
+		}
+	}
+	return merged
+}
@@ -0,0 +1,193 @@
+package sfc
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func Test_SFC_Round_Trip(t *testing.T) {
+	for _, size := range []struct {
+		dims int
+		bits uint
+	}{{1, 5}, {2, 4}, {3, 3}, {4, 2}, {2, 32}, {1, 64}} {
+		morton, _ := NewMorton(size.dims, size.bits)
+		hilbert, _ := NewHilbert(size.dims, size.bits)
+		for _, curve := range []Curve{morton, hilbert} {
+			cells := uint64(1) << (uint(size.dims) * size.bits)
+			r := rand.New(rand.NewSource(73))
+			for i := 0; i < 1000; i++ {
+				index := uint64(i)
+				if cells == 0 || cells > 1000 {
+					index = r.Uint64() & mask(uint(size.dims)*size.bits)
+				} else if index >= cells {
+					break
+				}
+				if got := curve.Encode(curve.Decode(index)); got != index {
+					t.Fatal(size, ` want: `, index, `
+					got: `, got)
+				}
+			}
+		}
+	}
+}
+
+func Test_SFC_Morton_Order(t *testing.T) {
+	morton, _ := NewMorton(2, 2)
+	want := [][]uint64{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}}
+	for i, cell := range want {
+		if got := morton.Decode(uint64(i)); !reflect.DeepEqual(got, cell) {
+			t.Error(`want: `, cell, `
+			got: `, got)
+		}
+	}
+}
+
+func Test_SFC_Hilbert_Adjacency(t *testing.T) {
+	for dims := 1; dims <= 4; dims++ {
+		hilbert, _ := NewHilbert(dims, 3)
+		previous := hilbert.Decode(0)
+		seen := map[uint64]bool{hilbert.Encode(previous): true}
+		for i := uint64(1); i < uint64(1)<<(3*uint(dims)); i++ {
+			cell := hilbert.Decode(i)
+			steps := uint64(0)
+			for axis := range cell {
+				if cell[axis] > previous[axis] {
+					steps += cell[axis] - previous[axis]
+				} else {
+					steps += previous[axis] - cell[axis]
+				}
+			}
+			if steps != 1 {
+				t.Fatal(dims, `-D step `, i, ` want: one cell
+				got: `, previous, ` to `, cell)
+			}
+			seen[hilbert.Encode(cell)] = true
+			previous = cell
+		}
+		if len(seen) != 1<<(3*uint(dims)) {
+			t.Error(`the curve should visit every cell once`)
+		}
+	}
+}
+
+func Test_SFC_Intervals_Match_Brute_Force(t *testing.T) {
+	r := rand.New(rand.NewSource(73))
+	bounds := []kdtree.Range{kdtree.NewRange(0, 16), kdtree.NewRange(0, 16), kdtree.NewRange(0, 16)}
+	morton, _ := NewMorton(3, 4)
+	hilbert, _ := NewHilbert(3, 4)
+	for _, curve := range []Curve{morton, hilbert} {
+		e, _ := NewEncoder(curve, bounds)
+		for q := 0; q < 50; q++ {
+			box := make([]kdtree.Range, 3)
+			for axis := range box {
+				a, b := r.Float64()*20-2, r.Float64()*20-2
+				if a > b {
+					a, b = b, a
+				}
+				box[axis] = kdtree.NewRange(a, b)
+			}
+			runs := e.Intervals(box, 0)
+			covered := map[uint64]bool{}
+			for i, run := range runs {
+				if run.Lo > run.Hi || i > 0 && runs[i-1].Hi+1 >= run.Lo {
+					t.Fatal(`runs should be sorted, disjoint and apart: `, runs)
+				}
+				for k := run.Lo; k <= run.Hi; k++ {
+					covered[k] = true
+				}
+			}
+			for k := uint64(0); k < 1<<12; k++ {
+				cell := curve.Decode(k)
+				inside := true
+				for axis, rb := range box {
+					lo, hi := clampCell(rb.Min()), clampCell(rb.Max())
+					if rb.Max() < 0 || rb.Min() > 16 || cell[axis] < lo || cell[axis] > hi {
+						inside = false
+					}
+				}
+				if inside != covered[k] {
+					t.Fatal(`cell `, cell, ` in box `, box, ` want: `, inside, `
+					got: `, covered[k])
+				}
+			}
+
+			if len(runs) > 3 {
+				coarse := e.Intervals(box, 3)
+				if len(coarse) != 3 || coarse[0].Lo != runs[0].Lo || coarse[2].Hi != runs[len(runs)-1].Hi {
+					t.Error(`want: 3 intervals spanning `, runs[0].Lo, runs[len(runs)-1].Hi, `
+					got: `, coarse)
+				}
+			}
+		}
+		if e.Intervals([]kdtree.Range{kdtree.NewRange(20, 30), kdtree.NewRange(0, 1), kdtree.NewRange(0, 1)}, 0) != nil {
+			t.Error(`a box outside the bounds should have no intervals`)
+		}
+	}
+}
+
+// clampCell returns the cell of 16 unit cells from 0 holding v.
+func clampCell(v float64) uint64 {
+	switch {
+	case v < 0:
+		return 0
+	case v >= 15:
+		return 15
+	}
+	return uint64(v)
+}
+
+func Test_SFC_Sort_And_Build(t *testing.T) {
+	r := rand.New(rand.NewSource(73))
+	ds := make(kdtree.Datapoints, 2000)
+	for i := range ds {
+		ds[i] = kdtree.NewDatapoint(i, []float64{r.Float64() * 100, r.Float64() * 50})
+	}
+	hilbert, _ := NewHilbert(2, 16)
+	root, err := Build(ds, hilbert, kdtree.Mean)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := NewEncoder(hilbert, boundsOf(ds))
+	for i := 1; i < len(ds); i++ {
+		a, _ := e.Encode(ds[i-1])
+		b, _ := e.Encode(ds[i])
+		if a > b {
+			t.Fatal(`want: Datapoints in curve order
+			got: `, a, ` before `, b)
+		}
+	}
+	if root == nil || len(root.Datapoints) != len(ds) {
+		t.Error(`Build should hold every Datapoint`)
+	}
+	for _, d := range ds[:50] {
+		if kdtree.NN(root, d) != d {
+			t.Error(`want: `, d, `
+			got: `, kdtree.NN(root, d))
+		}
+	}
+}
+
+func Test_SFC_Errors(t *testing.T) {
+	for _, size := range []struct {
+		dims int
+		bits uint
+	}{{0, 8}, {2, 0}, {3, 22}, {65, 1}} {
+		if _, err := NewHilbert(size.dims, size.bits); err != ErrTooManyBits {
+			t.Error(size, ` want: `, ErrTooManyBits, `
+			got: `, err)
+		}
+	}
+	morton, _ := NewMorton(2, 8)
+	if _, err := NewEncoder(morton, []kdtree.Range{kdtree.NewRange(0, 1)}); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	mixed := kdtree.Datapoints{kdtree.NewDatapoint(nil, []float64{1, 2}), kdtree.NewDatapoint(nil, []float64{1, 2, 3})}
+	if err := Sort(mixed, morton); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+}
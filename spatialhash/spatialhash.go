@@ -0,0 +1,351 @@
+package spatialhash
+
+import (
+	"errors"
+	"math"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+// MaxDims is the greatest dimensionality a Grid can index.
+const MaxDims = 3
+
+// DefaultCellSize is used when a non-positive cell size is given.
+const DefaultCellSize = 1.0
+
+// ErrTooManyDims is returned when a Datapoint has more than MaxDims dimensions.
+var ErrTooManyDims = errors.New("spatialhash: datapoint has more than 3 dimensions")
+
+// cell is the integer coordinates of a grid cell, unused axes left at zero.
+type cell [MaxDims]int64
+
+// slot records where a Datapoint is held, so it can be found in O(1).
+type slot struct {
+	key   cell
+	index int
+}
+
+// Grid is a uniform grid of cubic cells hashed by their integer coordinates,
+// implementing kdtree.SpatialIndex for up to three dimensions. Only occupied
+// cells are stored, so the space covered is unbounded. Insert, Delete and
+// Move are O(1); a query inspects only the cells it overlaps, so it is
+// fastest when the cell size is close to the typical query radius and the
+// Datapoints are spread roughly evenly, as with particles or game entities
+// in a dense simulation with constant churn.
+type Grid struct {
+	cellSize float64
+	cells    map[cell]kdtree.Datapoints
+	slots    map[*kdtree.Datapoint]slot
+	dims     int
+	lo, hi   cell // the extent of every cell occupied so far
+}
+
+var _ kdtree.SpatialIndex = (*Grid)(nil)
+
+// New returns an empty Grid with cells of the given side length
+// (DefaultCellSize when non-positive).
+func New(cellSize float64) *Grid {
+	if cellSize <= 0 {
+		cellSize = DefaultCellSize
+	}
+	return &Grid{
+		cellSize: cellSize,
+		cells:    make(map[cell]kdtree.Datapoints),
+		slots:    make(map[*kdtree.Datapoint]slot),
+	}
+}
+
+// Build returns a Grid with cells of the given side length holding every
+// Datapoint in ds. All Datapoints must share the same dimensionality.
+func Build(ds kdtree.Datapoints, cellSize float64) (*Grid, error) {
+	g := New(cellSize)
+	for _, d := range ds {
+		if err := g.Insert(d); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// CellSize returns the side length of each cell.
+func (g *Grid) CellSize() float64 {
+	return g.cellSize
+}
+
+// Len returns the number of Datapoints held.
+func (g *Grid) Len() int {
+	return len(g.slots)
+}
+
+// Dims returns the dimensionality of the Datapoints held,
+// or 0 if nothing has been inserted yet.
+func (g *Grid) Dims() int {
+	return g.dims
+}
+
+// keyOf returns the cell containing the values given.
+func (g *Grid) keyOf(f func(axis int) float64) cell {
+	var key cell
+	for axis := 0; axis < g.dims; axis++ {
+		key[axis] = int64(math.Floor(f(axis) / g.cellSize))
+	}
+	return key
+}
+
+// Insert adds a Datapoint to the cell containing it.
+// Inserting a Datapoint which is already held has no effect.
+func (g *Grid) Insert(d *kdtree.Datapoint) error {
+	if d == nil {
+		return kdtree.ErrDimensionMismatch
+	}
+	if d.Dimensionality() > MaxDims {
+		return ErrTooManyDims
+	}
+	if len(g.slots) == 0 && g.dims == 0 {
+		g.dims = d.Dimensionality()
+	}
+	if d.Dimensionality() != g.dims {
+		return kdtree.ErrDimensionMismatch
+	}
+	if _, held := g.slots[d]; held {
+		return nil
+	}
+	key := g.keyOf(d.At)
+	if len(g.slots) == 0 {
+		g.lo, g.hi = key, key
+	}
+	for axis := 0; axis < g.dims; axis++ {
+		if key[axis] < g.lo[axis] {
+			g.lo[axis] = key[axis]
+		}
+		if key[axis] > g.hi[axis] {
+			g.hi[axis] = key[axis]
+		}
+	}
+	g.slots[d] = slot{key, len(g.cells[key])}
+	g.cells[key] = append(g.cells[key], d)
+	return nil
+}
+
+// Delete removes the given Datapoint, reporting whether it was held.
+func (g *Grid) Delete(d *kdtree.Datapoint) bool {
+	s, held := g.slots[d]
+	if !held {
+		return false
+	}
+	delete(g.slots, d)
+	ds := g.cells[s.key]
+	last := len(ds) - 1
+	if s.index != last {
+		ds[s.index] = ds[last]
+		g.slots[ds[s.index]] = slot{s.key, s.index}
+	}
+	ds[last] = nil
+	if last == 0 {
+		delete(g.cells, s.key)
+	} else {
+		g.cells[s.key] = ds[:last]
+	}
+	return true
+}
+
+// Move replaces a held Datapoint with another, typically its successor at a
+// new position. Since a Datapoint's values cannot be changed in place, this
+// is how a moving object is tracked; it costs O(1) like Insert and Delete.
+// It reports false, leaving the Grid unchanged, if from is not held or to
+// is already held or cannot be inserted.
+func (g *Grid) Move(from, to *kdtree.Datapoint) bool {
+	if _, held := g.slots[from]; !held || to == nil || to.Dimensionality() != g.dims {
+		return false
+	}
+	if _, held := g.slots[to]; held {
+		return false
+	}
+	g.Delete(from)
+	g.Insert(to)
+	return true
+}
+
+// NN returns the exact nearest neighbour of the target, or nil if the Grid is empty.
+func (g *Grid) NN(target *kdtree.Datapoint) *kdtree.Datapoint {
+	ds := g.KNN(target, 1)
+	if len(ds) == 0 {
+		return nil
+	}
+	return ds[0]
+}
+
+// KNN returns the k exact nearest neighbours of the target, nearest first.
+// Cells are searched in square shells of growing size about the target's own
+// cell, stopping as soon as no unsearched cell could hold anything nearer.
+func (g *Grid) KNN(target *kdtree.Datapoint, k int) kdtree.Datapoints {
+	if len(g.slots) == 0 || k <= 0 || target == nil || target.Dimensionality() != g.dims {
+		return nil
+	}
+	set := nearest.New(k)
+	centre := g.keyOf(target.At)
+	for shell := int64(0); ; shell++ {
+		if g.shellCells(shell) > len(g.cells) {
+			// the shell is now larger than the occupied cells themselves
+			set = nearest.New(k)
+			for _, ds := range g.cells {
+				push(set, target, ds)
+			}
+			break
+		}
+		var lo, hi cell
+		for axis := 0; axis < g.dims; axis++ {
+			lo[axis], hi[axis] = centre[axis]-shell, centre[axis]+shell
+		}
+		g.each(lo, hi, func(key cell) {
+			if chebyshev(key, centre) == shell {
+				push(set, target, g.cells[key])
+			}
+		})
+		reach := float64(shell) * g.cellSize // nearest any further shell can be
+		if (set.Full() && set.Worst() <= reach*reach) || g.covers(lo, hi) {
+			break
+		}
+	}
+	return sortedDatapoints(set)
+}
+
+// shellCells returns the number of cells in the shell at Chebyshev distance s.
+func (g *Grid) shellCells(s int64) int {
+	if s == 0 {
+		return 1
+	}
+	outer, inner := 1, 1
+	for axis := 0; axis < g.dims; axis++ {
+		outer *= int(2*s + 1)
+		inner *= int(2*s - 1)
+	}
+	return outer - inner
+}
+
+// covers reports whether [lo, hi] includes every cell occupied so far.
+func (g *Grid) covers(lo, hi cell) bool {
+	for axis := 0; axis < g.dims; axis++ {
+		if lo[axis] > g.lo[axis] || hi[axis] < g.hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// Range returns every Datapoint lying within the bounds (inclusive).
+func (g *Grid) Range(bounds []kdtree.Range) kdtree.Datapoints {
+	if len(g.slots) == 0 || len(bounds) != g.dims {
+		return nil
+	}
+	lo := g.keyOf(func(axis int) float64 { return bounds[axis].Min() })
+	hi := g.keyOf(func(axis int) float64 { return bounds[axis].Max() })
+	var found kdtree.Datapoints
+	visit := func(key cell) {
+		for _, d := range g.cells[key] {
+			if kdtree.InBounds(d, bounds) {
+				found = append(found, d)
+			}
+		}
+	}
+	if g.boxCells(lo, hi) > len(g.cells) {
+		for key := range g.cells {
+			if within(key, lo, hi, g.dims) {
+				visit(key)
+			}
+		}
+		return found
+	}
+	g.each(lo, hi, visit)
+	return found
+}
+
+// Radius returns every Datapoint within Euclidean distance r of the target (inclusive).
+func (g *Grid) Radius(target *kdtree.Datapoint, r float64) kdtree.Datapoints {
+	if len(g.slots) == 0 || target == nil || target.Dimensionality() != g.dims || r < 0 {
+		return nil
+	}
+	bounds := make([]kdtree.Range, g.dims)
+	for axis := range bounds {
+		bounds[axis] = kdtree.NewRange(target.At(axis)-r, target.At(axis)+r)
+	}
+	var found kdtree.Datapoints
+	for _, d := range g.Range(bounds) {
+		if kdtree.DistanceSq(target, d) <= r*r {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+// boxCells returns the number of cells in [lo, hi], saturating rather than overflowing.
+func (g *Grid) boxCells(lo, hi cell) int {
+	n := 1.0
+	for axis := 0; axis < g.dims; axis++ {
+		n *= float64(hi[axis]-lo[axis]) + 1
+	}
+	if n > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(n)
+}
+
+// each calls fn with every occupied cell in [lo, hi].
+func (g *Grid) each(lo, hi cell, fn func(cell)) {
+	key := lo
+	for {
+		if _, occupied := g.cells[key]; occupied {
+			fn(key)
+		}
+		axis := 0
+		for ; axis < g.dims; axis++ {
+			if key[axis] < hi[axis] {
+				key[axis]++
+				break
+			}
+			key[axis] = lo[axis]
+		}
+		if axis == g.dims {
+			return
+		}
+	}
+}
+
+func within(key, lo, hi cell, dims int) bool {
+	for axis := 0; axis < dims; axis++ {
+		if key[axis] < lo[axis] || key[axis] > hi[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+func chebyshev(a, b cell) int64 {
+	var d int64
+	for axis := range a {
+		diff := a[axis] - b[axis]
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > d {
+			d = diff
+		}
+	}
+	return d
+}
+
+func push(set *nearest.Set, target *kdtree.Datapoint, ds kdtree.Datapoints) {
+	for _, d := range ds {
+		set.Push(d, kdtree.DistanceSq(target, d))
+	}
+}
+
+func sortedDatapoints(set *nearest.Set) kdtree.Datapoints {
+	sorted := set.Sorted()
+	ds := make(kdtree.Datapoints, len(sorted))
+	for i := range sorted {
+		ds[i] = sorted[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
@@ -0,0 +1,142 @@
+package spatialhash
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/internal/testutil"
+	"github.com/benjamin-rood/goeometric/kdtree"
+)
+
+func Test_SpatialHash_Matches_Linear(t *testing.T) {
+	r := rand.New(rand.NewSource(63))
+	for dims := 1; dims <= MaxDims; dims++ {
+		for _, cellSize := range []float64{0.5, 4, 100} {
+			ds := testutil.RandomDatapoints(r, 600, dims, testutil.Uniform(-20, 20))
+			grid, err := Build(ds[:400], cellSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			linear, _ := kdtree.NewLinear(ds[:400])
+			for i, d := range ds[400:] {
+				if i%2 == 0 {
+					grid.Insert(d)
+					linear.Insert(d)
+					continue
+				}
+				if !grid.Move(ds[i], d) {
+					t.Fatal(`Move failed for `, ds[i])
+				}
+				linear.Delete(ds[i])
+				linear.Insert(d)
+			}
+			for i := 0; i < 400; i += 5 {
+				if grid.Delete(ds[i]) != linear.Delete(ds[i]) {
+					t.Fatal(`Delete disagreed for `, ds[i])
+				}
+			}
+			if grid.Len() != linear.Len() || grid.Dims() != dims {
+				t.Fatal(`want: `, linear.Len(), dims, `
+				got: `, grid.Len(), grid.Dims())
+			}
+
+			for q := 0; q < 40; q++ {
+				target := testutil.RandomDatapoints(r, 1, dims, testutil.Uniform(-30, 30))[0] // some targets lie outside the data
+				got, want := grid.KNN(target, 8), linear.KNN(target, 8)
+				for i := range want {
+					if i >= len(got) || kdtree.DistanceSq(target, got[i]) != kdtree.DistanceSq(target, want[i]) {
+						t.Fatal(`cellSize=`, cellSize, ` KNN want: `, want.PointsSetString(), `
+						got: `, got.PointsSetString())
+					}
+				}
+				bounds := make([]kdtree.Range, dims)
+				for axis := range bounds {
+					lo := (r.Float64() - 0.5) * 50
+					bounds[axis] = kdtree.NewRange(lo, lo+r.Float64()*15)
+				}
+				if !testutil.SameMembers(grid.Range(bounds), linear.Range(bounds)) {
+					t.Error(`Range disagreed for `, bounds)
+				}
+				var within kdtree.Datapoints
+				for _, d := range linear.KNN(target, linear.Len()) {
+					if kdtree.DistanceSq(target, d) <= 25 {
+						within = append(within, d)
+					}
+				}
+				if !testutil.SameMembers(grid.Radius(target, 5), within) {
+					t.Error(`Radius disagreed for `, target)
+				}
+			}
+		}
+	}
+}
+
+func Test_SpatialHash_Errors(t *testing.T) {
+	grid := New(0)
+	p := kdtree.NewDatapoint(nil, []float64{1, 2})
+	if grid.CellSize() != DefaultCellSize || grid.NN(p) != nil {
+		t.Error(`empty Grid should hold nothing`)
+	}
+	if err := grid.Insert(kdtree.NewDatapoint(nil, []float64{1, 2, 3, 4})); err != ErrTooManyDims {
+		t.Error(`want: `, ErrTooManyDims, `
+		got: `, err)
+	}
+	grid.Insert(p)
+	grid.Insert(p)
+	if grid.NN(nil) != nil || grid.KNN(nil, 1) != nil || grid.Radius(nil, 1) != nil {
+		t.Error(`a nil target should find nothing`)
+	}
+	if err := grid.Insert(kdtree.NewDatapoint(nil, []float64{1})); err != kdtree.ErrDimensionMismatch {
+		t.Error(`want: `, kdtree.ErrDimensionMismatch, `
+		got: `, err)
+	}
+	if grid.Len() != 1 || grid.Move(p, p) || !grid.Delete(p) || grid.Delete(p) || grid.Move(p, p) {
+		t.Error(`Grid should hold p exactly once`)
+	}
+}
+
+// The benchmarks compare the cost of moving every Datapoint by a small step
+// and then answering a radius query, as in a game or particle simulation.
+// Against the node-valued k-d tree, which is the cheapest k-d tree to update,
+// the Grid is level at a thousand Datapoints and slightly ahead at ten
+// thousand; its updates stay O(1) however long the churn goes on, whereas the
+// k-d tree is never rebalanced as its Datapoints drift.
+
+func Benchmark_SpatialHash_Churn(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		r := rand.New(rand.NewSource(1))
+		ds := testutil.RandomDatapoints(r, n, 2, testutil.Uniform(-50, 50))
+		grid, _ := Build(ds, 2)
+		b.Run(fmt.Sprint("n=", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				j := i % n
+				next := kdtree.NewDatapoint(j, []float64{ds[j].At(0) + 0.1, ds[j].At(1) - 0.1})
+				grid.Move(ds[j], next)
+				ds[j] = next
+				grid.Radius(next, 2)
+			}
+		})
+	}
+}
+
+func Benchmark_KdTree_Churn(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		r := rand.New(rand.NewSource(1))
+		ds := testutil.RandomDatapoints(r, n, 2, testutil.Uniform(-50, 50))
+		tree, _ := kdtree.NewNodeTree(ds)
+		b.Run(fmt.Sprint("n=", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				j := i % n
+				next := kdtree.NewDatapoint(j, []float64{ds[j].At(0) + 0.1, ds[j].At(1) - 0.1})
+				tree.Delete(ds[j])
+				tree.Insert(next)
+				ds[j] = next
+				tree.Range([]kdtree.Range{
+					kdtree.NewRange(next.At(0)-2, next.At(0)+2),
+					kdtree.NewRange(next.At(1)-2, next.At(1)+2),
+				})
+			}
+		})
+	}
+}
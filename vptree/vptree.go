@@ -0,0 +1,166 @@
+package vptree
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/benjamin-rood/goeometric/internal/nearest"
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+// Tree is a vantage-point tree: a metric index which needs nothing but a
+// distance function between its items. Each node picks a vantage point and
+// a radius, the median distance to the other items beneath it; the nearer
+// half lies inside the radius and the rest outside, and searches prune either
+// half by the triangle inequality.
+type Tree struct {
+	root *node
+	dist metric.ItemFunc
+	size int
+}
+
+type node struct {
+	item            interface{}
+	radius          float64
+	inside, outside *node
+}
+
+// Neighbour is a search result: an item and its distance from the target.
+type Neighbour struct {
+	Item     interface{}
+	Distance float64
+}
+
+// Build constructs a Tree over items, which are not modified.
+// dist must be a true metric; see metric.ItemFunc. The seed seeds the random
+// choice of vantage points, so that a Tree can be rebuilt exactly.
+func Build(items []interface{}, dist metric.ItemFunc, seed int64) *Tree {
+	work := make([]interface{}, len(items))
+	copy(work, items)
+	t := &Tree{dist: dist, size: len(items)}
+	t.root = t.build(work, make([]float64, len(items)), rand.New(rand.NewSource(seed)))
+	return t
+}
+
+// FromDatapoints constructs a Tree over coordinate data using a Datapoint
+// metric, such as metric.Euclidean or metric.Manhattan.
+func FromDatapoints(ds kdtree.Datapoints, m metric.Func, seed int64) *Tree {
+	items := make([]interface{}, len(ds))
+	for i := range ds {
+		items[i] = ds[i]
+	}
+	return Build(items, m.Items(), seed)
+}
+
+func (t *Tree) build(items []interface{}, dists []float64, r *rand.Rand) *node {
+	if len(items) == 0 {
+		return nil
+	}
+	// a random vantage point avoids worst cases on sorted input
+	v := r.Intn(len(items))
+	items[0], items[v] = items[v], items[0]
+	n := &node{item: items[0]}
+	rest, dists := items[1:], dists[1:]
+	if len(rest) == 0 {
+		return n
+	}
+	for i := range rest {
+		dists[i] = t.dist(n.item, rest[i])
+	}
+	sort.Sort(byDistance{rest, dists})
+	mid := len(rest) / 2
+	n.radius = dists[mid]
+	for mid > 0 && dists[mid-1] == n.radius {
+		mid-- // everything at the radius goes outside
+	}
+	n.inside = t.build(rest[:mid], dists[:mid], r)
+	n.outside = t.build(rest[mid:], dists[mid:], r)
+	return n
+}
+
+// Len returns the number of items held.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// KNN returns the k items nearest the target, nearest first.
+func (t *Tree) KNN(target interface{}, k int) []Neighbour {
+	if t.root == nil || k <= 0 {
+		return nil
+	}
+	set := nearest.New(k)
+	t.knn(t.root, target, set)
+	sorted := set.Sorted()
+	ns := make([]Neighbour, len(sorted))
+	for i, c := range sorted {
+		ns[i] = Neighbour{c.Item, c.Dist}
+	}
+	return ns
+}
+
+func (t *Tree) knn(n *node, target interface{}, set *nearest.Set) {
+	if n == nil {
+		return
+	}
+	d := t.dist(target, n.item)
+	set.Push(n.item, d)
+	if d < n.radius {
+		t.knn(n.inside, target, set)
+		if d+set.Worst() >= n.radius {
+			t.knn(n.outside, target, set)
+		}
+		return
+	}
+	t.knn(n.outside, target, set)
+	if d-set.Worst() < n.radius {
+		t.knn(n.inside, target, set)
+	}
+}
+
+// Radius returns every item within distance r of the target (inclusive), nearest first.
+func (t *Tree) Radius(target interface{}, r float64) []Neighbour {
+	var found []Neighbour
+	t.radiusSearch(t.root, target, r, &found)
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].Distance < found[j].Distance
+	})
+	return found
+}
+
+func (t *Tree) radiusSearch(n *node, target interface{}, r float64, found *[]Neighbour) {
+	if n == nil {
+		return
+	}
+	d := t.dist(target, n.item)
+	if d <= r {
+		*found = append(*found, Neighbour{n.item, d})
+	}
+	if d-r < n.radius {
+		t.radiusSearch(n.inside, target, r, found)
+	}
+	if d+r >= n.radius {
+		t.radiusSearch(n.outside, target, r, found)
+	}
+}
+
+// Datapoints extracts the Datapoints from the results of a Tree built by FromDatapoints.
+func Datapoints(ns []Neighbour) kdtree.Datapoints {
+	ds := make(kdtree.Datapoints, len(ns))
+	for i := range ns {
+		ds[i] = ns[i].Item.(*kdtree.Datapoint)
+	}
+	return ds
+}
+
+type byDistance struct {
+	items []interface{}
+	dists []float64
+}
+
+func (b byDistance) Len() int           { return len(b.items) }
+func (b byDistance) Less(i, j int) bool { return b.dists[i] < b.dists[j] }
+func (b byDistance) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.dists[i], b.dists[j] = b.dists[j], b.dists[i]
+}
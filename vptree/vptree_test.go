@@ -0,0 +1,93 @@
+package vptree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/benjamin-rood/goeometric/kdtree"
+	"github.com/benjamin-rood/goeometric/metric"
+)
+
+var words = []interface{}{
+	"geode", "geometry", "geometric", "goemetric", "geology", "geodesic",
+	"kdtree", "quadtree", "octree", "rtree", "vptree", "tree", "trees",
+	"range", "ranges", "ring", "rings", "graph", "graphs", "giraffe",
+	"", "g", "go", "gopher", "golang", "goal", "gold", "golden",
+}
+
+func bruteForce(items []interface{}, target interface{}, dist metric.ItemFunc) []float64 {
+	ds := make([]float64, len(items))
+	for i := range items {
+		ds[i] = dist(target, items[i])
+	}
+	sort.Float64s(ds)
+	return ds
+}
+
+func Test_VPTree_Strings_Under_Edit_Distance(t *testing.T) {
+	tree := Build(words, metric.Levenshtein, 1)
+	for _, target := range []string{"geometry", "gopher", "treez", "graffe", "", "xyzzy"} {
+		want := bruteForce(words, target, metric.Levenshtein)
+		got := tree.KNN(target, 5)
+		for i := range got {
+			if got[i].Distance != want[i] {
+				t.Error(target, ` want: `, want[:5], `
+				got: `, got)
+				break
+			}
+		}
+		var within int
+		for _, d := range want {
+			if d <= 2 {
+				within++
+			}
+		}
+		if r := tree.Radius(target, 2); len(r) != within {
+			t.Error(target, ` Radius want: `, within, `
+			got: `, len(r))
+		}
+	}
+	if got := tree.KNN("goemetric", 1)[0]; got.Item != "goemetric" || got.Distance != 0 {
+		t.Error(`an item should be its own nearest neighbour`)
+	}
+	if again := Build(words, metric.Levenshtein, 1); again.root.item != tree.root.item || again.root.radius != tree.root.radius {
+		t.Error(`the same seed should choose the same vantage points`)
+	}
+}
+
+func Test_VPTree_Datapoints(t *testing.T) {
+	r := rand.New(rand.NewSource(59))
+	var ds kdtree.Datapoints
+	for i := 0; i < 600; i++ {
+		ds = append(ds, kdtree.NewDatapoint(i, []float64{float64(r.Intn(20)), float64(r.Intn(20)), float64(r.Intn(20))}))
+	}
+	items := make([]interface{}, len(ds))
+	for i := range ds {
+		items[i] = ds[i]
+	}
+	for _, m := range []metric.Func{metric.Euclidean, metric.Manhattan, metric.Chebyshev} {
+		tree := FromDatapoints(ds, m, 1)
+		for q := 0; q < 50; q++ {
+			target := kdtree.NewDatapoint(nil, []float64{r.Float64() * 20, r.Float64() * 20, r.Float64() * 20})
+			want := bruteForce(items, target, m.Items())
+			got := tree.KNN(target, 8)
+			for i := range got {
+				if got[i].Distance != want[i] {
+					t.Fatal(`KNN want: `, want[:8], `
+					got: `, got)
+				}
+			}
+			if len(Datapoints(got)) != 8 {
+				t.Error(`want 8 Datapoints`)
+			}
+		}
+	}
+}
+
+func Test_VPTree_Empty(t *testing.T) {
+	tree := Build(nil, metric.Levenshtein, 1)
+	if tree.KNN("geode", 3) != nil || tree.Radius("geode", 3) != nil || tree.Len() != 0 {
+		t.Error(`an empty Tree should find nothing`)
+	}
+}